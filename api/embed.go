@@ -0,0 +1,10 @@
+// Package api embeds the OpenAPI document of the public API so the binary does not depend
+// on files next to it.
+package api
+
+import _ "embed"
+
+// OpenAPI is api/openapi.yaml as of the build.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
@@ -0,0 +1,429 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: offerings/v1/offerings.proto
+
+// Offerings of the traveler service for internal callers. The data is the same as served
+// by the REST API under /api/v1/offerings.
+
+package offeringsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Special is a travel special offering.
+type Special struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Price float64                `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+	// ISO 4217 currency code, e.g. "USD".
+	Currency    string `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
+	Description string `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	// Absolute http(s) URLs of the images shown with the special.
+	HeroImages []string `protobuf:"bytes,6,rep,name=hero_images,json=heroImages,proto3" json:"hero_images,omitempty"`
+	// What the price includes, e.g. "Return flights".
+	Inclusions []string `protobuf:"bytes,7,rep,name=inclusions,proto3" json:"inclusions,omitempty"`
+	// Duration in nights; unset if the special has no fixed duration.
+	Nights        *int32 `protobuf:"varint,8,opt,name=nights,proto3,oneof" json:"nights,omitempty"`
+	DepartureCity string `protobuf:"bytes,9,opt,name=departure_city,json=departureCity,proto3" json:"departure_city,omitempty"`
+	// Id of the destination, or empty.
+	DestinationId string `protobuf:"bytes,10,opt,name=destination_id,json=destinationId,proto3" json:"destination_id,omitempty"`
+	// Lower-case tags such as "beach" or "family".
+	Tags          []string `protobuf:"bytes,11,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Special) Reset() {
+	*x = Special{}
+	mi := &file_offerings_v1_offerings_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Special) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Special) ProtoMessage() {}
+
+func (x *Special) ProtoReflect() protoreflect.Message {
+	mi := &file_offerings_v1_offerings_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Special.ProtoReflect.Descriptor instead.
+func (*Special) Descriptor() ([]byte, []int) {
+	return file_offerings_v1_offerings_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Special) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Special) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Special) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Special) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Special) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Special) GetHeroImages() []string {
+	if x != nil {
+		return x.HeroImages
+	}
+	return nil
+}
+
+func (x *Special) GetInclusions() []string {
+	if x != nil {
+		return x.Inclusions
+	}
+	return nil
+}
+
+func (x *Special) GetNights() int32 {
+	if x != nil && x.Nights != nil {
+		return *x.Nights
+	}
+	return 0
+}
+
+func (x *Special) GetDepartureCity() string {
+	if x != nil {
+		return x.DepartureCity
+	}
+	return ""
+}
+
+func (x *Special) GetDestinationId() string {
+	if x != nil {
+		return x.DestinationId
+	}
+	return ""
+}
+
+func (x *Special) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type ListSpecialsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Tags the specials must all carry; case-insensitive. Tags are up to 32 letters, digits
+	// and '-', and at most 10 are allowed; others give INVALID_ARGUMENT.
+	Tags          []string `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSpecialsRequest) Reset() {
+	*x = ListSpecialsRequest{}
+	mi := &file_offerings_v1_offerings_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSpecialsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSpecialsRequest) ProtoMessage() {}
+
+func (x *ListSpecialsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_offerings_v1_offerings_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSpecialsRequest.ProtoReflect.Descriptor instead.
+func (*ListSpecialsRequest) Descriptor() ([]byte, []int) {
+	return file_offerings_v1_offerings_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListSpecialsRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type ListSpecialsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Specials      []*Special             `protobuf:"bytes,1,rep,name=specials,proto3" json:"specials,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSpecialsResponse) Reset() {
+	*x = ListSpecialsResponse{}
+	mi := &file_offerings_v1_offerings_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSpecialsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSpecialsResponse) ProtoMessage() {}
+
+func (x *ListSpecialsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_offerings_v1_offerings_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSpecialsResponse.ProtoReflect.Descriptor instead.
+func (*ListSpecialsResponse) Descriptor() ([]byte, []int) {
+	return file_offerings_v1_offerings_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListSpecialsResponse) GetSpecials() []*Special {
+	if x != nil {
+		return x.Specials
+	}
+	return nil
+}
+
+type GetSpecialRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Id of the special, e.g. "sp-1001".
+	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSpecialRequest) Reset() {
+	*x = GetSpecialRequest{}
+	mi := &file_offerings_v1_offerings_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSpecialRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSpecialRequest) ProtoMessage() {}
+
+func (x *GetSpecialRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_offerings_v1_offerings_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSpecialRequest.ProtoReflect.Descriptor instead.
+func (*GetSpecialRequest) Descriptor() ([]byte, []int) {
+	return file_offerings_v1_offerings_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetSpecialRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetSpecialResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Special       *Special               `protobuf:"bytes,1,opt,name=special,proto3" json:"special,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSpecialResponse) Reset() {
+	*x = GetSpecialResponse{}
+	mi := &file_offerings_v1_offerings_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSpecialResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSpecialResponse) ProtoMessage() {}
+
+func (x *GetSpecialResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_offerings_v1_offerings_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSpecialResponse.ProtoReflect.Descriptor instead.
+func (*GetSpecialResponse) Descriptor() ([]byte, []int) {
+	return file_offerings_v1_offerings_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetSpecialResponse) GetSpecial() *Special {
+	if x != nil {
+		return x.Special
+	}
+	return nil
+}
+
+var File_offerings_v1_offerings_proto protoreflect.FileDescriptor
+
+const file_offerings_v1_offerings_proto_rawDesc = "" +
+	"\n" +
+	"\x1cofferings/v1/offerings.proto\x12\fofferings.v1\"\xcc\x02\n" +
+	"\aSpecial\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x01R\x05price\x12\x1a\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x12\x1f\n" +
+	"\vhero_images\x18\x06 \x03(\tR\n" +
+	"heroImages\x12\x1e\n" +
+	"\n" +
+	"inclusions\x18\a \x03(\tR\n" +
+	"inclusions\x12\x1b\n" +
+	"\x06nights\x18\b \x01(\x05H\x00R\x06nights\x88\x01\x01\x12%\n" +
+	"\x0edeparture_city\x18\t \x01(\tR\rdepartureCity\x12%\n" +
+	"\x0edestination_id\x18\n" +
+	" \x01(\tR\rdestinationId\x12\x12\n" +
+	"\x04tags\x18\v \x03(\tR\x04tagsB\t\n" +
+	"\a_nights\")\n" +
+	"\x13ListSpecialsRequest\x12\x12\n" +
+	"\x04tags\x18\x01 \x03(\tR\x04tags\"I\n" +
+	"\x14ListSpecialsResponse\x121\n" +
+	"\bspecials\x18\x01 \x03(\v2\x15.offerings.v1.SpecialR\bspecials\"#\n" +
+	"\x11GetSpecialRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"E\n" +
+	"\x12GetSpecialResponse\x12/\n" +
+	"\aspecial\x18\x01 \x01(\v2\x15.offerings.v1.SpecialR\aspecial2\xba\x01\n" +
+	"\x10OfferingsService\x12U\n" +
+	"\fListSpecials\x12!.offerings.v1.ListSpecialsRequest\x1a\".offerings.v1.ListSpecialsResponse\x12O\n" +
+	"\n" +
+	"GetSpecial\x12\x1f.offerings.v1.GetSpecialRequest\x1a .offerings.v1.GetSpecialResponseB'Z%traveler/api/offerings/v1;offeringsv1b\x06proto3"
+
+var (
+	file_offerings_v1_offerings_proto_rawDescOnce sync.Once
+	file_offerings_v1_offerings_proto_rawDescData []byte
+)
+
+func file_offerings_v1_offerings_proto_rawDescGZIP() []byte {
+	file_offerings_v1_offerings_proto_rawDescOnce.Do(func() {
+		file_offerings_v1_offerings_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_offerings_v1_offerings_proto_rawDesc), len(file_offerings_v1_offerings_proto_rawDesc)))
+	})
+	return file_offerings_v1_offerings_proto_rawDescData
+}
+
+var file_offerings_v1_offerings_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_offerings_v1_offerings_proto_goTypes = []any{
+	(*Special)(nil),              // 0: offerings.v1.Special
+	(*ListSpecialsRequest)(nil),  // 1: offerings.v1.ListSpecialsRequest
+	(*ListSpecialsResponse)(nil), // 2: offerings.v1.ListSpecialsResponse
+	(*GetSpecialRequest)(nil),    // 3: offerings.v1.GetSpecialRequest
+	(*GetSpecialResponse)(nil),   // 4: offerings.v1.GetSpecialResponse
+}
+var file_offerings_v1_offerings_proto_depIdxs = []int32{
+	0, // 0: offerings.v1.ListSpecialsResponse.specials:type_name -> offerings.v1.Special
+	0, // 1: offerings.v1.GetSpecialResponse.special:type_name -> offerings.v1.Special
+	1, // 2: offerings.v1.OfferingsService.ListSpecials:input_type -> offerings.v1.ListSpecialsRequest
+	3, // 3: offerings.v1.OfferingsService.GetSpecial:input_type -> offerings.v1.GetSpecialRequest
+	2, // 4: offerings.v1.OfferingsService.ListSpecials:output_type -> offerings.v1.ListSpecialsResponse
+	4, // 5: offerings.v1.OfferingsService.GetSpecial:output_type -> offerings.v1.GetSpecialResponse
+	4, // [4:6] is the sub-list for method output_type
+	2, // [2:4] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_offerings_v1_offerings_proto_init() }
+func file_offerings_v1_offerings_proto_init() {
+	if File_offerings_v1_offerings_proto != nil {
+		return
+	}
+	file_offerings_v1_offerings_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_offerings_v1_offerings_proto_rawDesc), len(file_offerings_v1_offerings_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_offerings_v1_offerings_proto_goTypes,
+		DependencyIndexes: file_offerings_v1_offerings_proto_depIdxs,
+		MessageInfos:      file_offerings_v1_offerings_proto_msgTypes,
+	}.Build()
+	File_offerings_v1_offerings_proto = out.File
+	file_offerings_v1_offerings_proto_goTypes = nil
+	file_offerings_v1_offerings_proto_depIdxs = nil
+}
@@ -0,0 +1,176 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: offerings/v1/offerings.proto
+
+// Offerings of the traveler service for internal callers. The data is the same as served
+// by the REST API under /api/v1/offerings.
+
+package offeringsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	OfferingsService_ListSpecials_FullMethodName = "/offerings.v1.OfferingsService/ListSpecials"
+	OfferingsService_GetSpecial_FullMethodName   = "/offerings.v1.OfferingsService/GetSpecial"
+)
+
+// OfferingsServiceClient is the client API for OfferingsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// OfferingsService reads travel offerings. Every call needs a Keycloak access token in the
+// "authorization: Bearer <token>" metadata, or a verified client certificate when
+// server.tls.client_cert_auth is enabled.
+type OfferingsServiceClient interface {
+	// ListSpecials returns the active specials, ordered by id, optionally only those with
+	// all of the requested tags.
+	ListSpecials(ctx context.Context, in *ListSpecialsRequest, opts ...grpc.CallOption) (*ListSpecialsResponse, error)
+	// GetSpecial returns one active special; NOT_FOUND if there is none with the id.
+	GetSpecial(ctx context.Context, in *GetSpecialRequest, opts ...grpc.CallOption) (*GetSpecialResponse, error)
+}
+
+type offeringsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOfferingsServiceClient(cc grpc.ClientConnInterface) OfferingsServiceClient {
+	return &offeringsServiceClient{cc}
+}
+
+func (c *offeringsServiceClient) ListSpecials(ctx context.Context, in *ListSpecialsRequest, opts ...grpc.CallOption) (*ListSpecialsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSpecialsResponse)
+	err := c.cc.Invoke(ctx, OfferingsService_ListSpecials_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *offeringsServiceClient) GetSpecial(ctx context.Context, in *GetSpecialRequest, opts ...grpc.CallOption) (*GetSpecialResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSpecialResponse)
+	err := c.cc.Invoke(ctx, OfferingsService_GetSpecial_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OfferingsServiceServer is the server API for OfferingsService service.
+// All implementations must embed UnimplementedOfferingsServiceServer
+// for forward compatibility.
+//
+// OfferingsService reads travel offerings. Every call needs a Keycloak access token in the
+// "authorization: Bearer <token>" metadata, or a verified client certificate when
+// server.tls.client_cert_auth is enabled.
+type OfferingsServiceServer interface {
+	// ListSpecials returns the active specials, ordered by id, optionally only those with
+	// all of the requested tags.
+	ListSpecials(context.Context, *ListSpecialsRequest) (*ListSpecialsResponse, error)
+	// GetSpecial returns one active special; NOT_FOUND if there is none with the id.
+	GetSpecial(context.Context, *GetSpecialRequest) (*GetSpecialResponse, error)
+	mustEmbedUnimplementedOfferingsServiceServer()
+}
+
+// UnimplementedOfferingsServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOfferingsServiceServer struct{}
+
+func (UnimplementedOfferingsServiceServer) ListSpecials(context.Context, *ListSpecialsRequest) (*ListSpecialsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListSpecials not implemented")
+}
+func (UnimplementedOfferingsServiceServer) GetSpecial(context.Context, *GetSpecialRequest) (*GetSpecialResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSpecial not implemented")
+}
+func (UnimplementedOfferingsServiceServer) mustEmbedUnimplementedOfferingsServiceServer() {}
+func (UnimplementedOfferingsServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeOfferingsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OfferingsServiceServer will
+// result in compilation errors.
+type UnsafeOfferingsServiceServer interface {
+	mustEmbedUnimplementedOfferingsServiceServer()
+}
+
+func RegisterOfferingsServiceServer(s grpc.ServiceRegistrar, srv OfferingsServiceServer) {
+	// If the following call panics, it indicates UnimplementedOfferingsServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OfferingsService_ServiceDesc, srv)
+}
+
+func _OfferingsService_ListSpecials_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSpecialsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OfferingsServiceServer).ListSpecials(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OfferingsService_ListSpecials_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OfferingsServiceServer).ListSpecials(ctx, req.(*ListSpecialsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OfferingsService_GetSpecial_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSpecialRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OfferingsServiceServer).GetSpecial(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OfferingsService_GetSpecial_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OfferingsServiceServer).GetSpecial(ctx, req.(*GetSpecialRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OfferingsService_ServiceDesc is the grpc.ServiceDesc for OfferingsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OfferingsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "offerings.v1.OfferingsService",
+	HandlerType: (*OfferingsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSpecials",
+			Handler:    _OfferingsService_ListSpecials_Handler,
+		},
+		{
+			MethodName: "GetSpecial",
+			Handler:    _OfferingsService_GetSpecial_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "offerings/v1/offerings.proto",
+}
@@ -2,28 +2,56 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
+
+	"github.com/spf13/pflag"
 
 	"traveler/internal/app"
+	"traveler/pkg/auth"
 	"traveler/pkg/config"
 	"traveler/pkg/log"
 )
 
 func main() {
+	// traveler config validate [--config path]
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "validate" {
+		os.Exit(validateConfig(os.Args[3:]))
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	cfg := config.LoadOrDefault("configs/config.yaml")
+	flags := pflag.NewFlagSet("traveler", pflag.ExitOnError)
+	config.RegisterFlags(flags)
+	_ = flags.Parse(os.Args[1:])
+
+	// Precedence: flags > TRAVELER_* environment variables > config file > defaults
+	cfg, err := config.LoadOrDefault(config.DefaultPath, flags)
+	if err != nil {
+		// The logger is not configured yet, so report directly.
+		fmt.Fprintf(os.Stderr, "traveler: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Initialize logger with configured level, file path, and optional Elasticsearch sink
-	if err := log.Init(cfg.Log.Level, cfg.Log.File, &cfg.Log.Elasticsearch); err != nil {
+	if err := log.Init(&cfg.Log); err != nil {
 		log.Fatal("failed to init logger", "error", err)
 	}
 
-	defer func() { _ = log.Sync() }()
+	defer func() { _ = log.Close() }()
+
+	// SIGUSR1/SIGUSR2 raise/lower verbosity at runtime
+	log.HandleSignals(ctx)
+
+	// Reload the configuration on file changes and SIGHUP; only subscribed keys change live.
+	// app.Run runs the watcher until shutdown.
+	watcher := config.NewWatcher(config.DefaultPath, flags, cfg)
+	watcher.Subscribe(log.Reconfigure, "log")
+	watcher.Subscribe(auth.Reconfigure, "auth.audience", "auth.audiences")
+	watcher.OnReload(logReload)
 
 	logMsg := "starting application"
 	logFields := []interface{}{"port", cfg.Server.Port, "log_level", cfg.Log.Level}
@@ -34,9 +62,25 @@ func main() {
 
 	log.Info(logMsg, logFields...)
 
-	if err := app.Run(ctx, cfg); err != nil {
+	// Run flushes the logs and closes the database as its last shutdown phases.
+	if err := app.Run(ctx, watcher); err != nil {
 		log.Fatal("application error", "error", err)
 	}
+}
 
-	time.Sleep(50 * time.Millisecond)
+// logReload reports the outcome of a configuration reload.
+func logReload(r config.ReloadReport) {
+	if r.Err != nil && len(r.Changed) == 0 {
+		log.Error("configuration reload failed, keeping the running configuration", "error", r.Err)
+		return
+	}
+	if len(r.Rejected) > 0 {
+		log.Warn("configuration changes require a restart and were not applied", "keys", r.Rejected)
+	}
+	if len(r.Changed) > 0 {
+		log.Info("configuration reloaded", "changed", r.Changed)
+	}
+	if r.Err != nil {
+		log.Error("failed to apply reloaded configuration", "error", r.Err)
+	}
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"traveler/pkg/config"
+)
+
+// validateConfig implements "traveler config validate": it loads the configuration the same
+// way the server does and reports every problem. It returns the process exit code.
+func validateConfig(args []string) int {
+	flags := pflag.NewFlagSet("traveler config validate", pflag.ContinueOnError)
+	config.RegisterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadWithFlags(config.DefaultPath, flags)
+	if errors.Is(err, config.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "%v (the server would start with defaults)\n", err)
+		cfg, err = config.LoadOrDefault(config.DefaultPath, flags)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("configuration OK (port %d, log level %s, database %s)\n",
+		cfg.Server.Port, cfg.Log.Level, cfg.Database.Path)
+	return 0
+}
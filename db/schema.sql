@@ -21,7 +21,16 @@ CREATE TABLE IF NOT EXISTS specials (
   starts_at TEXT,
   ends_at TEXT,
   created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
-  updated_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+  updated_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  -- Content of the deal card. Lists are JSON arrays of strings. Databases created before
+  -- these columns existed get them from db.Init (see addedColumns).
+  description TEXT NOT NULL DEFAULT '',
+  hero_images TEXT NOT NULL DEFAULT '[]',   -- absolute http(s) URLs, the first is the main image
+  inclusions TEXT NOT NULL DEFAULT '[]',    -- e.g. "Return flights", "Breakfast"
+  nights INTEGER,                           -- NULL when the duration is open
+  departure_city TEXT NOT NULL DEFAULT '',
+  destination_id TEXT NOT NULL DEFAULT '',  -- id of the destination in the destinations catalogue
+  tags TEXT NOT NULL DEFAULT '[]'           -- lower-case slugs such as "beach", "family"
 );
 
 -- Seed initial specials if they don't exist
@@ -32,3 +41,158 @@ WHERE NOT EXISTS (SELECT 1 FROM specials WHERE id = 'sp-1001');
 INSERT INTO specials(id, name, price, currency)
 SELECT 'sp-1002', 'City Break Deluxe', 499.0, 'USD'
 WHERE NOT EXISTS (SELECT 1 FROM specials WHERE id = 'sp-1002');
+
+-- Bump updated_at (with millisecond precision) on every change that does not set it.
+CREATE TRIGGER IF NOT EXISTS specials_touch_updated_at
+AFTER UPDATE ON specials
+WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+  UPDATE specials SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.id;
+END;
+
+-- Version of the specials listing, the source of its ETag, Last-Modified and response cache.
+-- Every insert, update and delete of a special bumps it (translations touch their special),
+-- so unlike MAX(updated_at) it moves on deletes and unlike MAX(rowid) it is never reused.
+CREATE TABLE IF NOT EXISTS specials_version (
+  id INTEGER PRIMARY KEY CHECK (id = 1),
+  version INTEGER NOT NULL,
+  changed_at TEXT NOT NULL
+);
+
+INSERT INTO specials_version(id, version, changed_at)
+SELECT 1, 1, strftime('%Y-%m-%d %H:%M:%f', 'now')
+WHERE NOT EXISTS (SELECT 1 FROM specials_version);
+
+CREATE TRIGGER IF NOT EXISTS specials_version_insert
+AFTER INSERT ON specials
+BEGIN
+  UPDATE specials_version SET version = version + 1, changed_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS specials_version_update
+AFTER UPDATE ON specials
+BEGIN
+  UPDATE specials_version SET version = version + 1, changed_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS specials_version_delete
+AFTER DELETE ON specials
+BEGIN
+  UPDATE specials_version SET version = version + 1, changed_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = 1;
+END;
+
+-- Idempotency keys of POST/PATCH requests and the responses replayed to retries. Times are
+-- unix milliseconds.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  principal TEXT NOT NULL,          -- "<source>:<subject>"; empty for anonymous callers
+  key TEXT NOT NULL,                -- the Idempotency-Key header
+  fingerprint TEXT NOT NULL,        -- hash of method, path and body of the first request
+  status INTEGER,                   -- NULL while the first request is in flight
+  headers TEXT,                     -- JSON object of response headers
+  body BLOB,
+  locked_until INTEGER NOT NULL,    -- an in-flight reservation is abandoned after this
+  expires_at INTEGER NOT NULL,
+  PRIMARY KEY (principal, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- Log of changes to active specials, streamed to clients as Server-Sent Events. Clients resume
+-- after a reconnect from the id they saw last, so ids must never be reused (AUTOINCREMENT).
+-- type is 'created' (a special became visible), 'updated' or 'expired' (it was deactivated
+-- or deleted); data is the special as of the change.
+CREATE TABLE IF NOT EXISTS specials_events (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  type TEXT NOT NULL,
+  special_id TEXT NOT NULL,
+  data TEXT NOT NULL,
+  created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS specials_events_created_at ON specials_events(created_at);
+
+-- The event triggers are recreated on every start so that changes to the event data reach
+-- existing databases.
+DROP TRIGGER IF EXISTS specials_events_insert;
+CREATE TRIGGER specials_events_insert
+AFTER INSERT ON specials
+WHEN NEW.active = 1
+BEGIN
+  INSERT INTO specials_events(type, special_id, data)
+  VALUES ('created', NEW.id, json_object('id', NEW.id, 'name', NEW.name, 'price', NEW.price, 'currency', NEW.currency,
+    'description', NEW.description, 'hero_images', json(NEW.hero_images), 'inclusions', json(NEW.inclusions),
+    'nights', NEW.nights, 'departure_city', NEW.departure_city, 'destination_id', NEW.destination_id,
+    'tags', json(NEW.tags)));
+END;
+
+-- Only changes to the columns clients see are logged, not the updated_at bump of
+-- specials_touch_updated_at.
+DROP TRIGGER IF EXISTS specials_events_update;
+CREATE TRIGGER specials_events_update
+AFTER UPDATE ON specials
+WHEN (NEW.active = 1 OR OLD.active = 1)
+  AND (NEW.active IS NOT OLD.active OR NEW.name IS NOT OLD.name OR NEW.price IS NOT OLD.price
+    OR NEW.currency IS NOT OLD.currency OR NEW.description IS NOT OLD.description
+    OR NEW.hero_images IS NOT OLD.hero_images OR NEW.inclusions IS NOT OLD.inclusions
+    OR NEW.nights IS NOT OLD.nights OR NEW.departure_city IS NOT OLD.departure_city
+    OR NEW.destination_id IS NOT OLD.destination_id OR NEW.tags IS NOT OLD.tags)
+BEGIN
+  INSERT INTO specials_events(type, special_id, data)
+  VALUES (
+    CASE WHEN NEW.active = 0 THEN 'expired' WHEN OLD.active = 0 THEN 'created' ELSE 'updated' END,
+    NEW.id,
+    json_object('id', NEW.id, 'name', NEW.name, 'price', NEW.price, 'currency', NEW.currency,
+      'description', NEW.description, 'hero_images', json(NEW.hero_images), 'inclusions', json(NEW.inclusions),
+      'nights', NEW.nights, 'departure_city', NEW.departure_city, 'destination_id', NEW.destination_id,
+      'tags', json(NEW.tags))
+  );
+END;
+
+DROP TRIGGER IF EXISTS specials_events_delete;
+CREATE TRIGGER specials_events_delete
+AFTER DELETE ON specials
+WHEN OLD.active = 1
+BEGIN
+  INSERT INTO specials_events(type, special_id, data)
+  VALUES ('expired', OLD.id, json_object('id', OLD.id, 'name', OLD.name, 'price', OLD.price, 'currency', OLD.currency,
+    'description', OLD.description, 'hero_images', json(OLD.hero_images), 'inclusions', json(OLD.inclusions),
+    'nights', OLD.nights, 'departure_city', OLD.departure_city, 'destination_id', OLD.destination_id,
+    'tags', json(OLD.tags)));
+END;
+
+-- Names and descriptions of specials in other languages; specials.name is English. locale
+-- is a canonical BCP 47 tag reduced to language, script and region (de, de-CH, zh-Hant).
+CREATE TABLE IF NOT EXISTS special_translations (
+  special_id TEXT NOT NULL,
+  locale TEXT NOT NULL,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  PRIMARY KEY (special_id, locale)
+);
+
+-- Foreign keys are not enforced, so translations are deleted with their special here.
+CREATE TRIGGER IF NOT EXISTS specials_delete_translations
+AFTER DELETE ON specials
+BEGIN
+  DELETE FROM special_translations WHERE special_id = OLD.id;
+END;
+
+-- A changed translation changes the specials response: touch the special so that
+-- specials_version moves.
+CREATE TRIGGER IF NOT EXISTS special_translations_touch_insert
+AFTER INSERT ON special_translations
+BEGIN
+  UPDATE specials SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.special_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS special_translations_touch_update
+AFTER UPDATE ON special_translations
+BEGIN
+  UPDATE specials SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = NEW.special_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS special_translations_touch_delete
+AFTER DELETE ON special_translations
+BEGIN
+  UPDATE specials SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = OLD.special_id;
+END;
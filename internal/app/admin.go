@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"traveler/internal/handlers"
+	"traveler/internal/metrics"
+	"traveler/internal/middleware"
+	"traveler/pkg/config"
+	"traveler/pkg/log"
+)
+
+// newAdminApp builds the Fiber app of the admin listener. ready decides the /readyz answer.
+func newAdminApp(watcher *config.Watcher, reg *metrics.Registry, ready func(ctx context.Context) error) *fiber.App {
+	admin := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+	})
+	admin.Use(middleware.Recover())
+	handlers.RegisterAdminRoutes(admin, watcher, reg, ready)
+	return admin
+}
+
+// adminListener opens the admin listener on admin.socket, or on admin.port if no socket is set.
+func adminListener(cfg config.AdminConfig) (net.Listener, error) {
+	if cfg.Socket == "" {
+		return net.Listen("tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)))
+	}
+
+	// A socket left behind by an unclean exit would make the bind fail.
+	if err := os.Remove(cfg.Socket); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale admin socket: %w", err)
+	}
+	ln, err := net.Listen("unix", cfg.Socket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(cfg.Socket, 0o660); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("failed to set admin socket permissions: %w", err)
+	}
+	return ln, nil
+}
+
+// startAdminServer serves the admin app in the background and reports errors via errCh.
+func startAdminServer(admin *fiber.App, cfg config.AdminConfig, errCh chan<- error) {
+	ln, err := adminListener(cfg)
+	if err != nil {
+		errCh <- fmt.Errorf("failed to start admin listener: %w", err)
+		return
+	}
+	log.Info("starting admin server", "address", ln.Addr().String(), "auth", cfg.Auth)
+	if err := admin.Listener(ln); err != nil {
+		errCh <- err
+	}
+}
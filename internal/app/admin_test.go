@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"traveler/internal/metrics"
+	"traveler/pkg/config"
+)
+
+func newTestAdminApp(t *testing.T, admin config.AdminConfig, ready func(context.Context) error) (*fiber.App, *metrics.Registry) {
+	t.Helper()
+	cfg := &config.Config{Admin: admin, Log: config.LogConfig{Level: "info"}}
+	reg := metrics.New()
+	return newAdminApp(config.NewWatcher(filepath.Join(t.TempDir(), "config.yaml"), nil, cfg), reg, ready), reg
+}
+
+func get(t *testing.T, app *fiber.App, path, token string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestAdminApp_TokenAuth(t *testing.T) {
+	ready := func(context.Context) error { return nil }
+	admin, _ := newTestAdminApp(t, config.AdminConfig{Auth: "token", Token: "s3cret-admin", Pprof: true}, ready)
+
+	t.Run("probes are open", func(t *testing.T) {
+		status, _ := get(t, admin, "/healthz", "")
+		assert.Equal(t, http.StatusOK, status)
+		status, body := get(t, admin, "/readyz", "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"ready"`)
+	})
+
+	t.Run("operational endpoints need the token", func(t *testing.T) {
+		for _, path := range []string{"/metrics", "/admin/log-level", "/admin/config", "/debug/pprof/"} {
+			status, _ := get(t, admin, path, "")
+			assert.Equal(t, http.StatusUnauthorized, status, path)
+			status, _ = get(t, admin, path, "wrong")
+			assert.Equal(t, http.StatusUnauthorized, status, path)
+			status, _ = get(t, admin, path, "s3cret-admin")
+			assert.Equal(t, http.StatusOK, status, path)
+		}
+	})
+}
+
+func TestAdminApp_ReadinessFailure(t *testing.T) {
+	admin, _ := newTestAdminApp(t, config.AdminConfig{Auth: "none"}, func(context.Context) error {
+		return errors.New("database is closed")
+	})
+
+	status, body := get(t, admin, "/readyz", "")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Contains(t, body, "database is closed")
+}
+
+func TestAdminApp_PprofDisabled(t *testing.T) {
+	admin, _ := newTestAdminApp(t, config.AdminConfig{Auth: "none"}, nil)
+
+	status, _ := get(t, admin, "/debug/pprof/", "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestAdminApp_MetricsCountPublicRequests(t *testing.T) {
+	admin, reg := newTestAdminApp(t, config.AdminConfig{Auth: "none"}, nil)
+	public := fiber.New()
+	public.Use(reg.Middleware())
+	public.Get("/api/ping", func(c *fiber.Ctx) error { return c.SendString("pong") })
+
+	_, err := public.Test(httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	require.NoError(t, err)
+	_, err = public.Test(httptest.NewRequest(http.MethodGet, "/missing", nil))
+	require.NoError(t, err)
+
+	status, body := get(t, admin, "/metrics", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `traveler_http_requests_total{method="GET",code="2xx"} 1`)
+	assert.Contains(t, body, `traveler_http_requests_total{method="GET",code="4xx"} 1`)
+	assert.Contains(t, body, "# TYPE process_uptime_seconds gauge")
+}
+
+func TestAdminListener_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "admin.sock")
+	// A stale socket from a previous run must not prevent the bind.
+	stale, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	admin, _ := newTestAdminApp(t, config.AdminConfig{Socket: socket, Auth: "none"}, nil)
+	errCh := make(chan error, 1)
+	go startAdminServer(admin, config.AdminConfig{Socket: socket, Auth: "none"}, errCh)
+	t.Cleanup(func() { _ = admin.Shutdown() })
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	require.Eventually(t, func() bool {
+		resp, err := client.Get("http://admin/healthz")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 3*time.Second, 20*time.Millisecond)
+}
@@ -2,66 +2,209 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
+	"errors"
 	"fmt"
-	"time"
+	"sync/atomic"
 
 	"github.com/gofiber/fiber/v2"
 
 	appdb "traveler/internal/db"
+	"traveler/internal/events"
+	"traveler/internal/grpcserver"
 	"traveler/internal/handlers"
+	"traveler/internal/handlers/docs"
+	"traveler/internal/httpcache"
+	"traveler/internal/idempotency"
+	"traveler/internal/metrics"
+	"traveler/internal/middleware"
+	"traveler/internal/openapi"
+	"traveler/pkg/auth"
 	"traveler/pkg/config"
 	"traveler/pkg/log"
 )
 
-// Run starts the application. It runs a Fiber HTTP server until context is cancelled.
-func Run(ctx context.Context, cfg *config.Config) error {
+// Run starts the application. It runs the public and admin Fiber servers and, with
+// grpc.enabled, the gRPC server until context is cancelled, then shuts down in phases (see
+// shutdown.phases). The watcher holds the configuration; it is run as a background worker
+// and settings it reloads are picked up by subscribers.
+func Run(ctx context.Context, watcher *config.Watcher) error {
+	cfg := watcher.Current()
 	sqlDb, err := initDatabase(ctx, cfg)
 	if err != nil {
 		return err
 	}
 
-	app := fiber.New(fiber.Config{
-		DisableStartupMessage: true,
+	reg := metrics.New()
+	corsMW, err := middleware.NewCORS(cfg.Server.CORS)
+	if err != nil {
+		_ = sqlDb.Close()
+		return err
+	}
+	watcher.Subscribe(corsMW.Reconfigure, "server.cors")
+	limiter := middleware.NewRateLimiter(cfg.Server.RateLimit)
+	watcher.Subscribe(limiter.Reconfigure, "server.rate_limit")
+	deprecations := middleware.NewDeprecations(cfg.Server.API.Deprecations)
+	watcher.Subscribe(deprecations.Reconfigure, "server.api.deprecations")
+	var spec *openapi.Spec
+	var apiDocs *docs.Handlers
+	if o := cfg.Server.OpenAPI; o.ValidateRequests || o.ValidateResponses || cfg.Server.Docs.Enabled {
+		if spec, err = openapi.Load(o.SpecFile); err != nil {
+			_ = sqlDb.Close()
+			return err
+		}
+	}
+	if cfg.Server.Docs.Enabled {
+		if apiDocs, err = docs.New(spec, cfg); err != nil {
+			_ = sqlDb.Close()
+			return err
+		}
+		log.Info("serving API documentation", "path", "/docs")
+	}
+	// TLS comes before the broker, which needs Close once created, so that failing
+	// certificates leave only the database to clean up.
+	var (
+		tlsCfg   *tls.Config
+		reloader *certReloader
+	)
+	if cfg.Server.TLS.Enabled {
+		if tlsCfg, reloader, err = newTLSConfig(cfg.Server.TLS); err != nil {
+			_ = sqlDb.Close()
+			return err
+		}
+	}
+	broker, err := events.NewBroker(ctx, sqlDb, cfg.Server.Events)
+	if err != nil {
+		_ = sqlDb.Close()
+		return err
+	}
+	app := newPublicApp(cfg, reg, corsMW, limiter, deprecations)
+
+	// Background workers run on their own context so they keep working while requests drain.
+	bg := &workers{}
+	bg.start("config watcher", func(ctx context.Context) {
+		if err := watcher.Run(ctx); err != nil {
+			log.Warn("configuration hot reload disabled", "error", err)
+		}
+	})
+
+	bg.start("idempotency key cleanup", func(ctx context.Context) {
+		idempotency.Cleanup(ctx, sqlDb)
 	})
+	bg.start("specials event broker", broker.Run)
+	if reloader != nil {
+		bg.start("TLS certificate reloader", reloader.watch)
+	}
 
-	handlers.RegisterRoutes(app, cfg, sqlDb)
+	var validate fiber.Handler
+	if o := cfg.Server.OpenAPI; spec != nil && (o.ValidateRequests || o.ValidateResponses) {
+		validate = spec.Middleware(o.ValidateResponses)
+	}
+	handlers.RegisterRoutes(app, watcher, sqlDb, broker, apiDocs, validate)
 
-	errCh := make(chan error, 1)
-	go startServer(app, cfg, errCh)
+	var grpcSrv *grpcserver.Server
+	if cfg.GRPC.Enabled {
+		grpcSrv = grpcserver.New(cfg, sqlDb, tlsCfg)
+	}
+
+	var ready atomic.Bool
+	ready.Store(true)
+	admin := newAdminApp(watcher, reg, func(ctx context.Context) error {
+		if !ready.Load() {
+			return errShuttingDown
+		}
+		return sqlDb.PingContext(ctx)
+	})
+
+	sd := &shutdown{
+		cfg:          cfg.Server.Shutdown,
+		ready:        &ready,
+		public:       app,
+		admin:        admin,
+		grpc:         grpcSrv,
+		workers:      bg,
+		closeStreams: broker.Close,
+		stopShipping: log.Close,
+		flushLogs:    log.Sync,
+		closeDB:      sqlDb.Close,
+	}
+
+	errCh := make(chan error, 3)
+	go startServer(app, cfg, tlsCfg, errCh)
+	go startAdminServer(admin, cfg.Admin, errCh)
+	if grpcSrv != nil {
+		go startGRPCServer(grpcSrv, cfg.GRPC, tlsCfg != nil, errCh)
+	}
 
 	select {
 	case <-ctx.Done():
-		return gracefulShutdown(app, sqlDb)
+		return sd.run()
 	case err := <-errCh:
-		_ = sqlDb.Close()
-		return err
+		// A listener failed; nothing routes traffic here yet, so skip the pre-stop delay.
+		log.Error("server failed, shutting down", "error", err)
+		sd.cfg.PreStopDelay = 0
+		return errors.Join(err, sd.run())
 	}
 }
 
-// startServer starts the Fiber HTTP server in the background and reports errors via errCh.
-func startServer(app *fiber.App, cfg *config.Config, errCh chan<- error) {
-	addr := fmt.Sprintf(":%d", cfg.Server.Port)
-	log.Info("starting server", "address", addr)
+// newPublicApp builds the public Fiber app with the hardening layer configured under server:
+// limits and timeouts, trusted proxies, panic recovery, security headers, API version
+// routing, CORS, rate limiting, Cache-Control and deprecation headers. OpenAPI validation
+// runs per route, after authentication (see handlers.RegisterRoutes).
+func newPublicApp(cfg *config.Config, reg *metrics.Registry, corsMW *middleware.CORS,
+	limiter *middleware.RateLimiter, deprecations *middleware.Deprecations) *fiber.App {
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		BodyLimit:             cfg.Server.BodyLimit,
+		ReadTimeout:           cfg.Server.ReadTimeout,
+		WriteTimeout:          cfg.Server.WriteTimeout,
+		IdleTimeout:           cfg.Server.IdleTimeout,
+		// c.IP() and c.Protocol() believe proxy headers only from server.trusted_proxies.
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.Server.TrustedProxies,
+		ProxyHeader:             cfg.Server.ProxyHeader,
+	})
 
-	if err := app.Listen(addr); err != nil {
-		errCh <- err
+	// Metrics come first so that recovered panics are counted as 500s.
+	app.Use(reg.Middleware())
+	app.Use(middleware.Recover())
+	app.Use(middleware.SecurityHeaders(cfg.Server.Headers))
+	// Unversioned /api paths are rewritten first; everything below sees /api/<version>/...
+	app.Use(middleware.APIVersions(handlers.APIVersions, handlers.CurrentAPIVersion, docs.Paths...))
+	app.Use(corsMW.Handler())
+	// After CORS, so that preflights are not counted and browsers can read the 429.
+	app.Use(limiter.Handler())
+	app.Use(httpcache.CacheControl(cfg.Server.HTTPCache.CacheControl))
+	app.Use(deprecations.Handler())
+	if cfg.Server.TLS.Enabled {
+		// Client certificate subjects become the request principal.
+		app.Use(auth.ClientCertMiddleware())
 	}
+	return app
 }
 
-// gracefulShutdown performs a graceful shutdown of the server and closes the database.
-func gracefulShutdown(app *fiber.App, sqlDb *sql.DB) error {
-	log.Info("shutting down server gracefully")
-
-	ctxShutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := app.ShutdownWithContext(ctxShutdown); err != nil {
-		_ = sqlDb.Close()
-		return err
+// startServer starts the Fiber HTTP server in the background and reports errors via errCh.
+// With a TLS configuration it serves HTTPS.
+func startServer(app *fiber.App, cfg *config.Config, tlsCfg *tls.Config, errCh chan<- error) {
+	addr := fmt.Sprintf(":%d", cfg.Server.Port)
+	if tlsCfg == nil {
+		log.Info("starting server", "address", addr)
+		if err := app.Listen(addr); err != nil {
+			errCh <- err
+		}
+		return
 	}
 
-	return sqlDb.Close()
+	ln, err := tls.Listen("tcp", addr, tlsCfg)
+	if err != nil {
+		errCh <- err
+		return
+	}
+	log.Info("starting server", "address", addr, "tls", true, "client_auth", cfg.Server.TLS.ClientAuth)
+	if err := app.Listener(ln); err != nil {
+		errCh <- err
+	}
 }
 
 // initDatabase initializes the SQLite database and applies the schema.
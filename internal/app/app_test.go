@@ -0,0 +1,63 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"traveler/internal/metrics"
+	"traveler/internal/middleware"
+	"traveler/pkg/config"
+)
+
+func newTestPublicApp(t *testing.T, server config.ServerConfig) *fiber.App {
+	t.Helper()
+	corsMW, err := middleware.NewCORS(server.CORS)
+	require.NoError(t, err)
+	app := newPublicApp(&config.Config{Server: server}, metrics.New(), corsMW,
+		middleware.NewRateLimiter(server.RateLimit), middleware.NewDeprecations(server.API.Deprecations))
+	app.Get("/ip", func(c *fiber.Ctx) error { return c.SendString(c.IP()) })
+	app.Post("/upload", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+	return app
+}
+
+func TestPublicApp_BodyLimit(t *testing.T) {
+	baseURL := serve(t, newTestPublicApp(t, config.ServerConfig{BodyLimit: 16}))
+
+	resp, err := http.Post(baseURL+"/upload", "application/octet-stream", bytes.NewReader(make([]byte, 8)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.Post(baseURL+"/upload", "application/octet-stream", bytes.NewReader(make([]byte, 64)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func TestPublicApp_ProxyHeaderOnlyFromTrustedProxies(t *testing.T) {
+	ip := func(app *fiber.App) string {
+		req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		return buf.String()
+	}
+
+	// app.Test connects from 0.0.0.0.
+	trusted := newTestPublicApp(t, config.ServerConfig{
+		BodyLimit: 1024, ProxyHeader: "X-Forwarded-For", TrustedProxies: []string{"0.0.0.0/8"},
+	})
+	assert.Equal(t, "203.0.113.7", ip(trusted))
+
+	untrusted := newTestPublicApp(t, config.ServerConfig{BodyLimit: 1024, ProxyHeader: "X-Forwarded-For"})
+	assert.Equal(t, "0.0.0.0", ip(untrusted))
+}
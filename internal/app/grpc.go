@@ -0,0 +1,23 @@
+package app
+
+import (
+	"fmt"
+	"net"
+
+	"traveler/internal/grpcserver"
+	"traveler/pkg/config"
+	"traveler/pkg/log"
+)
+
+// startGRPCServer serves the gRPC API in the background and reports errors via errCh.
+func startGRPCServer(srv *grpcserver.Server, cfg config.GRPCConfig, tls bool, errCh chan<- error) {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
+	if err != nil {
+		errCh <- fmt.Errorf("failed to start gRPC listener: %w", err)
+		return
+	}
+	log.Info("starting gRPC server", "address", ln.Addr().String(), "tls", tls, "reflection", cfg.Reflection)
+	if err := srv.Serve(ln); err != nil {
+		errCh <- err
+	}
+}
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"traveler/internal/grpcserver"
+	"traveler/pkg/config"
+	"traveler/pkg/log"
+)
+
+// errShuttingDown is the readiness failure reported once shutdown has started.
+var errShuttingDown = errors.New("shutting down")
+
+// workerStopTimeout bounds the wait for one background worker to return after cancellation.
+const workerStopTimeout = 5 * time.Second
+
+// worker is a background goroutine owned by the application.
+type worker struct {
+	name   string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// workers runs background goroutines that outlive the shutdown signal and are stopped
+// one by one, in start order, once the servers have drained.
+type workers struct {
+	list []*worker
+}
+
+// start runs fn in a new goroutine until stop cancels its context.
+func (w *workers) start(name string, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	wk := &worker{name: name, cancel: cancel, done: make(chan struct{})}
+	w.list = append(w.list, wk)
+	go func() {
+		defer close(wk.done)
+		fn(ctx)
+	}()
+}
+
+// stop cancels the workers in start order and waits for each to return.
+func (w *workers) stop() error {
+	var errs []error
+	for _, wk := range w.list {
+		wk.cancel()
+		select {
+		case <-wk.done:
+			log.Info("background worker stopped", "worker", wk.name)
+		case <-time.After(workerStopTimeout):
+			errs = append(errs, fmt.Errorf("worker %s did not stop within %s", wk.name, workerStopTimeout))
+		}
+	}
+	w.list = nil
+	return errors.Join(errs...)
+}
+
+// phase is one step of the shutdown sequence.
+type phase struct {
+	name string
+	run  func() error
+}
+
+// shutdown holds what the shutdown sequence stops.
+type shutdown struct {
+	cfg     config.ShutdownConfig
+	ready   *atomic.Bool // read by /readyz
+	public  *fiber.App
+	admin   *fiber.App
+	grpc    *grpcserver.Server // nil unless grpc.enabled
+	workers *workers
+
+	// closeStreams ends the open event streams, which would otherwise hold the drain open.
+	closeStreams func()
+	// stopShipping and flushLogs default to log.Close and log.Sync.
+	stopShipping func() error
+	flushLogs    func() error
+	closeDB      func() error
+}
+
+// phases returns the shutdown steps in order:
+//
+//  1. readiness: /readyz and the gRPC health service start failing so load balancers take
+//     the instance out of rotation
+//  2. pre-stop delay: keep serving while they notice (server.shutdown.pre_stop_delay)
+//  3. event streams: end open Server-Sent Events streams; clients reconnect elsewhere
+//  4. drain: stop accepting connections and wait for in-flight requests and gRPC calls
+//     (server.shutdown.drain_timeout)
+//  5. admin listener: probes and metrics stay available until the public listener is drained
+//  6. background workers: config watcher, TLS certificate reloader, ... in start order
+//  7. log shipping: deliver buffered entries to remote sinks and close them
+//  8. flush logs: sync local outputs
+//  9. database: close the connection pool
+func (s *shutdown) phases() []phase {
+	return []phase{
+		{"readiness", func() error {
+			s.ready.Store(false)
+			if s.grpc != nil {
+				s.grpc.SetServing(false)
+			}
+			return nil
+		}},
+		{"pre-stop delay", func() error {
+			time.Sleep(s.cfg.PreStopDelay)
+			return nil
+		}},
+		{"event streams", func() error {
+			s.closeStreams()
+			return nil
+		}},
+		{"drain", func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), s.cfg.DrainTimeout)
+			defer cancel()
+			grpcErr := make(chan error, 1)
+			go func() {
+				if s.grpc == nil {
+					grpcErr <- nil
+					return
+				}
+				if err := s.grpc.Shutdown(ctx); err != nil {
+					grpcErr <- fmt.Errorf("in-flight gRPC calls did not finish within %s: %w", s.cfg.DrainTimeout, err)
+					return
+				}
+				grpcErr <- nil
+			}()
+			var err error
+			if err = s.public.ShutdownWithContext(ctx); err != nil {
+				err = fmt.Errorf("in-flight requests did not finish within %s: %w", s.cfg.DrainTimeout, err)
+			}
+			return errors.Join(err, <-grpcErr)
+		}},
+		{"admin listener", func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), s.cfg.DrainTimeout)
+			defer cancel()
+			return s.admin.ShutdownWithContext(ctx)
+		}},
+		{"background workers", s.workers.stop},
+		{"log shipping", s.stopShipping},
+		{"flush logs", s.flushLogs},
+		{"database", s.closeDB},
+	}
+}
+
+// run executes every phase, logging its start and outcome. A failing phase does not stop
+// the sequence; the errors are returned together.
+func (s *shutdown) run() error {
+	log.Info("shutting down server gracefully",
+		"pre_stop_delay", s.cfg.PreStopDelay, "drain_timeout", s.cfg.DrainTimeout)
+
+	var errs []error
+	for _, p := range s.phases() {
+		log.Info("shutdown phase started", "phase", p.name)
+		start := time.Now()
+		if err := p.run(); err != nil {
+			log.Error("shutdown phase failed", "phase", p.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", p.name, err))
+			continue
+		}
+		log.Info("shutdown phase completed", "phase", p.name, "duration", time.Since(start))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"traveler/pkg/config"
+)
+
+// recorder collects the order in which shutdown steps happen.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) step(event string) func() error {
+	return func() error {
+		r.add(event)
+		return nil
+	}
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+// serve runs app on a random local port and returns its base URL.
+func serve(t *testing.T, app *fiber.App) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+	return "http://" + ln.Addr().String()
+}
+
+// newTestShutdown returns a shutdown whose public app serves handler on /work.
+func newTestShutdown(t *testing.T, cfg config.ShutdownConfig, handler fiber.Handler) (*shutdown, string, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	public := fiber.New(fiber.Config{DisableStartupMessage: true})
+	public.Get("/work", handler)
+	baseURL := serve(t, public)
+
+	ready := &atomic.Bool{}
+	ready.Store(true)
+	bg := &workers{}
+	for _, name := range []string{"first", "second"} {
+		bg.start(name, func(ctx context.Context) {
+			<-ctx.Done()
+			rec.add("worker " + name)
+		})
+	}
+	return &shutdown{
+		cfg:          cfg,
+		ready:        ready,
+		public:       public,
+		admin:        fiber.New(),
+		workers:      bg,
+		closeStreams: func() { rec.add("event streams") },
+		stopShipping: rec.step("log shipping"),
+		flushLogs:    rec.step("flush logs"),
+		closeDB:      rec.step("database"),
+	}, baseURL, rec
+}
+
+func TestShutdown_ReadinessFailsBeforeTrafficStops(t *testing.T) {
+	sd, baseURL, rec := newTestShutdown(t, config.ShutdownConfig{
+		PreStopDelay: 300 * time.Millisecond,
+		DrainTimeout: time.Second,
+	}, func(c *fiber.Ctx) error { return c.SendString("done") })
+
+	done := make(chan error, 1)
+	go func() { done <- sd.run() }()
+
+	require.Eventually(t, func() bool { return !sd.ready.Load() }, time.Second, 5*time.Millisecond)
+	// During the pre-stop delay requests are still served.
+	resp, err := http.Get(baseURL + "/work")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, rec.list(), "nothing may stop during the pre-stop delay")
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"event streams", "worker first", "worker second", "log shipping", "flush logs", "database"}, rec.list())
+
+	_, err = http.Get(baseURL + "/work")
+	assert.Error(t, err, "the public listener is closed after draining")
+}
+
+func TestShutdown_DrainWaitsForInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	sd, baseURL, rec := newTestShutdown(t, config.ShutdownConfig{DrainTimeout: 2 * time.Second},
+		func(c *fiber.Ctx) error {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			return c.SendString("done")
+		})
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(baseURL + "/work")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+
+	require.NoError(t, sd.run())
+	assert.Equal(t, http.StatusOK, <-status)
+	assert.Equal(t, "database", rec.list()[len(rec.list())-1])
+}
+
+func TestShutdown_DrainDeadlineStillClosesEverything(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	sd, baseURL, rec := newTestShutdown(t, config.ShutdownConfig{DrainTimeout: 100 * time.Millisecond},
+		func(c *fiber.Ctx) error {
+			close(started)
+			<-release
+			return c.SendString("done")
+		})
+
+	go func() {
+		if resp, err := http.Get(baseURL + "/work"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	start := time.Now()
+	err := sd.run()
+	assert.ErrorContains(t, err, "drain: in-flight requests did not finish within 100ms")
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []string{"event streams", "worker first", "worker second", "log shipping", "flush logs", "database"}, rec.list())
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"traveler/pkg/config"
+	"traveler/pkg/log"
+)
+
+// certReloader serves the current server certificate and reloads it when the certificate
+// or key file changes, so renewed certificates are used without a restart.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+	debounce time.Duration
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, debounce: 250 * time.Millisecond}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	r.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// watch reloads the certificate on file changes until ctx is cancelled. A pair that fails
+// to load (e.g. the key is written after the certificate) keeps the previous one in use.
+func (r *certReloader) watch(ctx context.Context) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warn("TLS certificate reload disabled", "error", err)
+		return
+	}
+	defer fw.Close()
+
+	dirs := map[string]bool{filepath.Dir(r.certFile): true, filepath.Dir(r.keyFile): true}
+	for dir := range dirs {
+		if err := fw.Add(dir); err != nil {
+			log.Warn("TLS certificate reload disabled", "dir", dir, "error", err)
+			return
+		}
+	}
+
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return
+			}
+			name := filepath.Clean(ev.Name)
+			// Kubernetes secret volumes swap a "..data" symlink instead of writing the files.
+			if name != filepath.Clean(r.certFile) && name != filepath.Clean(r.keyFile) &&
+				!strings.HasPrefix(filepath.Base(name), "..") {
+				continue
+			}
+			timer = time.After(r.debounce)
+		case <-timer:
+			timer = nil
+			if err := r.load(); err != nil {
+				log.Error("failed to reload TLS certificate, keeping the previous one", "error", err)
+				continue
+			}
+			log.Info("TLS certificate reloaded", "cert_file", r.certFile)
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return
+			}
+			log.Warn("TLS certificate watcher error", "error", err)
+		}
+	}
+}
+
+// newTLSConfig builds the server TLS configuration from server.tls.
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, *certReloader, error) {
+	reloader, err := newCertReloader(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsCfg := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+	if v, ok := config.TLSVersions[cfg.MinVersion]; ok {
+		tlsCfg.MinVersion = v
+	}
+	for _, name := range cfg.CipherSuites {
+		id, ok := config.CipherSuite(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		tlsCfg.CipherSuites = append(tlsCfg.CipherSuites, id)
+	}
+
+	switch cfg.ClientAuth {
+	case "request":
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if tlsCfg.ClientAuth != tls.NoClientCert {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read client CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("no certificates found in client CA bundle %s", cfg.ClientCAFile)
+		}
+		tlsCfg.ClientCAs = pool
+	}
+	return tlsCfg, reloader, nil
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"traveler/pkg/auth"
+	"traveler/pkg/config"
+)
+
+// testPKI is a throwaway CA issuing server and client certificates.
+type testPKI struct {
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caPEM  []byte
+	serial int64
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "traveler test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testPKI{ca: ca, caKey: key, caPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), serial: 1}
+}
+
+// issue returns PEM encoded certificate and key.
+func (p *testPKI) issue(t *testing.T, subject pkix.Name, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p.serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(p.serial),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca, &key.PublicKey, p.caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+// startTLSApp serves app on a random local port and returns its base URL.
+func startTLSApp(t *testing.T, app *fiber.App, tlsCfg *tls.Config) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", tlsCfg)
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+	return "https://" + ln.Addr().String()
+}
+
+func TestTLS_MutualAuthMapsClientCertToPrincipal(t *testing.T) {
+	dir := t.TempDir()
+	pki := newTestPKI(t)
+	serverCert, serverKey := pki.issue(t, pkix.Name{CommonName: "localhost"}, x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Join(dir, "server.crt"), serverCert)
+	writeFile(t, filepath.Join(dir, "server.key"), serverKey)
+	writeFile(t, filepath.Join(dir, "ca.crt"), pki.caPEM)
+
+	cfg := &config.Config{Server: config.ServerConfig{TLS: config.TLSConfig{
+		Enabled:        true,
+		CertFile:       filepath.Join(dir, "server.crt"),
+		KeyFile:        filepath.Join(dir, "server.key"),
+		MinVersion:     "1.2",
+		ClientAuth:     "require",
+		ClientCAFile:   filepath.Join(dir, "ca.crt"),
+		ClientCertAuth: true,
+	}}}
+	tlsCfg, _, err := newTLSConfig(cfg.Server.TLS)
+	require.NoError(t, err)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(auth.ClientCertMiddleware())
+	app.Get("/whoami", auth.JWTMiddleware(cfg), func(c *fiber.Ctx) error {
+		p, _ := auth.PrincipalFrom(c)
+		return c.JSON(p)
+	})
+	baseURL := startTLSApp(t, app, tlsCfg)
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(pki.caPEM)
+
+	t.Run("client certificate authenticates the call", func(t *testing.T) {
+		clientCert, clientKey := pki.issue(t, pkix.Name{CommonName: "billing-service", Organization: []string{"traveler"}}, x509.ExtKeyUsageClientAuth)
+		pair, err := tls.X509KeyPair(clientCert, clientKey)
+		require.NoError(t, err)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs: roots, Certificates: []tls.Certificate{pair},
+		}}}
+
+		resp, err := client.Get(baseURL + "/whoami")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), `"subject":"CN=billing-service,O=traveler"`)
+		assert.Contains(t, string(body), `"source":"client_cert"`)
+	})
+
+	t.Run("clients without a certificate are refused", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+		resp, err := client.Get(baseURL + "/whoami")
+		if err == nil {
+			resp.Body.Close()
+		}
+		assert.Error(t, err)
+	})
+}
+
+func TestCertReloader_PicksUpRenewedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	pki := newTestPKI(t)
+	cert, key := pki.issue(t, pkix.Name{CommonName: "first"}, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, cert)
+	writeFile(t, keyFile, key)
+
+	r, err := newCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	r.debounce = 10 * time.Millisecond
+
+	ctx := t.Context()
+	go r.watch(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	cert, key = pki.issue(t, pkix.Name{CommonName: "renewed"}, x509.ExtKeyUsageServerAuth)
+	writeFile(t, keyFile, key)
+	writeFile(t, certFile, cert)
+
+	require.Eventually(t, func() bool {
+		c, _ := r.GetCertificate(nil)
+		leaf, err := x509.ParseCertificate(c.Certificate[0])
+		return err == nil && leaf.Subject.CommonName == "renewed"
+	}, 3*time.Second, 20*time.Millisecond)
+}
+
+func TestNewTLSConfig_RejectsUnknownCipherSuite(t *testing.T) {
+	dir := t.TempDir()
+	pki := newTestPKI(t)
+	cert, key := pki.issue(t, pkix.Name{CommonName: "localhost"}, x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Join(dir, "server.crt"), cert)
+	writeFile(t, filepath.Join(dir, "server.key"), key)
+
+	_, _, err := newTLSConfig(config.TLSConfig{
+		CertFile:     filepath.Join(dir, "server.crt"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"},
+	})
+	assert.ErrorContains(t, err, "cipher suite")
+}
@@ -0,0 +1,123 @@
+// Package idempotency stores idempotency keys and the responses replayed for them.
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Record is a stored idempotency key.
+type Record struct {
+	Principal   string
+	Key         string
+	Fingerprint string
+	// Status is 0 while the first request is in flight.
+	Status  int
+	Headers map[string][]string
+	Body    []byte
+	// LockedUntil is when an in-flight reservation counts as abandoned.
+	LockedUntil time.Time
+	ExpiresAt   time.Time
+}
+
+// InFlight reports whether the first request has not completed yet.
+func (r Record) InFlight() bool {
+	return r.Status == 0
+}
+
+// Reserve stores rec as in flight and returns true, unless an unexpired record for its
+// principal and key exists; then it returns that record and false. Expired records are
+// replaced, and an abandoned in-flight record with the same fingerprint is taken over.
+func Reserve(ctx context.Context, db *sql.DB, rec Record, now time.Time) (Record, bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Record{}, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	existing, found, err := get(ctx, tx, rec.Principal, rec.Key)
+	if err != nil {
+		return Record{}, false, err
+	}
+	switch {
+	case !found || !existing.ExpiresAt.After(now):
+		const q = `INSERT OR REPLACE INTO idempotency_keys
+			(principal, key, fingerprint, status, headers, body, locked_until, expires_at)
+			VALUES (?, ?, ?, NULL, NULL, NULL, ?, ?)`
+		if _, err := tx.ExecContext(ctx, q, rec.Principal, rec.Key, rec.Fingerprint,
+			rec.LockedUntil.UnixMilli(), rec.ExpiresAt.UnixMilli()); err != nil {
+			return Record{}, false, err
+		}
+	case existing.InFlight() && !existing.LockedUntil.After(now) && existing.Fingerprint == rec.Fingerprint:
+		const q = `UPDATE idempotency_keys SET locked_until = ? WHERE principal = ? AND key = ?`
+		if _, err := tx.ExecContext(ctx, q, rec.LockedUntil.UnixMilli(), rec.Principal, rec.Key); err != nil {
+			return Record{}, false, err
+		}
+	default:
+		return existing, false, nil
+	}
+	return rec, true, tx.Commit()
+}
+
+// Complete stores the response of a reserved key.
+func Complete(ctx context.Context, db *sql.DB, principal, key string, status int, headers map[string][]string, body []byte) error {
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	const q = `UPDATE idempotency_keys SET status = ?, headers = ?, body = ? WHERE principal = ? AND key = ?`
+	res, err := db.ExecContext(ctx, q, status, string(encoded), body, principal, key)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.New("idempotency key reservation is gone")
+	}
+	return nil
+}
+
+// Release deletes a reservation so that the request can be retried.
+func Release(ctx context.Context, db *sql.DB, principal, key string) error {
+	const q = `DELETE FROM idempotency_keys WHERE principal = ? AND key = ?`
+	_, err := db.ExecContext(ctx, q, principal, key)
+	return err
+}
+
+// DeleteExpired removes records that expired before now and returns how many there were.
+func DeleteExpired(ctx context.Context, db *sql.DB, now time.Time) (int64, error) {
+	const q = `DELETE FROM idempotency_keys WHERE expires_at <= ?`
+	res, err := db.ExecContext(ctx, q, now.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func get(ctx context.Context, tx *sql.Tx, principal, key string) (Record, bool, error) {
+	const q = `SELECT fingerprint, COALESCE(status, 0), COALESCE(headers, ''), body, locked_until, expires_at
+		FROM idempotency_keys WHERE principal = ? AND key = ?`
+
+	r := Record{Principal: principal, Key: key}
+	var (
+		headers             string
+		lockedUntil, expiry int64
+	)
+	err := tx.QueryRowContext(ctx, q, principal, key).Scan(&r.Fingerprint, &r.Status, &headers, &r.Body, &lockedUntil, &expiry)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, false, nil
+	}
+	if err != nil {
+		return Record{}, false, err
+	}
+	if headers != "" {
+		if err := json.Unmarshal([]byte(headers), &r.Headers); err != nil {
+			return Record{}, false, err
+		}
+	}
+	r.LockedUntil = time.UnixMilli(lockedUntil)
+	r.ExpiresAt = time.UnixMilli(expiry)
+	return r, true, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -60,6 +61,11 @@ func Init(ctx context.Context, dbPath, schemaPath string) (*sql.DB, error) {
 			_ = dbComm.Close()
 			return nil, err
 		}
+		if err := addColumns(ctx, tx); err != nil {
+			_ = tx.Rollback()
+			_ = dbComm.Close()
+			return nil, err
+		}
 		if _, err := tx.ExecContext(ctx, string(schemaSQL)); err != nil {
 			_ = tx.Rollback()
 			_ = dbComm.Close()
@@ -74,3 +80,38 @@ func Init(ctx context.Context, dbPath, schemaPath string) (*sql.DB, error) {
 
 	return dbComm, nil
 }
+
+// addedColumns are columns added to tables of the schema after their CREATE TABLE was first
+// released. CREATE TABLE IF NOT EXISTS leaves existing tables alone and SQLite has no ADD
+// COLUMN IF NOT EXISTS, so addColumns adds them before the schema, whose triggers and
+// indexes may use them, is applied. Definitions must match the schema file.
+var addedColumns = []struct{ table, column, definition string }{
+	{"specials", "description", "TEXT NOT NULL DEFAULT ''"},
+	{"specials", "hero_images", "TEXT NOT NULL DEFAULT '[]'"},
+	{"specials", "inclusions", "TEXT NOT NULL DEFAULT '[]'"},
+	{"specials", "nights", "INTEGER"},
+	{"specials", "departure_city", "TEXT NOT NULL DEFAULT ''"},
+	{"specials", "destination_id", "TEXT NOT NULL DEFAULT ''"},
+	{"specials", "tags", "TEXT NOT NULL DEFAULT '[]'"},
+}
+
+// addColumns adds the addedColumns missing from existing tables.
+func addColumns(ctx context.Context, tx *sql.Tx) error {
+	for _, c := range addedColumns {
+		var tableExists, columnExists bool
+		const q = `SELECT EXISTS (SELECT 1 FROM pragma_table_info(?1)),
+			EXISTS (SELECT 1 FROM pragma_table_info(?1) WHERE name = ?2)`
+		if err := tx.QueryRowContext(ctx, q, c.table, c.column).Scan(&tableExists, &columnExists); err != nil {
+			return err
+		}
+		if !tableExists || columnExists {
+			continue
+		}
+		stmt := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, c.table, c.column, c.definition)
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", c.table, c.column, err)
+		}
+		log.Info("database column added", "table", c.table, "column", c.column)
+	}
+	return nil
+}
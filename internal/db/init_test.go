@@ -0,0 +1,58 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit_AddsColumnsToExistingTables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traveler.db")
+
+	// A database created before the columns were added.
+	old, err := sql.Open("sqlite", "file:"+path)
+	require.NoError(t, err)
+	_, err = old.Exec(`CREATE TABLE specials (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		price REAL NOT NULL,
+		currency TEXT NOT NULL DEFAULT 'USD',
+		active INTEGER NOT NULL DEFAULT 1,
+		starts_at TEXT,
+		ends_at TEXT,
+		created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	INSERT INTO specials(id, name, price) VALUES ('sp-0001', 'Old Special', 10.0)`)
+	require.NoError(t, err)
+	require.NoError(t, old.Close())
+
+	db, err := Init(t.Context(), path, "../../db/schema.sql")
+	require.NoError(t, err)
+
+	var (
+		tags, heroImages string
+		nights           sql.NullInt64
+	)
+	require.NoError(t, db.QueryRow(`SELECT tags, hero_images, nights FROM specials WHERE id = 'sp-0001'`).
+		Scan(&tags, &heroImages, &nights))
+	assert.Equal(t, "[]", tags)
+	assert.Equal(t, "[]", heroImages)
+	assert.False(t, nights.Valid)
+
+	// The event triggers were recreated with the new columns.
+	_, err = db.Exec(`UPDATE specials SET tags = '["beach"]' WHERE id = 'sp-0001'`)
+	require.NoError(t, err)
+	var data string
+	require.NoError(t, db.QueryRow(`SELECT data FROM specials_events ORDER BY id DESC LIMIT 1`).Scan(&data))
+	assert.Contains(t, data, `"tags":["beach"]`)
+	require.NoError(t, db.Close())
+
+	// Applying the schema again changes nothing.
+	db, err = Init(t.Context(), path, "../../db/schema.sql")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+}
@@ -0,0 +1,169 @@
+package offerings
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// SpecialRecord is a special with all its columns, as imported and exported in bulk.
+type SpecialRecord struct {
+	Special
+	Active bool
+	// StartsAt and EndsAt are RFC 3339 timestamps; empty when not set.
+	StartsAt string
+	EndsAt   string
+}
+
+// args returns the column values of r in the order of ImportSpecials' statements.
+func (r SpecialRecord) args() ([]any, error) {
+	lists := make([]any, 0, 3)
+	for _, list := range [][]string{r.HeroImages, r.Inclusions, r.Tags} {
+		if list == nil {
+			list = []string{}
+		}
+		b, err := json.Marshal(list)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, string(b))
+	}
+	return []any{r.ID, r.Name, r.Price, r.Currency, r.Active, r.StartsAt, r.EndsAt,
+		r.Description, lists[0], lists[1], r.Nights, r.DepartureCity, r.DestinationID, lists[2]}, nil
+}
+
+// ImportResult counts what an import changed.
+type ImportResult struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	// Deleted counts specials missing from a replacing import.
+	Deleted int `json:"deleted"`
+}
+
+// ImportSpecials writes records in a single transaction: new ids are inserted and existing
+// ones updated. With replace, specials whose id is not among the records are deleted. With
+// dryRun the transaction is rolled back, so the result only tells what would change.
+func ImportSpecials(ctx context.Context, db *sql.DB, records []SpecialRecord, replace, dryRun bool) (ImportResult, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	existing, err := specialIDs(ctx, tx)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	const (
+		insert = `INSERT INTO specials(id, name, price, currency, active, starts_at, ends_at,
+				description, hero_images, inclusions, nights, departure_city, destination_id, tags)
+			VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?)`
+		// Unchanged rows are skipped so that the specials version, and with it the ETag of
+		// the specials list, stays the same.
+		update = `UPDATE specials SET name = ?2, price = ?3, currency = ?4, active = ?5,
+				starts_at = NULLIF(?6, ''), ends_at = NULLIF(?7, ''), description = ?8, hero_images = ?9,
+				inclusions = ?10, nights = ?11, departure_city = ?12, destination_id = ?13, tags = ?14
+			WHERE id = ?1 AND (name IS NOT ?2 OR price IS NOT ?3 OR currency IS NOT ?4 OR active IS NOT ?5
+				OR starts_at IS NOT NULLIF(?6, '') OR ends_at IS NOT NULLIF(?7, '') OR description IS NOT ?8
+				OR hero_images IS NOT ?9 OR inclusions IS NOT ?10 OR nights IS NOT ?11
+				OR departure_city IS NOT ?12 OR destination_id IS NOT ?13 OR tags IS NOT ?14)`
+	)
+	var res ImportResult
+	imported := make(map[string]bool, len(records))
+	for _, r := range records {
+		imported[r.ID] = true
+		args, err := r.args()
+		if err != nil {
+			return ImportResult{}, err
+		}
+		if !existing[r.ID] {
+			if _, err := tx.ExecContext(ctx, insert, args...); err != nil {
+				return ImportResult{}, err
+			}
+			res.Created++
+			continue
+		}
+		out, err := tx.ExecContext(ctx, update, args...)
+		if err != nil {
+			return ImportResult{}, err
+		}
+		if n, err := out.RowsAffected(); err != nil {
+			return ImportResult{}, err
+		} else if n > 0 {
+			res.Updated++
+		} else {
+			res.Unchanged++
+		}
+	}
+
+	if replace {
+		for id := range existing {
+			if imported[id] {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM specials WHERE id = ?`, id); err != nil {
+				return ImportResult{}, err
+			}
+			res.Deleted++
+		}
+	}
+
+	if dryRun {
+		return res, nil
+	}
+	return res, tx.Commit()
+}
+
+func specialIDs(ctx context.Context, tx *sql.Tx) (map[string]bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM specials`)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	ids := map[string]bool{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// GetSpecialRecords returns up to limit specials, active or not, with an id greater than
+// after, ordered by id. Exports read every page within tx, so that they see a single state
+// of the table even while an import runs.
+func GetSpecialRecords(ctx context.Context, tx *sql.Tx, after string, limit int) ([]SpecialRecord, error) {
+	const q = `SELECT active, COALESCE(starts_at, ''), COALESCE(ends_at, ''), ` + specialColumns + `
+		FROM specials WHERE id > ? ORDER BY id LIMIT ?`
+
+	rows, err := tx.QueryContext(ctx, q, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var out []SpecialRecord
+	for rows.Next() {
+		var (
+			r   SpecialRecord
+			err error
+		)
+		r.Special, err = scanSpecial(func(dest ...any) error {
+			return rows.Scan(append([]any{&r.Active, &r.StartsAt, &r.EndsAt}, dest...)...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,108 @@
+package offerings
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Event types of the specials event log.
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventExpired = "expired"
+)
+
+// Event is an entry of the specials event log, written by the schema's triggers whenever an
+// active special changes.
+type Event struct {
+	ID        int64
+	Type      string
+	SpecialID string
+	// Data is the special as of the change, as JSON.
+	Data      json.RawMessage
+	CreatedAt time.Time
+}
+
+// GetEventsAfter returns up to limit events with an id greater than after, oldest first.
+func GetEventsAfter(ctx context.Context, db *sql.DB, after int64, limit int) ([]Event, error) {
+	const q = `SELECT id, type, special_id, data, created_at FROM specials_events
+		WHERE id > ? ORDER BY id LIMIT ?`
+
+	rows, err := db.QueryContext(ctx, q, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var out []Event
+	for rows.Next() {
+		var (
+			e             Event
+			data, created string
+		)
+		if err := rows.Scan(&e.ID, &e.Type, &e.SpecialID, &data, &created); err != nil {
+			return nil, err
+		}
+		e.Data = json.RawMessage(data)
+		if e.CreatedAt, err = time.ParseInLocation(sqliteTimestamp, created, time.UTC); err != nil {
+			return nil, fmt.Errorf("invalid specials_events.created_at %q: %w", created, err)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// EventLogBounds describes which events can still be read.
+type EventLogBounds struct {
+	// First is the id of the oldest retained event; Last+1 when none are retained.
+	First int64
+	// Last is the id of the newest event ever written; 0 before the first one.
+	Last int64
+}
+
+// CanResumeAfter reports whether every event after id is still in the log.
+func (b EventLogBounds) CanResumeAfter(id int64) bool {
+	return id >= b.First-1 && id <= b.Last
+}
+
+// GetEventLogBounds returns the current EventLogBounds. Last survives the deletion of old
+// events because AUTOINCREMENT keeps it in sqlite_sequence.
+func GetEventLogBounds(ctx context.Context, db *sql.DB) (EventLogBounds, error) {
+	const q = `SELECT COALESCE((SELECT MIN(id) FROM specials_events), seq + 1), seq
+		FROM sqlite_sequence WHERE name = 'specials_events'`
+
+	var b EventLogBounds
+	err := db.QueryRowContext(ctx, q).Scan(&b.First, &b.Last)
+	if errors.Is(err, sql.ErrNoRows) {
+		return EventLogBounds{First: 1}, nil
+	}
+	return b, err
+}
+
+// DeleteEventsBefore removes events older than t and returns how many there were.
+func DeleteEventsBefore(ctx context.Context, db *sql.DB, t time.Time) (int64, error) {
+	const q = `DELETE FROM specials_events WHERE created_at < ?`
+	res, err := db.ExecContext(ctx, q, t.UTC().Format(sqliteTimestamp+".000"))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ExpireEndedSpecials deactivates active specials whose ends_at has passed, which logs an
+// expired event for each, and returns how many there were.
+func ExpireEndedSpecials(ctx context.Context, db *sql.DB, now time.Time) (int64, error) {
+	const q = `UPDATE specials SET active = 0
+		WHERE active = 1 AND ends_at IS NOT NULL AND ends_at != '' AND julianday(ends_at) <= julianday(?)`
+	res, err := db.ExecContext(ctx, q, now.UTC().Format(sqliteTimestamp))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -3,21 +3,127 @@ package offerings
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 )
 
 // Special represents a travel special offering stored in the database.
 type Special struct {
-	ID       string
-	Name     string
-	Price    float64
-	Currency string
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Currency string  `json:"currency"`
+	// Description and Name may be replaced by a translation, see LocalizeSpecials.
+	Description string `json:"description"`
+	// HeroImages are absolute image URLs; the first is the main image.
+	HeroImages []string `json:"hero_images"`
+	// Inclusions list what the price covers, e.g. "Return flights".
+	Inclusions []string `json:"inclusions"`
+	// Nights is the duration of the trip; nil when it is open.
+	Nights        *int   `json:"nights"`
+	DepartureCity string `json:"departure_city"`
+	// DestinationID is the id of the destination of the trip; empty when there is none.
+	DestinationID string `json:"destination_id"`
+	// Tags are lower-case slugs such as "beach" or "family".
+	Tags []string `json:"tags"`
+}
+
+// specialColumns are the columns scanned by scanSpecial.
+const specialColumns = `id, name, price, currency, description, hero_images, inclusions, nights,
+	departure_city, destination_id, tags`
+
+// scanSpecial scans a row of specialColumns.
+func scanSpecial(scan func(dest ...any) error) (Special, error) {
+	var (
+		s                            Special
+		heroImages, inclusions, tags string
+		nights                       sql.NullInt64
+	)
+	if err := scan(&s.ID, &s.Name, &s.Price, &s.Currency, &s.Description, &heroImages, &inclusions, &nights,
+		&s.DepartureCity, &s.DestinationID, &tags); err != nil {
+		return Special{}, err
+	}
+	for _, list := range []struct {
+		column string
+		raw    string
+		dst    *[]string
+	}{{"hero_images", heroImages, &s.HeroImages}, {"inclusions", inclusions, &s.Inclusions}, {"tags", tags, &s.Tags}} {
+		if err := json.Unmarshal([]byte(list.raw), list.dst); err != nil {
+			return Special{}, fmt.Errorf("invalid specials.%s of %s: %w", list.column, s.ID, err)
+		}
+		if *list.dst == nil {
+			*list.dst = []string{}
+		}
+	}
+	if nights.Valid {
+		n := int(nights.Int64)
+		s.Nights = &n
+	}
+	return s, nil
+}
+
+// SpecialsFilter selects specials.
+type SpecialsFilter struct {
+	// Tags a special must all carry; none selects every special.
+	Tags []string
+}
+
+// MaxFilterTags bounds the tags of one specials query; each adds a subquery.
+const MaxFilterTags = 10
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// NormalizeTag returns a tag in lower case; false if it is not a tag, which is up to 32
+// letters, digits and '-'.
+func NormalizeTag(s string) (string, bool) {
+	tag := strings.ToLower(strings.TrimSpace(s))
+	return tag, tagPattern.MatchString(tag)
+}
+
+// ParseTagFilter returns the filter for tags given as repeated or comma-separated values,
+// normalized, deduplicated and sorted. It fails for an invalid tag or more than
+// MaxFilterTags.
+func ParseTagFilter(values []string) (SpecialsFilter, error) {
+	var f SpecialsFilter
+	for _, value := range values {
+		for _, raw := range strings.Split(value, ",") {
+			tag, ok := NormalizeTag(raw)
+			if !ok {
+				return SpecialsFilter{}, fmt.Errorf("%q is not a tag; tags are up to 32 letters, digits and '-'", raw)
+			}
+			if !slices.Contains(f.Tags, tag) {
+				f.Tags = append(f.Tags, tag)
+			}
+		}
+	}
+	if len(f.Tags) > MaxFilterTags {
+		return SpecialsFilter{}, fmt.Errorf("at most %d tags can be filtered on", MaxFilterTags)
+	}
+	slices.Sort(f.Tags)
+	return f, nil
 }
 
 // GetActiveSpecials return all active specials from the database.
 func GetActiveSpecials(ctx context.Context, db *sql.DB) ([]Special, error) {
-	const q = `SELECT id, name, price, currency FROM specials WHERE active = 1 ORDER BY id`
+	return FindActiveSpecials(ctx, db, SpecialsFilter{})
+}
+
+// FindActiveSpecials returns the active specials matching f, ordered by id.
+func FindActiveSpecials(ctx context.Context, db *sql.DB, f SpecialsFilter) ([]Special, error) {
+	q := `SELECT ` + specialColumns + ` FROM specials WHERE active = 1`
+	var args []any
+	for _, tag := range f.Tags {
+		q += ` AND EXISTS (SELECT 1 FROM json_each(specials.tags) WHERE value = ?)`
+		args = append(args, tag)
+	}
+	q += ` ORDER BY id`
 
-	rows, err := db.QueryContext(ctx, q)
+	rows, err := db.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -29,8 +135,8 @@ func GetActiveSpecials(ctx context.Context, db *sql.DB) ([]Special, error) {
 	var out []Special
 
 	for rows.Next() {
-		var s Special
-		if err := rows.Scan(&s.ID, &s.Name, &s.Price, &s.Currency); err != nil {
+		s, err := scanSpecial(rows.Scan)
+		if err != nil {
 			return nil, err
 		}
 		out = append(out, s)
@@ -42,3 +148,46 @@ func GetActiveSpecials(ctx context.Context, db *sql.DB) ([]Special, error) {
 
 	return out, nil
 }
+
+// GetActiveSpecial returns the active special with the given id; false if there is none.
+func GetActiveSpecial(ctx context.Context, db *sql.DB, id string) (Special, bool, error) {
+	const q = `SELECT ` + specialColumns + ` FROM specials WHERE id = ? AND active = 1`
+
+	s, err := scanSpecial(db.QueryRowContext(ctx, q, id).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Special{}, false, nil
+	}
+	if err != nil {
+		return Special{}, false, err
+	}
+	return s, true, nil
+}
+
+// SpecialsVersion identifies the state of the specials table. The schema's triggers bump it
+// on every insert, update and delete of a special, so a version is never seen twice.
+type SpecialsVersion struct {
+	Number    int64
+	ChangedAt time.Time // time of the last change
+}
+
+// sqliteTimestamp is the format of CURRENT_TIMESTAMP; fractional seconds are accepted too.
+const sqliteTimestamp = "2006-01-02 15:04:05"
+
+// GetSpecialsVersion returns the current SpecialsVersion.
+func GetSpecialsVersion(ctx context.Context, db *sql.DB) (SpecialsVersion, error) {
+	const q = `SELECT version, changed_at FROM specials_version WHERE id = 1`
+
+	var (
+		v       SpecialsVersion
+		changed string
+	)
+	if err := db.QueryRowContext(ctx, q).Scan(&v.Number, &changed); err != nil {
+		return SpecialsVersion{}, err
+	}
+	t, err := time.ParseInLocation(sqliteTimestamp, changed, time.UTC)
+	if err != nil {
+		return SpecialsVersion{}, fmt.Errorf("invalid specials_version.changed_at %q: %w", changed, err)
+	}
+	v.ChangedAt = t
+	return v, nil
+}
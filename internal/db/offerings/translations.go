@@ -0,0 +1,163 @@
+package offerings
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// BaseLocale is the language of specials.name; specials need no translation into it.
+const BaseLocale = "en"
+
+// Translation is the name and description of a special in one locale.
+type Translation struct {
+	Locale      string `json:"locale"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// SpecialExists reports whether a special with the given id exists, active or not.
+func SpecialExists(ctx context.Context, db *sql.DB, id string) (bool, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM specials WHERE id = ?)`, id).Scan(&exists)
+	return exists, err
+}
+
+// GetTranslations returns the translations of a special, ordered by locale.
+func GetTranslations(ctx context.Context, db *sql.DB, specialID string) ([]Translation, error) {
+	const q = `SELECT locale, name, description FROM special_translations WHERE special_id = ? ORDER BY locale`
+
+	rows, err := db.QueryContext(ctx, q, specialID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var out []Translation
+	for rows.Next() {
+		var t Translation
+		if err := rows.Scan(&t.Locale, &t.Name, &t.Description); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// PutTranslation creates or replaces the translation of a special into t.Locale and
+// reports whether it was created. The special must exist.
+func PutTranslation(ctx context.Context, db *sql.DB, specialID string, t Translation) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	const existsQ = `SELECT EXISTS (SELECT 1 FROM special_translations WHERE special_id = ? AND locale = ?)`
+	if err := tx.QueryRowContext(ctx, existsQ, specialID, t.Locale).Scan(&exists); err != nil {
+		return false, err
+	}
+	const upsert = `INSERT INTO special_translations(special_id, locale, name, description) VALUES (?, ?, ?, ?)
+		ON CONFLICT(special_id, locale) DO UPDATE SET name = excluded.name, description = excluded.description
+		WHERE name IS NOT excluded.name OR description IS NOT excluded.description`
+	if _, err := tx.ExecContext(ctx, upsert, specialID, t.Locale, t.Name, t.Description); err != nil {
+		return false, err
+	}
+	return !exists, tx.Commit()
+}
+
+// DeleteTranslation deletes the translation of a special into locale; false if there was
+// none.
+func DeleteTranslation(ctx context.Context, db *sql.DB, specialID, locale string) (bool, error) {
+	res, err := db.ExecContext(ctx, `DELETE FROM special_translations WHERE special_id = ? AND locale = ?`, specialID, locale)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetTranslatedLocales returns the locales active specials are translated into, ordered.
+func GetTranslatedLocales(ctx context.Context, db *sql.DB) ([]string, error) {
+	const q = `SELECT DISTINCT t.locale FROM special_translations t
+		JOIN specials s ON s.id = t.special_id WHERE s.active = 1 ORDER BY t.locale`
+
+	rows, err := db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var out []string
+	for rows.Next() {
+		var locale string
+		if err := rows.Scan(&locale); err != nil {
+			return nil, err
+		}
+		out = append(out, locale)
+	}
+	return out, rows.Err()
+}
+
+// LocalizeSpecials replaces the name and description of each special with its translation
+// into the first of locales it has one for. Specials without any keep their English name,
+// and translations without a description the English description.
+func LocalizeSpecials(ctx context.Context, db *sql.DB, items []Special, locales []string) error {
+	if len(items) == 0 || len(locales) == 0 {
+		return nil
+	}
+	args := make([]any, len(locales))
+	for i, l := range locales {
+		args[i] = l
+	}
+	q := `SELECT special_id, locale, name, description FROM special_translations
+		WHERE locale IN (?` + strings.Repeat(", ?", len(locales)-1) + `)`
+
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	rank := make(map[string]int, len(locales))
+	for i, l := range locales {
+		rank[l] = i
+	}
+	type best struct {
+		rank int
+		t    Translation
+	}
+	chosen := map[string]best{}
+	for rows.Next() {
+		var (
+			id string
+			t  Translation
+		)
+		if err := rows.Scan(&id, &t.Locale, &t.Name, &t.Description); err != nil {
+			return err
+		}
+		if b, ok := chosen[id]; !ok || rank[t.Locale] < b.rank {
+			chosen[id] = best{rank[t.Locale], t}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range items {
+		if b, ok := chosen[items[i].ID]; ok {
+			items[i].Name = b.t.Name
+			if b.t.Description != "" {
+				items[i].Description = b.t.Description
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// Package events fans the specials event log out to the open Server-Sent Events streams.
+// A single Broker polls the log, so the database is read once per change however many
+// clients are connected.
+package events
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	repo "traveler/internal/db/offerings"
+	"traveler/pkg/config"
+	"traveler/pkg/log"
+)
+
+// pageSize bounds the events read from the log at once.
+const pageSize = 100
+
+// retentionInterval is how often events older than the retention are deleted.
+const retentionInterval = 10 * time.Minute
+
+// Reasons a subscription ends.
+var (
+	// ErrTooManySubscribers is returned by Subscribe when max_connections are open.
+	ErrTooManySubscribers = errors.New("too many event stream connections")
+	// ErrSlowSubscriber ends a subscription whose buffer overflowed.
+	ErrSlowSubscriber = errors.New("event stream client is too slow")
+	// ErrClosed ends all subscriptions when the broker closes.
+	ErrClosed = errors.New("event streams are closed")
+)
+
+// Subscription receives the events logged after it was opened.
+type Subscription struct {
+	// C delivers the events in order. It is closed when the subscription ends; Err then
+	// tells why.
+	C <-chan repo.Event
+	// After is the id of the newest event logged before the subscription started; C only
+	// delivers later events.
+	After int64
+
+	ch  chan repo.Event
+	err error // guarded by Broker.mu
+}
+
+// Broker polls the specials event log and delivers new events to its subscriptions.
+type Broker struct {
+	db  *sql.DB
+	cfg config.EventsConfig
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	last   int64 // id of the newest delivered event
+	closed bool
+}
+
+// NewBroker returns a broker for db that delivers the events logged from now on. Run must
+// be started before events are delivered.
+func NewBroker(ctx context.Context, db *sql.DB, cfg config.EventsConfig) (*Broker, error) {
+	bounds, err := repo.GetEventLogBounds(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read specials event log: %w", err)
+	}
+	return &Broker{db: db, cfg: cfg, subs: map[*Subscription]struct{}{}, last: bounds.Last}, nil
+}
+
+// Run polls the event log every poll_interval until ctx is cancelled, and also expires
+// ended specials and deletes events older than the retention. It is meant to run as a
+// background worker.
+func (b *Broker) Run(ctx context.Context) {
+	poll := time.NewTicker(b.cfg.PollInterval)
+	defer poll.Stop()
+	retention := time.NewTicker(retentionInterval)
+	defer retention.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-poll.C:
+			if _, err := repo.ExpireEndedSpecials(ctx, b.db, now); err != nil {
+				log.Warn("failed to expire ended specials", "error", err)
+			}
+			if err := b.poll(ctx); err != nil {
+				log.Warn("failed to read specials event log", "error", err)
+			}
+		case now := <-retention.C:
+			n, err := repo.DeleteEventsBefore(ctx, b.db, now.Add(-b.cfg.Retention))
+			if err != nil {
+				log.Warn("failed to delete old specials events", "error", err)
+				continue
+			}
+			if n > 0 {
+				log.Debug("deleted old specials events", "count", n)
+			}
+		}
+	}
+}
+
+// poll delivers the events logged since the last poll.
+func (b *Broker) poll(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		after := b.last
+		b.mu.Unlock()
+
+		batch, err := repo.GetEventsAfter(ctx, b.db, after, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, e := range batch {
+			b.publish(e)
+		}
+		if len(batch) < pageSize {
+			return nil
+		}
+	}
+}
+
+// publish delivers e to every subscription. Subscriptions that cannot take it end with
+// ErrSlowSubscriber: the client catches up from the log when it reconnects.
+func (b *Broker) publish(e repo.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.last = e.ID
+	for s := range b.subs {
+		select {
+		case s.ch <- e:
+		default:
+			b.end(s, ErrSlowSubscriber)
+		}
+	}
+}
+
+// Subscribe opens a subscription to the events logged from now on.
+func (b *Broker) Subscribe() (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	if limit := b.cfg.MaxConnections; limit > 0 && len(b.subs) >= limit {
+		return nil, ErrTooManySubscribers
+	}
+	ch := make(chan repo.Event, b.cfg.Buffer)
+	s := &Subscription{C: ch, After: b.last, ch: ch}
+	b.subs[s] = struct{}{}
+	return s, nil
+}
+
+// Unsubscribe ends s. It is safe to call more than once.
+func (b *Broker) Unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.end(s, nil)
+}
+
+// Err returns why s ended: ErrSlowSubscriber, ErrClosed or nil after Unsubscribe.
+func (b *Broker) Err(s *Subscription) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return s.err
+}
+
+// Close ends all subscriptions with ErrClosed and refuses new ones, so that open streams
+// finish before the listener drains.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		b.end(s, ErrClosed)
+	}
+}
+
+// Subscribers returns the number of open subscriptions.
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// end removes s and closes its channel; b.mu must be held.
+func (b *Broker) end(s *Subscription, err error) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	s.err = err
+	close(s.ch)
+}
@@ -0,0 +1,182 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appdb "traveler/internal/db"
+	repo "traveler/internal/db/offerings"
+	"traveler/pkg/config"
+)
+
+var testConfig = config.EventsConfig{
+	PollInterval:      10 * time.Millisecond,
+	HeartbeatInterval: time.Second,
+	Retry:             time.Second,
+	Buffer:            8,
+	MaxConnections:    2,
+	Retention:         time.Hour,
+}
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := appdb.Init(t.Context(), filepath.Join(t.TempDir(), "traveler.db"), "../../db/schema.sql")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// startBroker runs a broker until the test ends.
+func startBroker(t *testing.T, db *sql.DB, cfg config.EventsConfig) *Broker {
+	t.Helper()
+	b, err := NewBroker(t.Context(), db, cfg)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return b
+}
+
+func receive(t *testing.T, s *Subscription) repo.Event {
+	t.Helper()
+	select {
+	case e, ok := <-s.C:
+		require.True(t, ok, "subscription ended")
+		return e
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "no event received")
+		return repo.Event{}
+	}
+}
+
+func exec(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+	_, err := db.Exec(query, args...)
+	require.NoError(t, err)
+}
+
+func TestBroker_DeliversLoggedChanges(t *testing.T) {
+	db := newTestDB(t)
+	b := startBroker(t, db, testConfig)
+	s, err := b.Subscribe()
+	require.NoError(t, err)
+
+	exec(t, db, `INSERT INTO specials(id, name, price) VALUES ('sp-2001', 'Island Hopper', 1299.0)`)
+	exec(t, db, `UPDATE specials SET price = 1199.0 WHERE id = 'sp-2001'`)
+	exec(t, db, `UPDATE specials SET active = 0 WHERE id = 'sp-2001'`)
+	// Inactive specials are invisible to clients; changing them logs nothing.
+	exec(t, db, `UPDATE specials SET price = 999.0 WHERE id = 'sp-2001'`)
+	exec(t, db, `DELETE FROM specials WHERE id = 'sp-1002'`)
+
+	var got []string
+	for range 4 {
+		e := receive(t, s)
+		assert.Greater(t, e.ID, s.After)
+		got = append(got, e.Type+" "+e.SpecialID)
+	}
+	assert.Equal(t, []string{
+		"created sp-2001", "updated sp-2001", "expired sp-2001", "expired sp-1002",
+	}, got)
+	select {
+	case e := <-s.C:
+		assert.Fail(t, "unexpected event", "%+v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBroker_ExpiresEndedSpecials(t *testing.T) {
+	db := newTestDB(t)
+	b := startBroker(t, db, testConfig)
+	s, err := b.Subscribe()
+	require.NoError(t, err)
+
+	exec(t, db, `UPDATE specials SET ends_at = ? WHERE id = 'sp-1001'`,
+		time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))
+
+	// Setting ends_at is not a visible change; passing it is.
+	e := receive(t, s)
+	assert.Equal(t, repo.EventExpired, e.Type)
+	assert.Equal(t, "sp-1001", e.SpecialID)
+	assert.JSONEq(t, `{"id":"sp-1001","name":"Winter Escape","price":799,"currency":"USD","description":"",
+		"hero_images":[],"inclusions":[],"nights":null,"departure_city":"","destination_id":"","tags":[]}`, string(e.Data))
+}
+
+func TestBroker_DropsSlowSubscribers(t *testing.T) {
+	db := newTestDB(t)
+	cfg := testConfig
+	cfg.Buffer = 1
+	b := startBroker(t, db, cfg)
+	slow, err := b.Subscribe()
+	require.NoError(t, err)
+
+	exec(t, db, `UPDATE specials SET price = price + 1`)
+
+	require.Eventually(t, func() bool { return b.Subscribers() == 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, b.Err(slow), ErrSlowSubscriber)
+	// The buffered event is still delivered before the channel closes.
+	receive(t, slow)
+	_, ok := <-slow.C
+	assert.False(t, ok)
+}
+
+func TestBroker_LimitsAndClose(t *testing.T) {
+	b, err := NewBroker(t.Context(), newTestDB(t), testConfig)
+	require.NoError(t, err)
+
+	first, err := b.Subscribe()
+	require.NoError(t, err)
+	second, err := b.Subscribe()
+	require.NoError(t, err)
+	_, err = b.Subscribe()
+	assert.ErrorIs(t, err, ErrTooManySubscribers)
+
+	b.Unsubscribe(first)
+	b.Unsubscribe(first)
+	assert.NoError(t, b.Err(first))
+
+	b.Close()
+	_, ok := <-second.C
+	assert.False(t, ok)
+	assert.ErrorIs(t, b.Err(second), ErrClosed)
+	_, err = b.Subscribe()
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestEventLogBounds(t *testing.T) {
+	db := newTestDB(t)
+	ctx := t.Context()
+
+	bounds, err := repo.GetEventLogBounds(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, repo.EventLogBounds{First: 1, Last: 0}, bounds)
+	assert.True(t, bounds.CanResumeAfter(0))
+
+	exec(t, db, `UPDATE specials SET price = price + 1`)
+	bounds, err = repo.GetEventLogBounds(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, repo.EventLogBounds{First: 1, Last: 2}, bounds)
+	assert.True(t, bounds.CanResumeAfter(0))
+	assert.False(t, bounds.CanResumeAfter(3), "ids from another database")
+
+	n, err := repo.DeleteEventsBefore(ctx, db, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	bounds, err = repo.GetEventLogBounds(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, repo.EventLogBounds{First: 3, Last: 2}, bounds)
+	assert.False(t, bounds.CanResumeAfter(1), "events after 1 were deleted")
+	assert.True(t, bounds.CanResumeAfter(2))
+}
@@ -0,0 +1,122 @@
+package grpcserver
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"traveler/pkg/auth"
+	"traveler/pkg/log"
+)
+
+// publicPrefixes are the methods callable without credentials: health checks come from
+// probes and load balancers. Reflection describes the whole API and needs a token.
+var publicPrefixes = []string{
+	"/" + healthpb.Health_ServiceDesc.ServiceName + "/",
+}
+
+func isPublic(fullMethod string) bool {
+	for _, prefix := range publicPrefixes {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticator stores the caller of each call as its principal, see
+// auth.PrincipalFromContext, and rejects calls without valid credentials.
+type authenticator struct {
+	validator *auth.TokenValidator
+	certAuth  bool
+}
+
+func (a *authenticator) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		token, ok := auth.BearerToken(values[0])
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "authorization metadata must be a bearer token")
+		}
+		_, p, err := a.validator.Validate(token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid access token")
+		}
+		return auth.ContextWithPrincipal(ctx, p), nil
+	}
+
+	// Verified client certificates may stand in for a token (service-to-service calls).
+	if a.certAuth {
+		if pr, ok := peer.FromContext(ctx); ok {
+			if info, ok := pr.AuthInfo.(credentials.TLSInfo); ok {
+				if p, ok := auth.ClientCertPrincipal(&info.State); ok {
+					return auth.ContextWithPrincipal(ctx, p), nil
+				}
+			}
+		}
+	}
+	return nil, status.Error(codes.Unauthenticated, "missing access token")
+}
+
+func (a *authenticator) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if isPublic(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	ctx, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if isPublic(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	ctx, err := a.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// contextStream replaces the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// recoverUnary turns a panic in a handler into a logged Internal error, like
+// middleware.Recover for HTTP.
+func recoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer recovered(info.FullMethod, &err)
+	return handler(ctx, req)
+}
+
+func recoverStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer recovered(info.FullMethod, &err)
+	return handler(srv, ss)
+}
+
+func recovered(method string, err *error) {
+	if r := recover(); r != nil {
+		log.Error("panic while handling gRPC call",
+			"panic", r,
+			"method", method,
+			"stack", string(debug.Stack()),
+		)
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
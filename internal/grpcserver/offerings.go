@@ -0,0 +1,71 @@
+package grpcserver
+
+import (
+	"context"
+	"database/sql"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	offeringsv1 "traveler/api/offerings/v1"
+	repo "traveler/internal/db/offerings"
+	"traveler/pkg/log"
+)
+
+// offeringsService implements offerings.v1.OfferingsService.
+type offeringsService struct {
+	offeringsv1.UnimplementedOfferingsServiceServer
+	db *sql.DB
+}
+
+func (s *offeringsService) ListSpecials(ctx context.Context, req *offeringsv1.ListSpecialsRequest) (*offeringsv1.ListSpecialsResponse, error) {
+	filter, err := repo.ParseTagFilter(req.GetTags())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	items, err := repo.FindActiveSpecials(ctx, s.db, filter)
+	if err != nil {
+		log.Error("failed to list specials", "error", err)
+		return nil, status.Error(codes.Internal, "failed to fetch specials")
+	}
+	resp := &offeringsv1.ListSpecialsResponse{Specials: make([]*offeringsv1.Special, 0, len(items))}
+	for _, item := range items {
+		resp.Specials = append(resp.Specials, toSpecial(item))
+	}
+	return resp, nil
+}
+
+func (s *offeringsService) GetSpecial(ctx context.Context, req *offeringsv1.GetSpecialRequest) (*offeringsv1.GetSpecialResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	item, found, err := repo.GetActiveSpecial(ctx, s.db, req.GetId())
+	if err != nil {
+		log.Error("failed to get special", "id", req.GetId(), "error", err)
+		return nil, status.Error(codes.Internal, "failed to fetch special")
+	}
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "special %q not found", req.GetId())
+	}
+	return &offeringsv1.GetSpecialResponse{Special: toSpecial(item)}, nil
+}
+
+func toSpecial(s repo.Special) *offeringsv1.Special {
+	out := &offeringsv1.Special{
+		Id:            s.ID,
+		Name:          s.Name,
+		Price:         s.Price,
+		Currency:      s.Currency,
+		Description:   s.Description,
+		HeroImages:    s.HeroImages,
+		Inclusions:    s.Inclusions,
+		DepartureCity: s.DepartureCity,
+		DestinationId: s.DestinationID,
+		Tags:          s.Tags,
+	}
+	if s.Nights != nil {
+		nights := int32(*s.Nights)
+		out.Nights = &nights
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+// Package grpcserver serves the gRPC API for internal services: the offerings of
+// api/offerings/v1 from the same repository as the REST API, the standard health service
+// and, optionally, server reflection.
+package grpcserver
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	offeringsv1 "traveler/api/offerings/v1"
+	"traveler/pkg/auth"
+	"traveler/pkg/config"
+)
+
+// Server is the gRPC server of the application.
+type Server struct {
+	srv    *grpc.Server
+	health *health.Server
+}
+
+// New builds the server. Every call except health checks, reflection included, must carry a
+// Keycloak access token, validated as by auth.JWTMiddleware, or, with
+// server.tls.client_cert_auth, a verified client certificate. With tlsCfg the server
+// speaks TLS using the certificates of the public listener.
+func New(cfg *config.Config, db *sql.DB, tlsCfg *tls.Config) *Server {
+	a := &authenticator{
+		validator: auth.NewTokenValidator(cfg),
+		certAuth:  tlsCfg != nil && cfg.Server.TLS.ClientCertAuth,
+	}
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(recoverUnary, a.unary),
+		grpc.ChainStreamInterceptor(recoverStream, a.stream),
+	}
+	if tlsCfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
+	s := &Server{srv: grpc.NewServer(opts...), health: health.NewServer()}
+	offeringsv1.RegisterOfferingsServiceServer(s.srv, &offeringsService{db: db})
+	healthpb.RegisterHealthServer(s.srv, s.health)
+	if cfg.GRPC.Reflection {
+		reflection.Register(s.srv)
+	}
+	s.SetServing(true)
+	return s
+}
+
+// Serve accepts connections on ln until Shutdown.
+func (s *Server) Serve(ln net.Listener) error {
+	return s.srv.Serve(ln)
+}
+
+// SetServing sets the status reported by the health service, for the server as a whole
+// ("") and for each service.
+func (s *Server) SetServing(serving bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	s.health.SetServingStatus("", status)
+	s.health.SetServingStatus(offeringsv1.OfferingsService_ServiceDesc.ServiceName, status)
+}
+
+// Shutdown stops accepting connections and waits for in-flight calls to finish. When ctx
+// ends first the remaining calls are cancelled and ctx's error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Shutdown()
+	done := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.srv.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,211 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	offeringsv1 "traveler/api/offerings/v1"
+	appdb "traveler/internal/db"
+	"traveler/pkg/config"
+)
+
+const (
+	issuer   = "https://sso.example.com/realms/traveler"
+	audience = "traveler-app"
+)
+
+// issuerKeys serves a JWKS with one RSA key and signs tokens with it.
+type issuerKeys struct {
+	key     *rsa.PrivateKey
+	jwksURL string
+}
+
+func newIssuerKeys(t *testing.T) *issuerKeys {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "test",
+		"alg": "RS256",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(jwks)
+	}))
+	t.Cleanup(srv.Close)
+	return &issuerKeys{key: key, jwksURL: srv.URL}
+}
+
+func (k *issuerKeys) token(t *testing.T, aud string) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": issuer,
+		"aud": aud,
+		"sub": "svc-billing",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	tok.Header["kid"] = "test"
+	signed, err := tok.SignedString(k.key)
+	require.NoError(t, err)
+	return signed
+}
+
+// newTestServer serves a Server over an in-process connection and returns a client
+// connection to it.
+func newTestServer(t *testing.T, keys *issuerKeys) (*Server, *grpc.ClientConn) {
+	t.Helper()
+	db, err := appdb.Init(t.Context(), filepath.Join(t.TempDir(), "traveler.db"), "../../db/schema.sql")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	cfg := &config.Config{
+		Auth: config.AuthConfig{Issuer: issuer, Audience: audience, JWKSURL: keys.jwksURL},
+		GRPC: config.GRPCConfig{Enabled: true, Reflection: true},
+	}
+	srv := New(cfg, db, nil)
+	ln := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return srv, conn
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func TestOfferingsService(t *testing.T) {
+	keys := newIssuerKeys(t)
+	_, conn := newTestServer(t, keys)
+	client := offeringsv1.NewOfferingsServiceClient(conn)
+	ctx := withToken(t.Context(), keys.token(t, audience))
+
+	list, err := client.ListSpecials(ctx, &offeringsv1.ListSpecialsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetSpecials(), 2)
+	assert.Equal(t, "sp-1001", list.GetSpecials()[0].GetId())
+	assert.Equal(t, "Winter Escape", list.GetSpecials()[0].GetName())
+	assert.Equal(t, 799.0, list.GetSpecials()[0].GetPrice())
+	assert.Equal(t, "USD", list.GetSpecials()[0].GetCurrency())
+	assert.Nil(t, list.GetSpecials()[0].Nights, "no fixed duration")
+
+	list, err = client.ListSpecials(ctx, &offeringsv1.ListSpecialsRequest{Tags: []string{"Beach"}})
+	require.NoError(t, err)
+	assert.Empty(t, list.GetSpecials(), "no special is tagged")
+	for _, tags := range [][]string{
+		{"no spaces"},
+		{"t1", "t2", "t3", "t4", "t5", "t6", "t7", "t8", "t9", "t10", "t11"},
+	} {
+		_, err = client.ListSpecials(ctx, &offeringsv1.ListSpecialsRequest{Tags: tags})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), tags)
+	}
+
+	got, err := client.GetSpecial(ctx, &offeringsv1.GetSpecialRequest{Id: "sp-1002"})
+	require.NoError(t, err)
+	assert.Equal(t, "City Break Deluxe", got.GetSpecial().GetName())
+
+	_, err = client.GetSpecial(ctx, &offeringsv1.GetSpecialRequest{Id: "sp-9999"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.GetSpecial(ctx, &offeringsv1.GetSpecialRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAuthentication(t *testing.T) {
+	keys := newIssuerKeys(t)
+	_, conn := newTestServer(t, keys)
+	client := offeringsv1.NewOfferingsServiceClient(conn)
+
+	for name, ctx := range map[string]context.Context{
+		"missing token":  t.Context(),
+		"wrong audience": withToken(t.Context(), keys.token(t, "other-app")),
+		"garbage token":  withToken(t.Context(), "not-a-jwt"),
+		"not bearer":     metadata.AppendToOutgoingContext(t.Context(), "authorization", "Basic dXNlcjpwYXNz"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := client.ListSpecials(ctx, &offeringsv1.ListSpecialsRequest{})
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	}
+}
+
+func TestHealthNeedsNoToken(t *testing.T) {
+	srv, conn := newTestServer(t, newIssuerKeys(t))
+
+	health := healthpb.NewHealthClient(conn)
+	for _, service := range []string{"", offeringsv1.OfferingsService_ServiceDesc.ServiceName} {
+		resp, err := health.Check(t.Context(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	}
+	srv.SetServing(false)
+	resp, err := health.Check(t.Context(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
+
+func TestReflectionNeedsToken(t *testing.T) {
+	keys := newIssuerKeys(t)
+	_, conn := newTestServer(t, keys)
+	client := reflectionpb.NewServerReflectionClient(conn)
+	listServices := func(ctx context.Context) ([]string, error) {
+		stream, err := client.ServerReflectionInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := stream.Send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+		}); err != nil {
+			return nil, err
+		}
+		reply, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		var services []string
+		for _, s := range reply.GetListServicesResponse().GetService() {
+			services = append(services, s.GetName())
+		}
+		return services, nil
+	}
+
+	_, err := listServices(t.Context())
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	services, err := listServices(withToken(t.Context(), keys.token(t, audience)))
+	require.NoError(t, err)
+	assert.Contains(t, services, offeringsv1.OfferingsService_ServiceDesc.ServiceName)
+	assert.Contains(t, services, healthpb.Health_ServiceDesc.ServiceName)
+}
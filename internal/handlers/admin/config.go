@@ -0,0 +1,16 @@
+package admin
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"traveler/pkg/config"
+)
+
+// ConfigHandler returns the effective configuration (file, environment, flags and reloads
+// applied) with secrets and sensitive values redacted.
+// Route: GET /admin/config (admin listener)
+func ConfigHandler(current func() *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(current().Redacted())
+	}
+}
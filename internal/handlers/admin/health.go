@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HealthzHandler reports that the process is alive.
+// Route: GET /healthz (admin listener)
+func HealthzHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// ReadyzHandler reports whether the service can take traffic; check returns the reason
+// it cannot (e.g. the database is unreachable).
+// Route: GET /readyz (admin listener)
+func ReadyzHandler(check func(ctx context.Context) error) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), 2*time.Second)
+		defer cancel()
+		if err := check(ctx); err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"status": "unavailable",
+				"error":  err.Error(),
+			})
+		}
+		return c.JSON(fiber.Map{"status": "ready"})
+	}
+}
@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"traveler/pkg/log"
+)
+
+// LogLevelRequest is the body accepted by PUT /admin/log-level.
+type LogLevelRequest struct {
+	// Level is the new global level (debug/info/warn/error); empty keeps the current one.
+	Level string `json:"level"`
+	// Packages replaces the per-package overrides, e.g. {"auth": "debug"}.
+	// Omit it to keep the current overrides; send {} to clear them.
+	Packages map[string]string `json:"packages"`
+	// RevertAfter restores the configured levels after this duration (e.g. "15m").
+	// Empty uses log.level_revert_after; "0s" or a negative value never reverts.
+	RevertAfter string `json:"revert_after"`
+}
+
+// GetLogLevelHandler returns the log levels currently in effect.
+// Route: GET /admin/log-level (admin listener)
+func GetLogLevelHandler(c *fiber.Ctx) error {
+	return c.JSON(log.Levels())
+}
+
+// PutLogLevelHandler changes the global log level and per-package overrides at runtime.
+// Route: PUT /admin/log-level (admin listener)
+func PutLogLevelHandler(c *fiber.Ctx) error {
+	var req LogLevelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	var revertAfter time.Duration
+	if req.RevertAfter != "" {
+		d, err := time.ParseDuration(req.RevertAfter)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid revert_after duration",
+			})
+		}
+		// An explicit zero means "keep", which SetLevels expresses as a negative delay.
+		if d <= 0 {
+			d = -1
+		}
+		revertAfter = d
+	}
+
+	state, err := log.SetLevels(req.Level, req.Packages, revertAfter)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	log.Info("log level changed via admin endpoint", "ip", c.IP(), "level", state.Level)
+	return c.JSON(state)
+}
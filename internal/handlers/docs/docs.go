@@ -0,0 +1,198 @@
+// Package docs serves the OpenAPI document of the public API and a Swagger UI for it, both
+// embedded in the binary.
+package docs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+	swaggerFiles "github.com/swaggo/files/v2"
+	"gopkg.in/yaml.v3"
+
+	"traveler/internal/openapi"
+	"traveler/pkg/config"
+)
+
+// keycloakScheme is the oauth2 security scheme of api/openapi.yaml used by the Swagger UI login.
+const keycloakScheme = "keycloak"
+
+//go:embed index.html
+var indexHTML []byte
+
+// initializer starts the Swagger UI on the served document. Logins use the authorization
+// code flow with PKCE, so the Keycloak client can be public.
+var initializer = template.Must(template.New("swagger-initializer.js").Parse(`window.onload = function () {
+  const ui = SwaggerUIBundle({
+    url: "/api/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout",
+    oauth2RedirectUrl: window.location.origin + "/docs/oauth2-redirect.html",
+  });
+  ui.initOAuth({
+    clientId: "{{js .ClientID}}",
+    scopes: "openid",
+    usePkceWithAuthorizationCodeGrant: true,
+  });
+  window.ui = ui;
+};
+`))
+
+// Routes of the API document. They are not versioned.
+const (
+	JSONPath = "/api/openapi.json"
+	YAMLPath = "/api/openapi.yaml"
+)
+
+// Paths are the routes of the API document.
+var Paths = []string{JSONPath, YAMLPath}
+
+// Handlers serve the API document and the documentation page.
+type Handlers struct {
+	json, yaml  []byte
+	initializer []byte
+	redirect    []byte
+	// pageCSP allows the Swagger UI scripts and token requests to the issuer; redirectCSP
+	// only the inline script of the OAuth redirect page.
+	pageCSP, redirectCSP string
+	assets               fiber.Handler
+}
+
+// New prepares the documentation of spec for this deployment: the served document is
+// relative to this server and its Keycloak login points at auth.issuer.
+func New(spec *openapi.Spec, cfg *config.Config) (*Handlers, error) {
+	doc, err := published(spec.Document(), cfg.Auth.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	h := &Handlers{
+		assets: filesystem.New(filesystem.Config{
+			Root:   http.FS(swaggerFiles.FS),
+			MaxAge: 3600,
+		}),
+	}
+	if h.json, err = json.Marshal(doc); err != nil {
+		return nil, fmt.Errorf("failed to render OpenAPI document as JSON: %w", err)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to render OpenAPI document as YAML: %w", err)
+	}
+	h.yaml = bytes.Clone(buf.Bytes())
+
+	buf.Reset()
+	if err := initializer.Execute(&buf, cfg.Server.Docs); err != nil {
+		return nil, fmt.Errorf("failed to render Swagger UI initializer: %w", err)
+	}
+	h.initializer = buf.Bytes()
+
+	if h.redirect, err = fs.ReadFile(swaggerFiles.FS, "oauth2-redirect.html"); err != nil {
+		return nil, err
+	}
+	hash, err := scriptHash(h.redirect)
+	if err != nil {
+		return nil, err
+	}
+	h.redirectCSP = "default-src 'none'; script-src " + hash + "; frame-ancestors 'none'; base-uri 'none'"
+
+	connect := "'self'"
+	if u, err := url.Parse(cfg.Auth.Issuer); err == nil && u.Host != "" {
+		connect += " " + u.Scheme + "://" + u.Host
+	}
+	h.pageCSP = "default-src 'none'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+		"img-src 'self' data:; connect-src " + connect + "; frame-ancestors 'none'; base-uri 'none'"
+	return h, nil
+}
+
+// Register adds the documentation routes:
+// GET /api/openapi.json, GET /api/openapi.yaml and the Swagger UI under /docs.
+func (h *Handlers) Register(r fiber.Router) {
+	r.Get(JSONPath, document(fiber.MIMEApplicationJSONCharsetUTF8, h.json))
+	r.Get(YAMLPath, document("application/yaml; charset=utf-8", h.yaml))
+
+	ui := r.Group("/docs", h.headers)
+	ui.Get("/", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.Send(indexHTML)
+	})
+	ui.Get("/swagger-initializer.js", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJavaScriptCharsetUTF8)
+		return c.Send(h.initializer)
+	})
+	ui.Get("/oauth2-redirect.html", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentSecurityPolicy, h.redirectCSP)
+		// The page hands the code to the Swagger UI through window.opener.
+		c.Set("Cross-Origin-Opener-Policy", "unsafe-none")
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.Send(h.redirect)
+	})
+	ui.Use(h.assets)
+}
+
+// headers relaxes the security headers of the public listener for the Swagger UI, which
+// needs scripts, styles and a login popup.
+func (h *Handlers) headers(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentSecurityPolicy, h.pageCSP)
+	c.Set("Cross-Origin-Opener-Policy", "same-origin-allow-popups")
+	return c.Next()
+}
+
+func document(contentType string, body []byte) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, contentType)
+		return c.Send(body)
+	}
+}
+
+// published returns a copy of doc as served to clients: with this server as the only server
+// and the Keycloak login flow pointing at issuer.
+func published(doc *openapi3.T, issuer string) (*openapi3.T, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy OpenAPI document: %w", err)
+	}
+	out, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy OpenAPI document: %w", err)
+	}
+
+	out.Servers = openapi3.Servers{{URL: "/", Description: "This server"}}
+	if out.Components == nil {
+		return out, nil
+	}
+	if s := out.Components.SecuritySchemes[keycloakScheme]; s != nil && s.Value != nil &&
+		s.Value.Flows != nil && s.Value.Flows.AuthorizationCode != nil {
+		endpoints := strings.TrimSuffix(issuer, "/") + "/protocol/openid-connect"
+		s.Value.Flows.AuthorizationCode.AuthorizationURL = endpoints + "/auth"
+		s.Value.Flows.AuthorizationCode.TokenURL = endpoints + "/token"
+	}
+	return out, nil
+}
+
+// scriptHash returns the CSP source expression ('sha256-...') of the inline script of page.
+func scriptHash(page []byte) (string, error) {
+	start := bytes.Index(page, []byte("<script>"))
+	end := bytes.Index(page, []byte("</script>"))
+	if start < 0 || end < start {
+		return "", errors.New("oauth2-redirect.html has no inline script")
+	}
+	sum := sha256.Sum256(page[start+len("<script>") : end])
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'", nil
+}
@@ -0,0 +1,120 @@
+package docs
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"traveler/internal/middleware"
+	"traveler/internal/openapi"
+	"traveler/pkg/config"
+)
+
+const issuer = "https://sso.example.com/realms/traveler"
+
+func newTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+	spec, err := openapi.Load("")
+	require.NoError(t, err)
+	cfg := &config.Config{
+		Server: config.ServerConfig{Docs: config.DocsConfig{Enabled: true, ClientID: "docs-client"}},
+		Auth:   config.AuthConfig{Issuer: issuer},
+	}
+	h, err := New(spec, cfg)
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Use(middleware.SecurityHeaders(config.SecurityHeadersConfig{
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+	}))
+	h.Register(app)
+	return app
+}
+
+func get(t *testing.T, app *fiber.App, path string) (*http.Response, string) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+// keycloakFlow extracts the authorization code flow of the keycloak scheme.
+type keycloakFlow struct {
+	Servers []struct {
+		URL string `json:"url" yaml:"url"`
+	} `json:"servers" yaml:"servers"`
+	Components struct {
+		SecuritySchemes struct {
+			Keycloak struct {
+				Flows struct {
+					AuthorizationCode struct {
+						AuthorizationURL string `json:"authorizationUrl" yaml:"authorizationUrl"`
+						TokenURL         string `json:"tokenUrl" yaml:"tokenUrl"`
+					} `json:"authorizationCode" yaml:"authorizationCode"`
+				} `json:"flows" yaml:"flows"`
+			} `json:"keycloak" yaml:"keycloak"`
+		} `json:"securitySchemes" yaml:"securitySchemes"`
+	} `json:"components" yaml:"components"`
+}
+
+func TestDocument_PointsAtConfiguredIssuer(t *testing.T) {
+	app := newTestApp(t)
+
+	for path, unmarshal := range map[string]func([]byte, any) error{
+		"/api/openapi.json": json.Unmarshal,
+		"/api/openapi.yaml": yaml.Unmarshal,
+	} {
+		t.Run(path, func(t *testing.T) {
+			resp, body := get(t, app, path)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var doc keycloakFlow
+			require.NoError(t, unmarshal([]byte(body), &doc))
+			flow := doc.Components.SecuritySchemes.Keycloak.Flows.AuthorizationCode
+			assert.Equal(t, issuer+"/protocol/openid-connect/auth", flow.AuthorizationURL)
+			assert.Equal(t, issuer+"/protocol/openid-connect/token", flow.TokenURL)
+			require.Len(t, doc.Servers, 1)
+			assert.Equal(t, "/", doc.Servers[0].URL)
+		})
+	}
+}
+
+func TestSwaggerUI(t *testing.T) {
+	app := newTestApp(t)
+
+	resp, body := get(t, app, "/docs")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `src="/docs/swagger-initializer.js"`)
+	csp := resp.Header.Get(fiber.HeaderContentSecurityPolicy)
+	assert.Contains(t, csp, "script-src 'self'")
+	assert.Contains(t, csp, "connect-src 'self' https://sso.example.com")
+
+	_, body = get(t, app, "/docs/swagger-initializer.js")
+	assert.Contains(t, body, `clientId: "docs-client"`)
+	assert.Contains(t, body, `url: "/api/openapi.json"`)
+
+	resp, _ = get(t, app, "/docs/swagger-ui-bundle.js")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = get(t, app, "/docs/oauth2-redirect.html")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Regexp(t, `script-src 'sha256-[A-Za-z0-9+/]+=*'`, resp.Header.Get(fiber.HeaderContentSecurityPolicy))
+	assert.Equal(t, "unsafe-none", resp.Header.Get("Cross-Origin-Opener-Policy"))
+}
+
+func TestSecurityHeadersOutsideDocs(t *testing.T) {
+	app := newTestApp(t)
+	app.Get("/api/ping", func(c *fiber.Ctx) error { return c.SendString("pong") })
+
+	resp, _ := get(t, app, "/api/ping")
+	assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", resp.Header.Get(fiber.HeaderContentSecurityPolicy))
+}
@@ -0,0 +1,629 @@
+package offerings
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	repo "traveler/internal/db/offerings"
+	"traveler/internal/problem"
+	"traveler/pkg/log"
+)
+
+// Media types of bulk imports and exports.
+const (
+	MIMETextCSV = "text/csv"
+	MIMENDJSON  = "application/x-ndjson"
+)
+
+// Import modes: upsert leaves specials missing from the file alone, replace deletes them.
+const (
+	ImportUpsert  = "upsert"
+	ImportReplace = "replace"
+)
+
+const (
+	// maxImportErrors bounds the row errors reported for one import.
+	maxImportErrors = 100
+	// maxNDJSONLine bounds the length of one NDJSON line.
+	maxNDJSONLine = 1 << 20
+	// exportPageSize is the number of specials read from the database at once.
+	exportPageSize = 500
+)
+
+// bulkColumns are the CSV columns, in export order. id, name and price are required on import.
+var bulkColumns = []string{"id", "name", "price", "currency", "active", "starts_at", "ends_at",
+	"description", "hero_images", "inclusions", "nights", "departure_city", "destination_id", "tags"}
+
+// csvListSeparator separates the items of list columns (hero_images, inclusions, tags) in a
+// CSV cell. Unlike commas it needs no quoting and does not occur in URLs.
+const csvListSeparator = "|"
+
+var specialIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// RowError is a problem with one row of an import.
+type RowError struct {
+	// Line is the line of the row in the file, starting at 1 (the CSV header).
+	Line    int    `json:"line"`
+	ID      string `json:"id,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport is the response to an import.
+type ImportReport struct {
+	DryRun bool   `json:"dry_run"`
+	Mode   string `json:"mode"`
+	Rows   int    `json:"rows"`
+	repo.ImportResult
+	// Errors lists invalid rows, at most maxImportErrors; with any, nothing is written.
+	Errors []RowError `json:"errors"`
+}
+
+// specialRow is a special as written in an import or export file.
+type specialRow struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Price    *float64 `json:"price"`
+	Currency string   `json:"currency,omitempty"`
+	Active   *bool    `json:"active,omitempty"`
+	StartsAt string   `json:"starts_at,omitempty"`
+	EndsAt   string   `json:"ends_at,omitempty"`
+
+	Description   string   `json:"description,omitempty"`
+	HeroImages    []string `json:"hero_images,omitempty"`
+	Inclusions    []string `json:"inclusions,omitempty"`
+	Nights        *int     `json:"nights,omitempty"`
+	DepartureCity string   `json:"departure_city,omitempty"`
+	DestinationID string   `json:"destination_id,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// parsedRow is a row of an import file, the line it starts on and the errors found while
+// parsing it.
+type parsedRow struct {
+	line int
+	row  specialRow
+	errs []RowError
+}
+
+// SpecialsImportHandler returns a Fiber handler that imports specials from a CSV
+// (text/csv) or JSON Lines (application/x-ndjson) body. Every row is validated first; if
+// any is invalid the response is 422 with a report of the row errors and nothing is
+// written. Otherwise all rows are written in one transaction: mode=upsert (the default)
+// inserts and updates, mode=replace also deletes the specials missing from the file. With
+// dry_run=true the report tells what would change without changing anything.
+// Route: POST /api/{v1,v2}/admin/offerings/specials:import
+func SpecialsImportHandler(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		mode := c.Query("mode", ImportUpsert)
+		if mode != ImportUpsert && mode != ImportReplace {
+			return problem.Send(c, problem.New(fiber.StatusBadRequest,
+				"The mode parameter must be upsert or replace."))
+		}
+		dryRun := false
+		if v := c.Query("dry_run"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return problem.Send(c, problem.New(fiber.StatusBadRequest,
+					"The dry_run parameter must be true or false."))
+			}
+			dryRun = b
+		}
+
+		var (
+			rows []parsedRow
+			err  error
+		)
+		mediaType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+		switch mediaType {
+		case MIMETextCSV:
+			rows, err = parseCSV(c.Body())
+		case MIMENDJSON:
+			rows, err = parseNDJSON(c.Body())
+		default:
+			return problem.Send(c, problem.New(fiber.StatusUnsupportedMediaType,
+				"Specials are imported as text/csv or application/x-ndjson."))
+		}
+		if err != nil {
+			return problem.Send(c, problem.New(fiber.StatusBadRequest, "The file cannot be read.", err.Error()))
+		}
+		if len(rows) == 0 {
+			return problem.Send(c, problem.New(fiber.StatusBadRequest, "The file contains no specials."))
+		}
+
+		var errs []RowError
+		records := make([]repo.SpecialRecord, 0, len(rows))
+		seen := make(map[string]int, len(rows))
+		for _, r := range rows {
+			if len(r.errs) > 0 {
+				errs = append(errs, r.errs...)
+				continue
+			}
+			rec, rowErrs := validateRow(r)
+			if first, ok := seen[rec.ID]; ok {
+				rowErrs = append(rowErrs, RowError{Line: r.line, ID: rec.ID, Field: "id",
+					Message: fmt.Sprintf("duplicates the special on line %d", first)})
+			} else if rec.ID != "" {
+				seen[rec.ID] = r.line
+			}
+			errs = append(errs, rowErrs...)
+			records = append(records, rec)
+		}
+
+		report := ImportReport{DryRun: dryRun, Mode: mode, Rows: len(rows), Errors: []RowError{}}
+		if len(errs) > 0 {
+			if len(errs) > maxImportErrors {
+				errs = errs[:maxImportErrors]
+			}
+			report.Errors = errs
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(report)
+		}
+
+		res, err := repo.ImportSpecials(requestContext(c), db, records, mode == ImportReplace, dryRun)
+		if err != nil {
+			log.Error("failed to import specials", "error", err)
+			return fiber.ErrInternalServerError
+		}
+		report.ImportResult = res
+		if !dryRun {
+			log.Info("imported specials", "mode", mode, "rows", report.Rows,
+				"created", res.Created, "updated", res.Updated, "deleted", res.Deleted)
+		}
+		return c.JSON(report)
+	}
+}
+
+// utf8BOM is written at the start of CSV files by some spreadsheet applications.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// parseCSV reads the rows of a CSV import. The header names the columns, in any order; an
+// error means the file as a whole cannot be read.
+func parseCSV(body []byte) ([]parsedRow, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, utf8BOM)))
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(h))
+		if !slices.Contains(bulkColumns, name) {
+			return nil, fmt.Errorf("unknown column %q; the columns are %s", h, strings.Join(bulkColumns, ", "))
+		}
+		if _, dup := cols[name]; dup {
+			return nil, fmt.Errorf("column %q appears twice", name)
+		}
+		cols[name] = i
+	}
+	for _, name := range []string{"id", "name", "price"} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var rows []parsedRow
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		var pe *csv.ParseError
+		if errors.As(err, &pe) && errors.Is(pe.Err, csv.ErrFieldCount) {
+			rows = append(rows, parsedRow{line: pe.StartLine, errs: []RowError{{
+				Line:    pe.StartLine,
+				Message: fmt.Sprintf("has %d fields but the header has %d", len(record), len(header)),
+			}}})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		rows = append(rows, csvRow(record, cols, line))
+	}
+}
+
+// csvRow converts a CSV record; numbers and booleans that do not parse are row errors.
+func csvRow(record []string, cols map[string]int, line int) parsedRow {
+	get := func(name string) string {
+		if i, ok := cols[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	list := func(name string) []string {
+		var items []string
+		for _, item := range strings.Split(get(name), csvListSeparator) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	r := parsedRow{line: line, row: specialRow{
+		ID:            get("id"),
+		Name:          get("name"),
+		Currency:      get("currency"),
+		StartsAt:      get("starts_at"),
+		EndsAt:        get("ends_at"),
+		Description:   get("description"),
+		HeroImages:    list("hero_images"),
+		Inclusions:    list("inclusions"),
+		DepartureCity: get("departure_city"),
+		DestinationID: get("destination_id"),
+		Tags:          list("tags"),
+	}}
+	if v := get("price"); v != "" {
+		if p, err := strconv.ParseFloat(v, 64); err == nil {
+			r.row.Price = &p
+		} else {
+			r.errs = append(r.errs, RowError{Line: line, ID: r.row.ID, Field: "price", Message: "must be a number"})
+		}
+	}
+	if v := get("active"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			r.row.Active = &b
+		} else {
+			r.errs = append(r.errs, RowError{Line: line, ID: r.row.ID, Field: "active", Message: "must be true or false"})
+		}
+	}
+	if v := get("nights"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			r.row.Nights = &n
+		} else {
+			r.errs = append(r.errs, RowError{Line: line, ID: r.row.ID, Field: "nights", Message: "must be a whole number"})
+		}
+	}
+	return r
+}
+
+// parseNDJSON reads the rows of a JSON Lines import: one object per line, blank lines
+// skipped. Lines that are not a special are row errors.
+func parseNDJSON(body []byte) ([]parsedRow, error) {
+	sc := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(body, utf8BOM)))
+	sc.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+
+	var rows []parsedRow
+	for line := 1; sc.Scan(); line++ {
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		var row specialRow
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&row)
+		if err == nil && dec.More() {
+			err = errors.New("expected one object per line")
+		}
+		if err != nil {
+			rows = append(rows, parsedRow{line: line, errs: []RowError{ndjsonError(line, row.ID, err)}})
+			continue
+		}
+		rows = append(rows, parsedRow{line: line, row: row})
+	}
+	if err := sc.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("a line is longer than %d bytes", maxNDJSONLine)
+		}
+		return nil, err
+	}
+	return rows, nil
+}
+
+func ndjsonError(line int, id string, err error) RowError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		msg := "must be a string"
+		switch typeErr.Field {
+		case "price":
+			msg = "must be a number"
+		case "active":
+			msg = "must be true or false"
+		case "nights":
+			msg = "must be a whole number"
+		case "hero_images", "inclusions", "tags":
+			msg = "must be an array of strings"
+		}
+		return RowError{Line: line, ID: id, Field: typeErr.Field, Message: msg}
+	}
+	return RowError{Line: line, ID: id, Message: strings.TrimPrefix(err.Error(), "json: ")}
+}
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// maxNights bounds the duration of a special.
+const maxNights = 365
+
+// maxListItems bounds the hero images, inclusions and tags of a special.
+const maxListItems = 20
+
+// validateRow checks a parsed row and converts it to a record. Currency defaults to USD and
+// active to true; times are stored in UTC.
+func validateRow(r parsedRow) (repo.SpecialRecord, []RowError) {
+	row := r.row
+	rec := repo.SpecialRecord{
+		Special: repo.Special{
+			ID:            strings.TrimSpace(row.ID),
+			Name:          strings.TrimSpace(row.Name),
+			Currency:      strings.ToUpper(strings.TrimSpace(row.Currency)),
+			Description:   strings.TrimSpace(row.Description),
+			DepartureCity: strings.TrimSpace(row.DepartureCity),
+			DestinationID: strings.TrimSpace(row.DestinationID),
+			Nights:        row.Nights,
+		},
+		Active: true,
+	}
+	var errs []RowError
+	fail := func(field, msg string) {
+		errs = append(errs, RowError{Line: r.line, ID: rec.ID, Field: field, Message: msg})
+	}
+
+	switch {
+	case rec.ID == "":
+		fail("id", "is required")
+	case !specialIDPattern.MatchString(rec.ID):
+		fail("id", "must be up to 64 letters, digits, '.', '_' or '-', starting with a letter or digit")
+	}
+	if rec.Name == "" {
+		fail("name", "is required")
+	}
+	switch {
+	case row.Price == nil:
+		fail("price", "is required")
+	case math.IsNaN(*row.Price) || math.IsInf(*row.Price, 0):
+		fail("price", "must be a finite number")
+	case *row.Price < 0:
+		fail("price", "must not be negative")
+	default:
+		rec.Price = *row.Price
+	}
+	if rec.Currency == "" {
+		rec.Currency = "USD"
+	} else if !currencyPattern.MatchString(rec.Currency) {
+		fail("currency", "must be a three-letter ISO 4217 code")
+	}
+	if row.Active != nil {
+		rec.Active = *row.Active
+	}
+	if rec.Nights != nil && (*rec.Nights < 1 || *rec.Nights > maxNights) {
+		fail("nights", fmt.Sprintf("must be between 1 and %d", maxNights))
+	}
+	if rec.DestinationID != "" && !specialIDPattern.MatchString(rec.DestinationID) {
+		fail("destination_id", "must be up to 64 letters, digits, '.', '_' or '-', starting with a letter or digit")
+	}
+	for _, list := range []struct {
+		field string
+		items []string
+	}{{"hero_images", row.HeroImages}, {"inclusions", row.Inclusions}, {"tags", row.Tags}} {
+		if len(list.items) > maxListItems {
+			fail(list.field, fmt.Sprintf("must not have more than %d items", maxListItems))
+		}
+	}
+	rec.HeroImages = []string{}
+	for _, raw := range row.HeroImages {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			fail("hero_images", fmt.Sprintf("%q is not an absolute http or https URL", raw))
+			continue
+		}
+		rec.HeroImages = append(rec.HeroImages, u.String())
+	}
+	rec.Inclusions = []string{}
+	for _, item := range row.Inclusions {
+		if item = strings.TrimSpace(item); item != "" {
+			rec.Inclusions = append(rec.Inclusions, item)
+		}
+	}
+	rec.Tags = []string{}
+	for _, raw := range row.Tags {
+		tag, ok := repo.NormalizeTag(raw)
+		if !ok {
+			fail("tags", fmt.Sprintf("%q is not a tag; tags are up to 32 letters, digits and '-'", raw))
+			continue
+		}
+		if !slices.Contains(rec.Tags, tag) {
+			rec.Tags = append(rec.Tags, tag)
+		}
+	}
+
+	starts, ok := parseBulkTime(row.StartsAt)
+	if !ok {
+		fail("starts_at", "must be an RFC 3339 timestamp or a date (YYYY-MM-DD)")
+	}
+	ends, ok := parseBulkTime(row.EndsAt)
+	if !ok {
+		fail("ends_at", "must be an RFC 3339 timestamp or a date (YYYY-MM-DD)")
+	}
+	if !starts.IsZero() && !ends.IsZero() && !ends.After(starts) {
+		fail("ends_at", "must be after starts_at")
+	}
+	if !starts.IsZero() {
+		rec.StartsAt = starts.Format(time.RFC3339)
+	}
+	if !ends.IsZero() {
+		rec.EndsAt = ends.Format(time.RFC3339)
+	}
+	return rec, errs
+}
+
+// parseBulkTime parses an RFC 3339 timestamp or a date (midnight UTC) into UTC. An empty
+// value is the zero time.
+func parseBulkTime(v string) (time.Time, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}, true
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), true
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// SpecialsExportHandler returns a Fiber handler that downloads all specials, active or
+// not, as CSV (format=csv, the default) or JSON Lines (format=ndjson), in the format the
+// import takes. The specials are read a page at a time within one transaction, so the file
+// reflects a single state of the table; a read failure is a 500 before anything is sent.
+// The file is then streamed from memory without holding the database, and each page gets
+// writeTimeout to be written, like the event stream.
+// Route: GET /api/{v1,v2}/admin/offerings/specials:export
+func SpecialsExportHandler(db *sql.DB, writeTimeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		format := c.Query("format", "csv")
+		var contentType string
+		switch format {
+		case "csv":
+			contentType = MIMETextCSV + "; charset=utf-8"
+		case "ndjson":
+			contentType = MIMENDJSON
+		default:
+			return problem.Send(c, problem.New(fiber.StatusBadRequest,
+				"The format parameter must be csv or ndjson."))
+		}
+
+		records, err := readSpecialRecords(requestContext(c), db)
+		if err != nil {
+			log.Error("failed to export specials", "error", err)
+			return fiber.ErrInternalServerError
+		}
+
+		e := &specialsExport{records: records, csv: format == "csv", conn: c.Context().Conn(), writeTimeout: writeTimeout}
+		c.Set(fiber.HeaderContentType, contentType)
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="specials.%s"`, format))
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		c.Context().SetBodyStreamWriter(e.run)
+		return nil
+	}
+}
+
+// readSpecialRecords reads all specials page by page within one read transaction.
+func readSpecialRecords(ctx context.Context, db *sql.DB) ([]repo.SpecialRecord, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var records []repo.SpecialRecord
+	after := ""
+	for {
+		page, err := repo.GetSpecialRecords(ctx, tx, after, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, page...)
+		if len(page) < exportPageSize {
+			return records, nil
+		}
+		after = page[len(page)-1].ID
+	}
+}
+
+// specialsExport writes the specials of one export.
+type specialsExport struct {
+	records      []repo.SpecialRecord
+	csv          bool
+	conn         net.Conn
+	writeTimeout time.Duration
+}
+
+// run writes the file page by page. It runs after the handler has returned, so it must
+// not use the fiber.Ctx. A failed write closes the connection: returning would end the
+// chunked body normally and the client could not tell the file was cut short.
+func (e *specialsExport) run(w *bufio.Writer) {
+	var (
+		cw  *csv.Writer
+		enc *json.Encoder
+	)
+	if e.csv {
+		cw = csv.NewWriter(w)
+		_ = cw.Write(bulkColumns)
+	} else {
+		enc = json.NewEncoder(w)
+	}
+
+	for page := range slices.Chunk(e.records, exportPageSize) {
+		var err error
+		for _, r := range page {
+			if cw != nil {
+				nights := ""
+				if r.Nights != nil {
+					nights = strconv.Itoa(*r.Nights)
+				}
+				err = cw.Write([]string{r.ID, r.Name, strconv.FormatFloat(r.Price, 'f', -1, 64), r.Currency,
+					strconv.FormatBool(r.Active), r.StartsAt, r.EndsAt, r.Description,
+					strings.Join(r.HeroImages, csvListSeparator), strings.Join(r.Inclusions, csvListSeparator),
+					nights, r.DepartureCity, r.DestinationID, strings.Join(r.Tags, csvListSeparator)})
+			} else {
+				err = enc.Encode(specialRow{ID: r.ID, Name: r.Name, Price: &r.Price, Currency: r.Currency,
+					Active: &r.Active, StartsAt: r.StartsAt, EndsAt: r.EndsAt, Description: r.Description,
+					HeroImages: r.HeroImages, Inclusions: r.Inclusions, Nights: r.Nights,
+					DepartureCity: r.DepartureCity, DestinationID: r.DestinationID, Tags: r.Tags})
+			}
+			if err != nil {
+				break
+			}
+		}
+		if cw != nil {
+			cw.Flush()
+			err = errors.Join(err, cw.Error())
+		}
+		if err == nil {
+			err = e.flush(w)
+		}
+		if err != nil {
+			log.Debug("specials export aborted", "error", err)
+			e.abort()
+			return
+		}
+	}
+	if cw != nil {
+		// The header of an empty export is still buffered.
+		cw.Flush()
+	}
+	if err := e.flush(w); err != nil {
+		log.Debug("specials export aborted", "error", err)
+		e.abort()
+	}
+}
+
+// abort closes the connection so that the client sees an incomplete transfer.
+func (e *specialsExport) abort() {
+	if e.conn != nil {
+		_ = e.conn.Close()
+	}
+}
+
+// flush hands the buffered page to the connection within writeTimeout.
+func (e *specialsExport) flush(w *bufio.Writer) error {
+	if e.writeTimeout > 0 && e.conn != nil {
+		_ = e.conn.SetWriteDeadline(time.Now().Add(e.writeTimeout))
+	}
+	return w.Flush()
+}
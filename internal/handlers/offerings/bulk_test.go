@@ -0,0 +1,330 @@
+package offerings
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	repo "traveler/internal/db/offerings"
+	"traveler/internal/openapi"
+)
+
+const importPath = "/api/v1/admin/offerings/specials:import"
+
+func newBulkApp(t *testing.T, db *sql.DB) *fiber.App {
+	t.Helper()
+	spec, err := openapi.Load("../../../api/openapi.yaml")
+	require.NoError(t, err)
+	app := fiber.New()
+	app.Use(spec.Middleware(true))
+	app.Post("/api/v1/admin/offerings/specials\\:import", SpecialsImportHandler(db))
+	app.Get("/api/v1/admin/offerings/specials\\:export", SpecialsExportHandler(db, 0))
+	return app
+}
+
+func importSpecials(t *testing.T, app *fiber.App, query, contentType, body string) (int, ImportReport) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, importPath+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	raw, _ := io.ReadAll(resp.Body)
+	var report ImportReport
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusUnprocessableEntity {
+		require.NoError(t, json.Unmarshal(raw, &report), string(raw))
+	}
+	return resp.StatusCode, report
+}
+
+func specialNames(t *testing.T, db *sql.DB) map[string]string {
+	t.Helper()
+	rows, err := db.Query(`SELECT id, name FROM specials`)
+	require.NoError(t, err)
+	defer func() { _ = rows.Close() }()
+	names := map[string]string{}
+	for rows.Next() {
+		var id, name string
+		require.NoError(t, rows.Scan(&id, &name))
+		names[id] = name
+	}
+	require.NoError(t, rows.Err())
+	return names
+}
+
+func TestSpecialsImport_Upsert(t *testing.T) {
+	db := newTestDB(t)
+	app := newBulkApp(t, db)
+
+	csvBody := "\xef\xbb\xbfID,Name,Price,currency,active,starts_at,ends_at\n" +
+		"sp-1001,Winter Escape,799,USD,true,,\n" + // unchanged
+		"sp-1002,City Break Classic,449,usd,,,\n" +
+		`sp-2001,"Island Hopper, 10 nights",1299.5,EUR,false,2026-12-01,2027-03-01T00:00:00+01:00` + "\n"
+	status, report := importSpecials(t, app, "", "text/csv", csvBody)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, ImportUpsert, report.Mode)
+	assert.Equal(t, 3, report.Rows)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Empty(t, report.Errors)
+
+	var (
+		currency, startsAt, endsAt string
+		active                     bool
+	)
+	require.NoError(t, db.QueryRow(`SELECT currency, active, starts_at, ends_at FROM specials WHERE id = 'sp-2001'`).
+		Scan(&currency, &active, &startsAt, &endsAt))
+	assert.Equal(t, "EUR", currency)
+	assert.False(t, active)
+	assert.Equal(t, "2026-12-01T00:00:00Z", startsAt)
+	assert.Equal(t, "2027-02-28T23:00:00Z", endsAt)
+	assert.Equal(t, "City Break Classic", specialNames(t, db)["sp-1002"])
+
+	ndjson := `{"id":"sp-2002","name":"Alpine Lodge","price":899}` + "\n\n" +
+		`{"id":"sp-2001","name":"Island Hopper","price":1299.5,"currency":"EUR","active":true}` + "\n"
+	status, report = importSpecials(t, app, "", "application/x-ndjson", ndjson)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 2, report.Rows)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Len(t, specialNames(t, db), 4)
+}
+
+func TestSpecialsImport_RichContent(t *testing.T) {
+	db := newTestDB(t)
+	app := newBulkApp(t, db)
+
+	csvBody := "id,name,price,description,hero_images,inclusions,nights,departure_city,destination_id,tags\n" +
+		`sp-5001,Island Hopper,1299,"Ten days, five islands",https://img.example.com/a.jpg | https://img.example.com/b.jpg,` +
+		`Ferries|Breakfast,10,Athens,dest-cyclades,Beach|Family|beach` + "\n"
+	status, report := importSpecials(t, app, "", "text/csv", csvBody)
+	require.Equal(t, http.StatusOK, status, report.Errors)
+
+	s, found, err := repo.GetActiveSpecial(t.Context(), db, "sp-5001")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "Ten days, five islands", s.Description)
+	assert.Equal(t, []string{"https://img.example.com/a.jpg", "https://img.example.com/b.jpg"}, s.HeroImages)
+	assert.Equal(t, []string{"Ferries", "Breakfast"}, s.Inclusions)
+	require.NotNil(t, s.Nights)
+	assert.Equal(t, 10, *s.Nights)
+	assert.Equal(t, "Athens", s.DepartureCity)
+	assert.Equal(t, "dest-cyclades", s.DestinationID)
+	assert.Equal(t, []string{"beach", "family"}, s.Tags, "tags are lower-cased and deduplicated")
+
+	ndjson := `{"id":"sp-5002","name":"Bad Content","price":1,"hero_images":["/relative.jpg"],"nights":0,` +
+		`"tags":["no spaces"],"destination_id":"dest cyclades"}` + "\n" +
+		`{"id":"sp-5003","name":"Wrong Types","price":1,"tags":"beach"}` + "\n"
+	status, report = importSpecials(t, app, "", "application/x-ndjson", ndjson)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+	var fields []string
+	for _, e := range report.Errors {
+		fields = append(fields, e.Field)
+	}
+	assert.Equal(t, []string{"nights", "destination_id", "hero_images", "tags", "tags"}, fields)
+	assert.Equal(t, "must be an array of strings", report.Errors[4].Message)
+}
+
+func TestSpecialsImport_ReplaceAndDryRun(t *testing.T) {
+	db := newTestDB(t)
+	app := newBulkApp(t, db)
+	body := "id,name,price\nsp-1001,Winter Escape,799\nsp-3001,Desert Safari,999\n"
+
+	status, report := importSpecials(t, app, "?mode=replace&dry_run=true", "text/csv", body)
+	require.Equal(t, http.StatusOK, status)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Deleted)
+	assert.Equal(t, map[string]string{"sp-1001": "Winter Escape", "sp-1002": "City Break Deluxe"},
+		specialNames(t, db), "a dry run must not write")
+
+	status, report = importSpecials(t, app, "?mode=replace", "text/csv", body)
+	require.Equal(t, http.StatusOK, status)
+	assert.False(t, report.DryRun)
+	assert.Equal(t, 1, report.Deleted)
+	assert.Equal(t, map[string]string{"sp-1001": "Winter Escape", "sp-3001": "Desert Safari"}, specialNames(t, db))
+}
+
+func TestSpecialsImport_RowErrorsWriteNothing(t *testing.T) {
+	db := newTestDB(t)
+	app := newBulkApp(t, db)
+	before := specialNames(t, db)
+
+	csvBody := "id,name,price,currency,ends_at,starts_at\n" +
+		"sp-4001,Valid Row,100,USD,,\n" +
+		"sp 4002,,abc,USD,,\n" +
+		"sp-4003,Bad Currency,-1,dollars,,\n" +
+		"sp-4004,Backwards,10,USD,2026-01-01,2026-02-01\n" +
+		"sp-4001,Duplicate,10,USD,,\n" +
+		"sp-4005,Too Few Fields\n" +
+		"sp-4006,Not A Number,NaN,USD,,\n" +
+		"sp-4007,Infinite,Inf,USD,,\n"
+	status, report := importSpecials(t, app, "", "text/csv", csvBody)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, 8, report.Rows)
+	assert.Equal(t, []RowError{
+		{Line: 3, ID: "sp 4002", Field: "price", Message: "must be a number"},
+		{Line: 4, ID: "sp-4003", Field: "price", Message: "must not be negative"},
+		{Line: 4, ID: "sp-4003", Field: "currency", Message: "must be a three-letter ISO 4217 code"},
+		{Line: 5, ID: "sp-4004", Field: "ends_at", Message: "must be after starts_at"},
+		{Line: 6, ID: "sp-4001", Field: "id", Message: "duplicates the special on line 2"},
+		{Line: 7, Message: "has 2 fields but the header has 6"},
+		{Line: 8, ID: "sp-4006", Field: "price", Message: "must be a finite number"},
+		{Line: 9, ID: "sp-4007", Field: "price", Message: "must be a finite number"},
+	}, report.Errors)
+	assert.Equal(t, before, specialNames(t, db))
+
+	ndjson := `{"id":"sp-4001","name":"Valid","price":1}` + "\n" +
+		`{"id":"sp-4002","name":"Typo","prize":1}` + "\n" +
+		`{"id":"sp-4003","name":"Wrong Type","price":"1"}` + "\n" +
+		`not json` + "\n" +
+		`{"id":"","name":"No Id","price":1,"starts_at":"tomorrow"}` + "\n"
+	status, report = importSpecials(t, app, "", "application/x-ndjson", ndjson)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+	require.Len(t, report.Errors, 5)
+	assert.Equal(t, RowError{Line: 2, ID: "sp-4002", Message: `unknown field "prize"`}, report.Errors[0])
+	assert.Equal(t, RowError{Line: 3, ID: "sp-4003", Field: "price", Message: "must be a number"}, report.Errors[1])
+	assert.Equal(t, 4, report.Errors[2].Line)
+	assert.Equal(t, RowError{Line: 5, Field: "id", Message: "is required"}, report.Errors[3])
+	assert.Equal(t, "starts_at", report.Errors[4].Field)
+	assert.Equal(t, before, specialNames(t, db))
+}
+
+func TestSpecialsImport_RejectsUnreadableFiles(t *testing.T) {
+	db := newTestDB(t)
+	app := newBulkApp(t, db)
+	for name, tc := range map[string]struct {
+		contentType, body string
+		status            int
+	}{
+		"unknown column":   {"text/csv", "id,name,price,colour\n", http.StatusBadRequest},
+		"missing column":   {"text/csv", "id,name\nsp-1,x\n", http.StatusBadRequest},
+		"duplicate column": {"text/csv", "id,name,price,Name\n", http.StatusBadRequest},
+		"empty file":       {"text/csv", "id,name,price\n", http.StatusBadRequest},
+		"no rows":          {"application/x-ndjson", "\n\n", http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			status, _ := importSpecials(t, app, "", tc.contentType, tc.body)
+			assert.Equal(t, tc.status, status)
+		})
+	}
+
+	// Without the OpenAPI validator in front, the handler checks the media type itself.
+	plain := fiber.New()
+	plain.Post("/import", SpecialsImportHandler(db))
+	req := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(`[]`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := plain.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+}
+
+func TestSpecialsExport(t *testing.T) {
+	db := newTestDB(t)
+	app := newBulkApp(t, db)
+	// More than one page, to cover the keyset paging.
+	for i := range exportPageSize + 2 {
+		_, err := db.Exec(`INSERT INTO specials(id, name, price, active) VALUES (?, ?, ?, ?)`,
+			fmt.Sprintf("sp-9%04d", i), fmt.Sprintf("Deal %d", i), 10+i, i%2)
+		require.NoError(t, err)
+	}
+	_, err := db.Exec(`UPDATE specials SET name = 'Winter Escape, "Deluxe"', ends_at = '2027-02-28T00:00:00Z',
+		description = 'Seven nights in the Alps', hero_images = '["https://img.example.com/alps.jpg"]',
+		inclusions = '["Flights","Ski pass"]', nights = 7, departure_city = 'Boston', destination_id = 'dest-alps',
+		tags = '["ski","winter"]' WHERE id = 'sp-1001'`)
+	require.NoError(t, err)
+
+	export := func(format string) (*http.Response, string) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet,
+			"/api/v1/admin/offerings/specials:export"+format, nil), -1)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := export("")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="specials.csv"`, resp.Header.Get("Content-Disposition"))
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	require.Len(t, lines, exportPageSize+5)
+	assert.Equal(t, "id,name,price,currency,active,starts_at,ends_at,"+
+		"description,hero_images,inclusions,nights,departure_city,destination_id,tags", lines[0])
+	assert.Equal(t, `sp-1001,"Winter Escape, ""Deluxe""",799,USD,true,,2027-02-28T00:00:00Z,`+
+		`Seven nights in the Alps,https://img.example.com/alps.jpg,Flights|Ski pass,7,Boston,dest-alps,ski|winter`, lines[1])
+	assert.Equal(t, "sp-90000,Deal 0,10,USD,false,,,,,,,,,", lines[3])
+
+	resp, body = export("?format=ndjson")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	lines = strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	require.Len(t, lines, exportPageSize+4)
+	assert.JSONEq(t, `{"id":"sp-1002","name":"City Break Deluxe","price":499,"currency":"USD","active":true}`, lines[1])
+	assert.JSONEq(t, `{"id":"sp-1001","name":"Winter Escape, \"Deluxe\"","price":799,"currency":"USD","active":true,
+		"ends_at":"2027-02-28T00:00:00Z","description":"Seven nights in the Alps",
+		"hero_images":["https://img.example.com/alps.jpg"],"inclusions":["Flights","Ski pass"],"nights":7,
+		"departure_city":"Boston","destination_id":"dest-alps","tags":["ski","winter"]}`, lines[0])
+
+	// What is exported can be imported again without changes.
+	_, csvBody := export("?format=csv")
+	for contentType, body := range map[string]string{"text/csv": csvBody, "application/x-ndjson": body} {
+		status, report := importSpecials(t, app, "?mode=replace&dry_run=true", contentType, body)
+		require.Equal(t, http.StatusOK, status, contentType)
+		assert.Equal(t, exportPageSize+4, report.Unchanged, contentType)
+		assert.Zero(t, report.Created+report.Updated+report.Deleted, contentType)
+	}
+
+	resp, _ = export("?format=xml")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestSpecialsExport_EmptyAndFailedReads(t *testing.T) {
+	db := newTestDB(t)
+	app := newBulkApp(t, db)
+	export := func() (*http.Response, string) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/admin/offerings/specials:export", nil), -1)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	_, err := db.Exec(`DELETE FROM specials`)
+	require.NoError(t, err)
+	resp, body := export()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, strings.Join(bulkColumns, ",")+"\n", body)
+
+	// A failed read is reported before anything is sent, not as a truncated file.
+	_, err = db.Exec(`DROP TABLE specials`)
+	require.NoError(t, err)
+	resp, _ = export()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+func TestSpecialsExport_FailedWriteClosesConnection(t *testing.T) {
+	server, client := net.Pipe()
+	t.Cleanup(func() { _ = client.Close() })
+	e := &specialsExport{records: []repo.SpecialRecord{{Special: repo.Special{ID: "sp-1001", Name: "Winter Escape"}}},
+		csv: true, conn: server}
+
+	e.run(bufio.NewWriter(failingWriter{}))
+
+	_, err := client.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF, "the client sees the connection closed instead of a complete body")
+}
@@ -3,10 +3,17 @@ package offerings
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/gofiber/fiber/v2"
 
 	repo "traveler/internal/db/offerings"
+	"traveler/internal/httpcache"
+	"traveler/internal/locale"
+	"traveler/internal/problem"
 	"traveler/pkg/log"
 )
 
@@ -20,12 +27,72 @@ func requestContext(c *fiber.Ctx) context.Context {
 }
 
 // SpecialsHandler returns a Fiber handler that serves the authenticated specials endpoint.
-// Route: GET /api/offerings/specials
-func SpecialsHandler(db *sql.DB) fiber.Handler {
+// Responses carry ETag and Last-Modified validators and conditional GETs get 304. With
+// responseCache the rendered list is kept in memory until the specials table changes.
+//
+// Names and descriptions are translated into the language Accept-Language prefers, falling
+// back through the caller's other languages to English for each special; Content-Language
+// names the preferred language served.
+//
+// The tag query parameter, repeated or comma-separated, lists tags a special must all carry.
+// Route: GET /api/{v1,v2}/offerings/specials
+func SpecialsHandler(db *sql.DB, responseCache bool) fiber.Handler {
+	var cache *specialsCache
+	if responseCache {
+		cache = &specialsCache{}
+	}
+
 	return func(c *fiber.Ctx) error {
 		ctx := requestContext(c)
+		c.Vary(fiber.HeaderAcceptLanguage)
+
+		var tags []string
+		for _, value := range c.Context().QueryArgs().PeekMulti("tag") {
+			tags = append(tags, string(value))
+		}
+		filter, err := repo.ParseTagFilter(tags)
+		if err != nil {
+			return problem.Send(c, problem.New(fiber.StatusBadRequest, "The tag filter is invalid.", err.Error()))
+		}
+
+		version, err := repo.GetSpecialsVersion(ctx, db)
+		if err != nil {
+			log.Error("failed to read specials version", "error", err)
+
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch specials",
+			})
+		}
+
+		available, ok := cache.getLocales(version)
+		if !ok {
+			available, err = repo.GetTranslatedLocales(ctx, db)
+			if err != nil {
+				log.Error("failed to list specials locales", "error", err)
+
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "failed to fetch specials",
+				})
+			}
+			cache.putLocales(version, available)
+		}
+		// Only locales some special is translated into matter, which also bounds the cache.
+		var locales []string
+		for _, l := range locale.Preferences(c.Get(fiber.HeaderAcceptLanguage), repo.BaseLocale) {
+			if slices.Contains(available, l) {
+				locales = append(locales, l)
+			}
+		}
+		key := strings.Join(locales, ",") + "|" + strings.Join(filter.Tags, ",")
+
+		if entry, ok := cache.get(version, key); ok {
+			return entry.Send(c)
+		}
 
-		items, err := repo.GetActiveSpecials(ctx, db)
+		items, err := repo.FindActiveSpecials(ctx, db, filter)
+		if err == nil {
+			err = repo.LocalizeSpecials(ctx, db, items, locales)
+		}
 
 		if err != nil {
 			log.Error("failed to list specials", "error", err)
@@ -35,8 +102,92 @@ func SpecialsHandler(db *sql.DB) fiber.Handler {
 			})
 		}
 
-		// Keep response shape stable: { "items": [ ... ] }
+		// Keep response shape stable: { "items": [ ... ] }, with [] rather than null when empty.
 		// Directly marshal the repo.Special values.
-		return c.JSON(fiber.Map{"items": items})
+		if items == nil {
+			items = []repo.Special{}
+		}
+		body, err := json.Marshal(fiber.Map{"items": items})
+		if err != nil {
+			return err
+		}
+
+		entry := httpcache.NewVersionedEntry(body, fiber.MIMEApplicationJSON, version.Number, version.ChangedAt)
+		entry.ContentLanguage = repo.BaseLocale
+		if len(locales) > 0 {
+			entry.ContentLanguage = locales[0]
+		}
+		cache.put(version, key, entry)
+		return entry.Send(c)
+	}
+}
+
+// maxCachedRenderings bounds the renderings of one table version kept by specialsCache.
+const maxCachedRenderings = 32
+
+// specialsCache holds the rendered specials responses of one table version, one per list of
+// locales and tag filter, and the locales specials are translated into. A nil
+// *specialsCache caches nothing.
+type specialsCache struct {
+	mu      sync.Mutex
+	version repo.SpecialsVersion
+	locales []string
+	entries map[string]httpcache.Entry
+}
+
+// reset drops everything cached for an older version. The caller holds mu.
+func (sc *specialsCache) reset(v repo.SpecialsVersion) {
+	if sc.version != v || sc.entries == nil {
+		sc.version, sc.locales, sc.entries = v, nil, map[string]httpcache.Entry{}
+	}
+}
+
+func (sc *specialsCache) get(v repo.SpecialsVersion, key string) (httpcache.Entry, bool) {
+	if sc == nil {
+		return httpcache.Entry{}, false
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.version != v {
+		return httpcache.Entry{}, false
+	}
+	e, ok := sc.entries[key]
+	return e, ok
+}
+
+func (sc *specialsCache) put(v repo.SpecialsVersion, key string, e httpcache.Entry) {
+	if sc == nil {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.reset(v)
+	if len(sc.entries) < maxCachedRenderings {
+		sc.entries[key] = e
+	}
+}
+
+func (sc *specialsCache) getLocales(v repo.SpecialsVersion) ([]string, bool) {
+	if sc == nil {
+		return nil, false
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.version != v || sc.locales == nil {
+		return nil, false
+	}
+	return sc.locales, true
+}
+
+func (sc *specialsCache) putLocales(v repo.SpecialsVersion, locales []string) {
+	if sc == nil {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.reset(v)
+	if locales == nil {
+		locales = []string{}
 	}
+	sc.locales = locales
 }
@@ -0,0 +1,207 @@
+package offerings
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appdb "traveler/internal/db"
+	repo "traveler/internal/db/offerings"
+	"traveler/internal/openapi"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := appdb.Init(t.Context(), filepath.Join(t.TempDir(), "traveler.db"), "../../../db/schema.sql")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func getSpecials(t *testing.T, app *fiber.App, header, value string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/specials", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+// backdateSpecials moves the last change of the specials into the past. Responses only carry
+// Last-Modified once the second of the last change is over.
+func backdateSpecials(t *testing.T, db *sql.DB) {
+	t.Helper()
+	_, err := db.Exec(`UPDATE specials_version SET changed_at = '2026-01-01 00:00:00'`)
+	require.NoError(t, err)
+}
+
+func TestSpecialsHandler_ConditionalGets(t *testing.T) {
+	for _, cached := range []bool{true, false} {
+		t.Run(map[bool]string{true: "with response cache", false: "without response cache"}[cached], func(t *testing.T) {
+			db := newTestDB(t)
+			backdateSpecials(t, db)
+			app := fiber.New()
+			app.Get("/specials", SpecialsHandler(db, cached))
+
+			resp, body := getSpecials(t, app, "", "")
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Contains(t, body, "Winter Escape")
+			etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+			require.NotEmpty(t, etag)
+			require.NotEmpty(t, lastModified)
+
+			resp, body = getSpecials(t, app, "If-None-Match", etag)
+			assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+			assert.Empty(t, body)
+
+			resp, _ = getSpecials(t, app, "If-Modified-Since", lastModified)
+			assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+			// A write changes the representation and must not be hidden by the cache.
+			_, err := db.Exec(`UPDATE specials SET price = 649.0 WHERE id = 'sp-1001'`)
+			require.NoError(t, err)
+
+			resp, body = getSpecials(t, app, "If-None-Match", etag)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Contains(t, body, "649")
+			assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+		})
+	}
+}
+
+func TestSpecialsHandler_DeleteInvalidatesValidators(t *testing.T) {
+	for _, cached := range []bool{true, false} {
+		t.Run(map[bool]string{true: "with response cache", false: "without response cache"}[cached], func(t *testing.T) {
+			db := newTestDB(t)
+			_, err := db.Exec(`INSERT INTO specials(id, name, price) VALUES ('sp-2001', 'Island Hopper', 1299.0)`)
+			require.NoError(t, err)
+			backdateSpecials(t, db)
+			app := fiber.New()
+			app.Get("/specials", SpecialsHandler(db, cached))
+
+			resp, _ := getSpecials(t, app, "", "")
+			etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+			require.NotEmpty(t, lastModified)
+
+			// Deleting the newest row moves MAX(updated_at) backwards; the version moves on.
+			_, err = db.Exec(`DELETE FROM specials WHERE id = 'sp-2001'`)
+			require.NoError(t, err)
+
+			resp, body := getSpecials(t, app, "If-None-Match", etag)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.NotContains(t, body, "Island Hopper")
+			assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+
+			resp, body = getSpecials(t, app, "If-Modified-Since", lastModified)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.NotContains(t, body, "Island Hopper")
+		})
+	}
+}
+
+func TestSpecialsHandler_CacheSeesInsertsAndDeletes(t *testing.T) {
+	db := newTestDB(t)
+	app := fiber.New()
+	app.Get("/specials", SpecialsHandler(db, true))
+
+	_, body := getSpecials(t, app, "", "")
+	assert.NotContains(t, body, "Island Hopper")
+
+	_, err := db.Exec(`INSERT INTO specials(id, name, price) VALUES ('sp-2001', 'Island Hopper', 1299.0)`)
+	require.NoError(t, err)
+	_, body = getSpecials(t, app, "", "")
+	assert.Contains(t, body, "Island Hopper")
+
+	_, err = db.Exec(`DELETE FROM specials WHERE id = 'sp-2001'`)
+	require.NoError(t, err)
+	_, body = getSpecials(t, app, "", "")
+	assert.NotContains(t, body, "Island Hopper")
+}
+
+func TestSpecialsHandler_ResponseMatchesOpenAPIDocument(t *testing.T) {
+	spec, err := openapi.Load("../../../api/openapi.yaml")
+	require.NoError(t, err)
+
+	db := newTestDB(t)
+	app := fiber.New()
+	app.Use(spec.Middleware(true))
+	app.Get("/api/v1/offerings/specials", SpecialsHandler(db, true))
+
+	for _, query := range []string{
+		"",
+		"DELETE FROM specials", // an empty list is still an array
+	} {
+		if query != "" {
+			_, err := db.Exec(query)
+			require.NoError(t, err)
+		}
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/offerings/specials", nil), -1)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	}
+}
+
+func TestSpecialsHandler_FiltersByTag(t *testing.T) {
+	spec, err := openapi.Load("../../../api/openapi.yaml")
+	require.NoError(t, err)
+
+	db := newTestDB(t)
+	app := fiber.New()
+	app.Use(spec.Middleware(true))
+	app.Get("/api/v1/offerings/specials", SpecialsHandler(db, true))
+
+	_, err = db.Exec(`UPDATE specials SET tags = CASE id
+		WHEN 'sp-1001' THEN '["ski","family"]' ELSE '["city"]' END,
+		hero_images = '["https://img.example.com/x.jpg"]', nights = 3`)
+	require.NoError(t, err)
+
+	ids := func(query string) (int, []string) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/offerings/specials"+query, nil), -1)
+		require.NoError(t, err)
+		var list struct {
+			Items []repo.Special `json:"items"`
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil
+		}
+		require.NoError(t, json.Unmarshal(body, &list), string(body))
+		var out []string
+		for _, s := range list.Items {
+			out = append(out, s.ID)
+		}
+		return resp.StatusCode, out
+	}
+
+	for _, tc := range []struct {
+		query  string
+		status int
+		ids    []string
+	}{
+		{"", http.StatusOK, []string{"sp-1001", "sp-1002"}},
+		{"?tag=ski", http.StatusOK, []string{"sp-1001"}},
+		{"?tag=SKI&tag=family", http.StatusOK, []string{"sp-1001"}},
+		{"?tag=family,city", http.StatusOK, nil},
+		{"?tag=city", http.StatusOK, []string{"sp-1002"}},
+		{"?tag=no%20spaces", http.StatusBadRequest, nil},
+		{"?tag=ski,SKI,ski", http.StatusOK, []string{"sp-1001"}},
+		{"?tag=a,b,c,d,e,f,g,h,i,j,k", http.StatusBadRequest, nil},
+	} {
+		status, got := ids(tc.query)
+		assert.Equal(t, tc.status, status, tc.query)
+		assert.ElementsMatch(t, tc.ids, got, tc.query)
+	}
+}
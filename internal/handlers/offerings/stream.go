@@ -0,0 +1,209 @@
+package offerings
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	repo "traveler/internal/db/offerings"
+	"traveler/internal/events"
+	"traveler/internal/problem"
+	"traveler/pkg/auth"
+	"traveler/pkg/config"
+	"traveler/pkg/log"
+)
+
+// HeaderLastEventID is sent by reconnecting event stream clients.
+const HeaderLastEventID = "Last-Event-ID"
+
+// EventReset tells a client that the events since its Last-Event-ID are no longer in the
+// log; it should fetch the specials again and continue from the reset event.
+const EventReset = "reset"
+
+// streamPageSize bounds the events read from the log at once while a client catches up.
+const streamPageSize = 100
+
+// SpecialsStreamHandler returns a Fiber handler that streams changes to the specials as
+// Server-Sent Events: created, updated and expired events carrying the special as data.
+// Clients that reconnect with Last-Event-ID get the events they missed from the log first.
+//
+// Idle streams get a heartbeat comment every heartbeat_interval. A client that falls more
+// than buffer events behind, or cannot take a write within writeTimeout, is disconnected and
+// resumes from the log when it reconnects. The stream ends when the caller's token expires.
+// Route: GET /api/{v1,v2}/offerings/specials/stream
+func SpecialsStreamHandler(db *sql.DB, broker *events.Broker, cfg config.EventsConfig, writeTimeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var (
+			resumeAfter int64
+			resume      bool
+		)
+		if v := c.Get(HeaderLastEventID); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || id < 0 {
+				return problem.Send(c, problem.New(fiber.StatusBadRequest,
+					"The Last-Event-ID header must be an event id."))
+			}
+			resumeAfter, resume = id, true
+		}
+
+		sub, err := broker.Subscribe()
+		if err != nil {
+			if !errors.Is(err, events.ErrTooManySubscribers) && !errors.Is(err, events.ErrClosed) {
+				return err
+			}
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int((cfg.Retry+time.Second-1)/time.Second)))
+			return problem.Send(c, problem.New(fiber.StatusServiceUnavailable,
+				"Too many event streams are open; try again later."))
+		}
+
+		s := &specialsStream{
+			db:           db,
+			broker:       broker,
+			sub:          sub,
+			cfg:          cfg,
+			conn:         c.Context().Conn(),
+			writeTimeout: writeTimeout,
+			sent:         sub.After,
+		}
+		if p, ok := auth.PrincipalFrom(c); ok {
+			s.expires = p.ExpiresAt
+		}
+		if resume {
+			bounds, err := repo.GetEventLogBounds(requestContext(c), db)
+			if err != nil {
+				broker.Unsubscribe(sub)
+				log.Error("failed to read specials event log", "error", err)
+				return fiber.ErrInternalServerError
+			}
+			if bounds.CanResumeAfter(resumeAfter) {
+				s.sent, s.catchUp = resumeAfter, true
+			} else {
+				s.reset = true
+			}
+		}
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		// Keep nginx and similar proxies from buffering the stream.
+		c.Set("X-Accel-Buffering", "no")
+		c.Context().SetBodyStreamWriter(s.run)
+		return nil
+	}
+}
+
+// specialsStream writes the events of one connection.
+type specialsStream struct {
+	db           *sql.DB
+	broker       *events.Broker
+	sub          *events.Subscription
+	cfg          config.EventsConfig
+	conn         net.Conn
+	writeTimeout time.Duration
+	expires      time.Time
+
+	sent    int64 // id of the last event sent
+	catchUp bool  // send the events after sent from the log first
+	reset   bool  // the client's Last-Event-ID is gone from the log
+}
+
+// run writes the stream until the client goes away, the subscription ends or the token
+// expires. It runs after the handler has returned, so it must not use the fiber.Ctx.
+func (s *specialsStream) run(w *bufio.Writer) {
+	defer s.broker.Unsubscribe(s.sub)
+
+	// The retry field and the initial id give a client that reconnects a place to resume
+	// from, even before it has seen an event.
+	fmt.Fprintf(w, "retry: %d\n", s.cfg.Retry.Milliseconds())
+	if s.reset {
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: {}\n\n", s.sent, EventReset)
+	} else {
+		fmt.Fprintf(w, "id: %d\n\n", s.sent)
+	}
+	if err := s.flush(w); err != nil {
+		return
+	}
+	if s.catchUp {
+		if err := s.sendBacklog(w); err != nil {
+			log.Debug("specials event stream closed", "error", err)
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(s.cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+	var expired <-chan time.Time
+	if !s.expires.IsZero() {
+		timer := time.NewTimer(time.Until(s.expires))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case e, ok := <-s.sub.C:
+			if !ok {
+				log.Debug("specials event stream closed", "reason", s.broker.Err(s.sub))
+				return
+			}
+			if e.ID <= s.sent {
+				continue // already sent from the log
+			}
+			if err := s.send(w, e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			_, _ = w.WriteString(": heartbeat\n\n")
+			if err := s.flush(w); err != nil {
+				return
+			}
+		case <-expired:
+			log.Debug("specials event stream closed", "reason", "token expired")
+			return
+		}
+	}
+}
+
+// sendBacklog sends the logged events after s.sent.
+func (s *specialsStream) sendBacklog(w *bufio.Writer) error {
+	for {
+		batch, err := repo.GetEventsAfter(context.Background(), s.db, s.sent, streamPageSize)
+		if err != nil {
+			log.Error("failed to read specials event log", "error", err)
+			return err
+		}
+		for _, e := range batch {
+			if err := s.send(w, e); err != nil {
+				return err
+			}
+		}
+		if len(batch) < streamPageSize {
+			return nil
+		}
+	}
+}
+
+func (s *specialsStream) send(w *bufio.Writer, e repo.Event) error {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+	if err := s.flush(w); err != nil {
+		return err
+	}
+	s.sent = e.ID
+	return nil
+}
+
+// flush hands the buffered output to the connection. Each write gets writeTimeout, rather
+// than the whole stream as for ordinary responses, so that slow clients are dropped while
+// healthy streams stay open.
+func (s *specialsStream) flush(w *bufio.Writer) error {
+	if s.writeTimeout > 0 && s.conn != nil {
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	}
+	return w.Flush()
+}
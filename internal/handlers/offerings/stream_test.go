@@ -0,0 +1,200 @@
+package offerings
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"traveler/internal/events"
+	"traveler/pkg/auth"
+	"traveler/pkg/config"
+)
+
+var streamConfig = config.EventsConfig{
+	PollInterval:      10 * time.Millisecond,
+	HeartbeatInterval: 50 * time.Millisecond,
+	Retry:             2 * time.Second,
+	Buffer:            8,
+	MaxConnections:    1,
+	Retention:         time.Hour,
+}
+
+// writeTimeout is the server's write timeout; streams must outlive it.
+const writeTimeout = 200 * time.Millisecond
+
+// serveStream serves the stream handler on a local port, with a principal expiring at
+// expires (zero: never), and returns the broker and the stream URL.
+func serveStream(t *testing.T, db *sql.DB, expires time.Time) (*events.Broker, string) {
+	t.Helper()
+	broker, err := events.NewBroker(t.Context(), db, streamConfig)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		broker.Run(ctx)
+	}()
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true, WriteTimeout: writeTimeout})
+	app.Get("/stream", func(c *fiber.Ctx) error {
+		auth.SetPrincipal(c, auth.Principal{Subject: "alice", Source: auth.SourceJWT, ExpiresAt: expires})
+		return c.Next()
+	}, SpecialsStreamHandler(db, broker, streamConfig, writeTimeout))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() {
+		broker.Close()
+		_ = app.ShutdownWithTimeout(time.Second)
+		cancel()
+		<-done
+	})
+	return broker, "http://" + ln.Addr().String() + "/stream"
+}
+
+// sseClient reads the frames of an event stream.
+type sseClient struct {
+	resp *http.Response
+	r    *bufio.Reader
+}
+
+func openStream(t *testing.T, url, lastEventID string) *sseClient {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set(HeaderLastEventID, lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return &sseClient{resp: resp, r: bufio.NewReader(resp.Body)}
+}
+
+// next returns the next frame without its trailing blank line, skipping heartbeats unless
+// heartbeats is set.
+func (s *sseClient) next(t *testing.T, heartbeats bool) string {
+	t.Helper()
+	for {
+		var lines []string
+		for {
+			line, err := s.r.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" {
+				break
+			}
+			lines = append(lines, line)
+		}
+		frame := strings.Join(lines, "\n")
+		if heartbeats || frame != ": heartbeat" {
+			return frame
+		}
+	}
+}
+
+func TestSpecialsStream_DeliversChanges(t *testing.T) {
+	db := newTestDB(t)
+	_, url := serveStream(t, db, time.Time{})
+
+	s := openStream(t, url, "")
+	require.Equal(t, http.StatusOK, s.resp.StatusCode)
+	assert.Equal(t, "text/event-stream", s.resp.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, "no-cache", s.resp.Header.Get(fiber.HeaderCacheControl))
+	assert.Equal(t, "retry: 2000\nid: 0", s.next(t, false))
+
+	_, err := db.Exec(`UPDATE specials SET price = 649.0 WHERE id = 'sp-1001'`)
+	require.NoError(t, err)
+	assert.Equal(t, "id: 1\nevent: updated\n"+
+		`data: {"id":"sp-1001","name":"Winter Escape","price":649.0,"currency":"USD","description":"",`+
+		`"hero_images":[],"inclusions":[],"nights":null,"departure_city":"","destination_id":"","tags":[]}`, s.next(t, false))
+
+	// Heartbeats keep coming after the write timeout of an ordinary response.
+	deadline := time.Now().Add(3 * writeTimeout)
+	for time.Now().Before(deadline) {
+		assert.Equal(t, ": heartbeat", s.next(t, true))
+	}
+}
+
+func TestSpecialsStream_ResumesFromLastEventID(t *testing.T) {
+	db := newTestDB(t)
+	for _, q := range []string{
+		`UPDATE specials SET price = 649.0 WHERE id = 'sp-1001'`,
+		`INSERT INTO specials(id, name, price) VALUES ('sp-2001', 'Island Hopper', 1299.0)`,
+		`DELETE FROM specials WHERE id = 'sp-1002'`,
+	} {
+		_, err := db.Exec(q)
+		require.NoError(t, err)
+	}
+	_, url := serveStream(t, db, time.Time{})
+
+	s := openStream(t, url, "1")
+	assert.Equal(t, "retry: 2000\nid: 1", s.next(t, false))
+	assert.True(t, strings.HasPrefix(s.next(t, false), "id: 2\nevent: created\n"))
+	assert.True(t, strings.HasPrefix(s.next(t, false), "id: 3\nevent: expired\n"))
+}
+
+func TestSpecialsStream_ResetsWhenEventsAreGone(t *testing.T) {
+	db := newTestDB(t)
+	_, err := db.Exec(`UPDATE specials SET price = price + 1`)
+	require.NoError(t, err)
+	_, err = db.Exec(`DELETE FROM specials_events`)
+	require.NoError(t, err)
+	_, url := serveStream(t, db, time.Time{})
+
+	s := openStream(t, url, "0")
+	assert.Equal(t, "retry: 2000\nid: 2\nevent: reset\ndata: {}", s.next(t, false))
+}
+
+func TestSpecialsStream_Limits(t *testing.T) {
+	db := newTestDB(t)
+	_, url := serveStream(t, db, time.Time{})
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// max_connections is 1.
+	s := openStream(t, url, "")
+	assert.Equal(t, http.StatusServiceUnavailable, s.resp.StatusCode)
+	assert.Equal(t, "2", s.resp.Header.Get(fiber.HeaderRetryAfter))
+
+	s = openStream(t, url, "abc")
+	assert.Equal(t, http.StatusBadRequest, s.resp.StatusCode)
+}
+
+func TestSpecialsStream_EndsWhenTokenExpires(t *testing.T) {
+	db := newTestDB(t)
+	broker, url := serveStream(t, db, time.Now().Add(200*time.Millisecond))
+
+	s := openStream(t, url, "")
+	s.next(t, false)
+	_, err := s.r.ReadString('\n')
+	for err == nil {
+		_, err = s.r.ReadString('\n')
+	}
+	assert.Eventually(t, func() bool { return broker.Subscribers() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestSpecialsStream_EndsWhenBrokerCloses(t *testing.T) {
+	db := newTestDB(t)
+	broker, url := serveStream(t, db, time.Time{})
+
+	s := openStream(t, url, "")
+	s.next(t, false)
+	broker.Close()
+	_, err := s.r.ReadString('\n')
+	for err == nil {
+		_, err = s.r.ReadString('\n')
+	}
+	assert.Equal(t, 0, broker.Subscribers())
+}
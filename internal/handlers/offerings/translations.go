@@ -0,0 +1,133 @@
+package offerings
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	repo "traveler/internal/db/offerings"
+	"traveler/internal/locale"
+	"traveler/internal/problem"
+	"traveler/pkg/log"
+)
+
+// TranslationRequest is the body of PUT .../translations/{locale}.
+type TranslationRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// TranslationsHandler returns a Fiber handler that lists the translations of a special.
+// Route: GET /api/{v1,v2}/admin/offerings/specials/:id/translations
+func TranslationsHandler(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := requestContext(c)
+		id := c.Params("id")
+		exists, err := repo.SpecialExists(ctx, db, id)
+		if err != nil {
+			log.Error("failed to look up special", "id", id, "error", err)
+			return fiber.ErrInternalServerError
+		}
+		if !exists {
+			return specialNotFound(c, id)
+		}
+		items, err := repo.GetTranslations(ctx, db, id)
+		if err != nil {
+			log.Error("failed to list translations", "id", id, "error", err)
+			return fiber.ErrInternalServerError
+		}
+		if items == nil {
+			items = []repo.Translation{}
+		}
+		return c.JSON(fiber.Map{"items": items})
+	}
+}
+
+// PutTranslationHandler returns a Fiber handler that creates (201) or replaces (200) the
+// translation of a special into the locale of the path, a BCP 47 tag stored in canonical
+// form ("de-ch" becomes "de-CH").
+// Route: PUT /api/{v1,v2}/admin/offerings/specials/:id/translations/:locale
+func PutTranslationHandler(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := requestContext(c)
+		id := c.Params("id")
+		tag, p := translationLocale(c)
+		if p != nil {
+			return problem.Send(c, *p)
+		}
+		var req TranslationRequest
+		if err := c.BodyParser(&req); err != nil {
+			return problem.Send(c, problem.New(fiber.StatusBadRequest, "The request body must be a JSON object."))
+		}
+		t := repo.Translation{
+			Locale:      tag,
+			Name:        strings.TrimSpace(req.Name),
+			Description: strings.TrimSpace(req.Description),
+		}
+		if t.Name == "" {
+			return problem.Send(c, problem.New(fiber.StatusBadRequest, "The name of a translation is required."))
+		}
+		exists, err := repo.SpecialExists(ctx, db, id)
+		if err != nil {
+			log.Error("failed to look up special", "id", id, "error", err)
+			return fiber.ErrInternalServerError
+		}
+		if !exists {
+			return specialNotFound(c, id)
+		}
+
+		created, err := repo.PutTranslation(ctx, db, id, t)
+		if err != nil {
+			log.Error("failed to store translation", "id", id, "locale", tag, "error", err)
+			return fiber.ErrInternalServerError
+		}
+		c.Set(fiber.HeaderContentLanguage, tag)
+		if created {
+			c.Status(fiber.StatusCreated)
+		}
+		return c.JSON(t)
+	}
+}
+
+// DeleteTranslationHandler returns a Fiber handler that deletes the translation of a
+// special into the locale of the path.
+// Route: DELETE /api/{v1,v2}/admin/offerings/specials/:id/translations/:locale
+func DeleteTranslationHandler(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		tag, p := translationLocale(c)
+		if p != nil {
+			return problem.Send(c, *p)
+		}
+		deleted, err := repo.DeleteTranslation(requestContext(c), db, id, tag)
+		if err != nil {
+			log.Error("failed to delete translation", "id", id, "locale", tag, "error", err)
+			return fiber.ErrInternalServerError
+		}
+		if !deleted {
+			return problem.Send(c, problem.New(fiber.StatusNotFound,
+				"Special "+id+" has no translation into "+tag+"."))
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// translationLocale returns the canonical locale of the path, or the problem with it.
+func translationLocale(c *fiber.Ctx) (string, *problem.Details) {
+	tag, ok := locale.Canonical(c.Params("locale"))
+	if !ok {
+		p := problem.New(fiber.StatusBadRequest, "The locale must be a BCP 47 language tag such as de or pt-BR.")
+		return "", &p
+	}
+	if tag == repo.BaseLocale {
+		p := problem.New(fiber.StatusBadRequest,
+			"Specials are written in "+repo.BaseLocale+"; change the special itself instead.")
+		return "", &p
+	}
+	return tag, nil
+}
+
+func specialNotFound(c *fiber.Ctx, id string) error {
+	return problem.Send(c, problem.New(fiber.StatusNotFound, "Special "+id+" does not exist."))
+}
@@ -0,0 +1,158 @@
+package offerings
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	repo "traveler/internal/db/offerings"
+	"traveler/internal/openapi"
+)
+
+func newTranslationsApp(t *testing.T, db *sql.DB) *fiber.App {
+	t.Helper()
+	spec, err := openapi.Load("../../../api/openapi.yaml")
+	require.NoError(t, err)
+	app := fiber.New()
+	app.Use(spec.Middleware(true))
+	app.Get("/api/v1/offerings/specials", SpecialsHandler(db, true))
+	admin := app.Group("/api/v1/admin/offerings/specials/:id/translations")
+	admin.Get("", TranslationsHandler(db))
+	admin.Put("/:locale", PutTranslationHandler(db))
+	admin.Delete("/:locale", DeleteTranslationHandler(db))
+	return app
+}
+
+func doRequest(t *testing.T, app *fiber.App, method, path, body string, header ...string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	raw, _ := io.ReadAll(resp.Body)
+	return resp, string(raw)
+}
+
+func putTranslation(t *testing.T, app *fiber.App, id, tag, name string) *http.Response {
+	t.Helper()
+	resp, body := doRequest(t, app, http.MethodPut, "/api/v1/admin/offerings/specials/"+id+"/translations/"+tag,
+		`{"name":"`+name+`","description":"`+name+` description"}`)
+	require.Less(t, resp.StatusCode, 300, body)
+	return resp
+}
+
+// localizedNames returns the names of the specials served for acceptLanguage and the
+// Content-Language header.
+func localizedNames(t *testing.T, app *fiber.App, acceptLanguage string) (map[string]string, string) {
+	t.Helper()
+	resp, body := doRequest(t, app, http.MethodGet, "/api/v1/offerings/specials", "", "Accept-Language", acceptLanguage)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	assert.Contains(t, resp.Header.Get("Vary"), "Accept-Language")
+	var list struct {
+		Items []repo.Special `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &list))
+	names := map[string]string{}
+	for _, s := range list.Items {
+		names[s.ID] = s.Name
+	}
+	return names, resp.Header.Get("Content-Language")
+}
+
+func TestSpecialsHandler_NegotiatesLanguage(t *testing.T) {
+	db := newTestDB(t)
+	app := newTranslationsApp(t, db)
+	english := map[string]string{"sp-1001": "Winter Escape", "sp-1002": "City Break Deluxe"}
+
+	names, lang := localizedNames(t, app, "de")
+	assert.Equal(t, english, names)
+	assert.Equal(t, "en", lang)
+
+	putTranslation(t, app, "sp-1001", "de", "Winterflucht")
+	putTranslation(t, app, "sp-1002", "de", "Städtereise Deluxe")
+	putTranslation(t, app, "sp-1001", "de-CH", "Winterferien")
+	putTranslation(t, app, "sp-1001", "fr", "Évasion hivernale")
+
+	for _, tc := range []struct {
+		acceptLanguage string
+		names          map[string]string
+		lang           string
+	}{
+		{"", english, "en"},
+		{"de", map[string]string{"sp-1001": "Winterflucht", "sp-1002": "Städtereise Deluxe"}, "de"},
+		// de-CH falls back to de for the special without a Swiss name.
+		{"de-ch", map[string]string{"sp-1001": "Winterferien", "sp-1002": "Städtereise Deluxe"}, "de-CH"},
+		// French first, then English for the special without a French name.
+		{"fr-CA, en;q=0.9, de;q=0.8", map[string]string{"sp-1001": "Évasion hivernale", "sp-1002": "City Break Deluxe"}, "fr"},
+		{"fr, de;q=0.5", map[string]string{"sp-1001": "Évasion hivernale", "sp-1002": "Städtereise Deluxe"}, "fr"},
+		{"ja, *", english, "en"},
+	} {
+		names, lang := localizedNames(t, app, tc.acceptLanguage)
+		assert.Equal(t, tc.names, names, tc.acceptLanguage)
+		assert.Equal(t, tc.lang, lang, tc.acceptLanguage)
+	}
+
+	resp, body := doRequest(t, app, http.MethodGet, "/api/v1/offerings/specials", "", "Accept-Language", "de")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `"description":"Winterflucht description"`)
+	englishResp, _ := doRequest(t, app, http.MethodGet, "/api/v1/offerings/specials", "")
+	assert.NotEqual(t, englishResp.Header.Get("ETag"), resp.Header.Get("ETag"), "each language has its own ETag")
+}
+
+func TestTranslationsAdmin(t *testing.T) {
+	db := newTestDB(t)
+	app := newTranslationsApp(t, db)
+	base := "/api/v1/admin/offerings/specials/sp-1001/translations"
+
+	resp, body := doRequest(t, app, http.MethodGet, base, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"items":[]}`, body)
+
+	resp = putTranslation(t, app, "sp-1001", "pt-br", "Fuga de Inverno")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "pt-BR", resp.Header.Get("Content-Language"))
+	resp = putTranslation(t, app, "sp-1001", "pt-BR", "Escapada de Inverno")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body = doRequest(t, app, http.MethodGet, base, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"items":[{"locale":"pt-BR","name":"Escapada de Inverno","description":"Escapada de Inverno description"}]}`, body)
+
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPut, base + "/not_a_tag", `{"name":"x"}`, http.StatusBadRequest},
+		{http.MethodPut, base + "/EN", `{"name":"x"}`, http.StatusBadRequest},
+		{http.MethodPut, base + "/de", `{"description":"x"}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/v1/admin/offerings/specials/sp-9999/translations/de", `{"name":"x"}`, http.StatusNotFound},
+		{http.MethodGet, "/api/v1/admin/offerings/specials/sp-9999/translations", "", http.StatusNotFound},
+		{http.MethodDelete, base + "/de", "", http.StatusNotFound},
+		{http.MethodDelete, base + "/pt-BR", "", http.StatusNoContent},
+		{http.MethodDelete, base + "/pt-BR", "", http.StatusNotFound},
+	} {
+		resp, body := doRequest(t, app, tc.method, tc.path, tc.body)
+		assert.Equal(t, tc.status, resp.StatusCode, "%s %s: %s", tc.method, tc.path, body)
+	}
+
+	// Translations go with their special.
+	putTranslation(t, app, "sp-1002", "it", "Città Deluxe")
+	_, err := db.Exec(`DELETE FROM specials WHERE id = 'sp-1002'`)
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM special_translations`).Scan(&n))
+	assert.Zero(t, n)
+}
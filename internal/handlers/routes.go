@@ -1,23 +1,108 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"slices"
+	"traveler/internal/events"
+	"traveler/internal/handlers/admin"
+	"traveler/internal/handlers/docs"
 	"traveler/internal/handlers/offerings"
+	"traveler/internal/idempotency"
+	"traveler/internal/metrics"
 	"traveler/pkg/auth"
 	"traveler/pkg/config"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
 )
 
+// APIVersions are the versions of the public API, oldest first; each is served under
+// /api/<version>.
+var APIVersions = []string{"v1", "v2"}
+
+// CurrentAPIVersion serves /api paths without a version unless Accept asks for another one
+// (see middleware.APIVersions).
+const CurrentAPIVersion = "v1"
+
 // RegisterRoutes registers all application routes with the Fiber app.
-func RegisterRoutes(app *fiber.App, cfg *config.Config, db *sql.DB) {
-	app.Get("/", RootHandler)
+// The watcher provides the configuration in effect, including hot reloads. The broker feeds
+// the specials event streams. apiDocs adds the API document and /docs; nil leaves them out.
+// validate checks requests against the OpenAPI document (nil skips that); it runs after
+// authentication and authorization, so that callers without access learn nothing about the
+// API from validation errors and request bodies are only parsed for them.
+func RegisterRoutes(app *fiber.App, watcher *config.Watcher, db *sql.DB, broker *events.Broker,
+	apiDocs *docs.Handlers, validate fiber.Handler) {
+	cfg := watcher.Current()
+
+	var validated []fiber.Handler
+	if validate != nil {
+		validated = []fiber.Handler{validate}
+	}
+	// public returns the handler chain of a route that needs no token.
+	public := func(h fiber.Handler) []fiber.Handler { return slices.Concat(validated, []fiber.Handler{h}) }
 
-	api := app.Group("/api")
-	api.Get("/ping", PingHandler)
-	api.Get("/ping/simple", PingHandlerSimple)
+	app.Get("/", public(RootHandler)...)
 
 	authMW := auth.JWTMiddleware(cfg)
-	offeringsGroup := api.Group("/offerings", authMW)
-	offeringsGroup.Get("/specials", offerings.SpecialsHandler(db))
+	// Authenticated groups also honour Idempotency-Key on POST and PATCH.
+	idempotent := idempotency.Middleware(db, cfg.Server.Idempotency)
+	offeringsMW := slices.Concat([]fiber.Handler{authMW}, validated, []fiber.Handler{idempotent})
+	specials := offerings.SpecialsHandler(db, cfg.Server.HTTPCache.ResponseCache)
+	specialsStream := offerings.SpecialsStreamHandler(db, broker, cfg.Server.Events, cfg.Server.WriteTimeout)
+	// Admin endpoints additionally need auth.admin_role.
+	adminMW := slices.Concat([]fiber.Handler{authMW, auth.RequireRole(cfg, cfg.Auth.AdminRole)}, validated,
+		[]fiber.Handler{idempotent})
+	specialsImport := offerings.SpecialsImportHandler(db)
+	specialsExport := offerings.SpecialsExportHandler(db, cfg.Server.WriteTimeout)
+	translations := offerings.TranslationsHandler(db)
+	putTranslation := offerings.PutTranslationHandler(db)
+	deleteTranslation := offerings.DeleteTranslationHandler(db)
+
+	// Every version serves the same handlers until a route changes incompatibly; the new
+	// handler is then chosen here by version (Fiber uses the first route registered for a
+	// path, so it cannot be added after the loop).
+	for _, version := range APIVersions {
+		api := app.Group("/api/" + version)
+		api.Get("/ping", public(PingHandler)...)
+		api.Get("/ping/simple", public(PingHandlerSimple)...)
+		apiOfferings := api.Group("/offerings", offeringsMW...)
+		apiOfferings.Get("/specials", specials)
+		apiOfferings.Get("/specials/stream", specialsStream)
+		apiAdmin := api.Group("/admin", adminMW...)
+		apiAdmin.Post("/offerings/specials\\:import", specialsImport)
+		apiAdmin.Get("/offerings/specials\\:export", specialsExport)
+		apiAdmin.Get("/offerings/specials/:id/translations", translations)
+		apiAdmin.Put("/offerings/specials/:id/translations/:locale", putTranslation)
+		apiAdmin.Delete("/offerings/specials/:id/translations/:locale", deleteTranslation)
+	}
+
+	if apiDocs != nil {
+		apiDocs.Register(app)
+	}
+}
+
+// RegisterAdminRoutes registers the operational endpoints of the admin listener. Health
+// and readiness are open for probes; everything else follows admin.auth.
+func RegisterAdminRoutes(app *fiber.App, watcher *config.Watcher, reg *metrics.Registry, ready func(ctx context.Context) error) {
+	cfg := watcher.Current()
+
+	app.Get("/healthz", admin.HealthzHandler)
+	app.Get("/readyz", admin.ReadyzHandler(ready))
+
+	var protected []fiber.Handler
+	switch cfg.Admin.Auth {
+	case "jwt":
+		protected = append(protected, auth.JWTMiddleware(cfg), auth.RequireRole(cfg, cfg.Auth.AdminRole))
+	case "token":
+		protected = append(protected, auth.StaticTokenMiddleware(cfg.Admin.Token))
+	}
+	ops := app.Group("/", protected...)
+	ops.Get("/metrics", reg.Handler())
+	ops.Get("/admin/log-level", admin.GetLogLevelHandler)
+	ops.Put("/admin/log-level", admin.PutLogLevelHandler)
+	ops.Get("/admin/config", admin.ConfigHandler(watcher.Current))
+	if cfg.Admin.Pprof {
+		ops.Use(pprof.New())
+	}
 }
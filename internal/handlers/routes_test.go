@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"traveler/internal/openapi"
+	"traveler/pkg/config"
+)
+
+const specFile = "../../api/openapi.yaml"
+
+func newTestWatcher(t *testing.T) *config.Watcher {
+	t.Helper()
+	cfg := &config.Config{Auth: config.AuthConfig{
+		Issuer:   "http://localhost:8081/realms/traveler-dev",
+		Audience: "traveler-app",
+	}}
+	return config.NewWatcher(filepath.Join(t.TempDir(), "config.yaml"), nil, cfg)
+}
+
+// fiberParam matches ":name" path parameters; an escaped `\:` is a literal colon.
+var fiberParam = regexp.MustCompile(`(^|[^\\]):([A-Za-z0-9_]+)`)
+
+// registeredOperations returns the routes of app as "METHOD /path" in OpenAPI path form.
+func registeredOperations(app *fiber.App) []string {
+	seen := map[string]bool{}
+	for _, r := range app.GetRoutes(true) {
+		// Fiber adds HEAD for every GET.
+		if r.Method == fiber.MethodHead {
+			continue
+		}
+		path := fiberParam.ReplaceAllString(r.Path, "$1{$2}")
+		seen[r.Method+" "+strings.ReplaceAll(path, `\:`, ":")] = true
+	}
+	ops := make([]string, 0, len(seen))
+	for op := range seen {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+func TestRegisterRoutes_MatchOpenAPIDocument(t *testing.T) {
+	spec, err := openapi.Load(specFile)
+	require.NoError(t, err)
+
+	app := fiber.New()
+	RegisterRoutes(app, newTestWatcher(t), nil, nil, nil, nil)
+
+	assert.Equal(t, spec.Operations(), registeredOperations(app),
+		"routes in handlers.RegisterRoutes and paths in api/openapi.yaml must match")
+}
+
+func TestRegisterRoutes_ResponsesMatchOpenAPIDocument(t *testing.T) {
+	spec, err := openapi.Load(specFile)
+	require.NoError(t, err)
+
+	app := fiber.New()
+	RegisterRoutes(app, newTestWatcher(t), nil, nil, nil, spec.Middleware(true))
+
+	for _, path := range []string{"/", "/api/v1/ping", "/api/v1/ping/simple", "/api/v2/ping", "/api/v2/ping/simple"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "%s: %s", path, body)
+	}
+}
+
+func TestRegisterRoutes_AuthenticationBeforeValidation(t *testing.T) {
+	spec, err := openapi.Load(specFile)
+	require.NoError(t, err)
+
+	// An unknown mode and an undocumented body type.
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/offerings/specials:import?mode=merge",
+			strings.NewReader("not csv"))
+		req.Header.Set("Content-Type", "text/plain")
+		return req
+	}
+
+	validated := fiber.New()
+	validated.Use(spec.Middleware(false))
+	validated.Post("/api/v1/admin/offerings/specials\\:import", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	resp, err := validated.Test(newRequest())
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	app := fiber.New()
+	RegisterRoutes(app, newTestWatcher(t), nil, nil, nil, spec.Middleware(false))
+	resp, err = app.Test(newRequest())
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.NotContains(t, string(body), "mode")
+}
@@ -0,0 +1,109 @@
+// Package httpcache implements HTTP validators (ETag, Last-Modified), conditional GET
+// handling and Cache-Control headers for read endpoints.
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Entry is a rendered response with its validators.
+type Entry struct {
+	Body         []byte
+	ContentType  string
+	ETag         string
+	LastModified time.Time // zero if unknown
+	// ContentLanguage is sent as Content-Language when set.
+	ContentLanguage string
+}
+
+// NewEntry returns an entry whose strong ETag is derived from body.
+func NewEntry(body []byte, contentType string, lastModified time.Time) Entry {
+	sum := sha256.Sum256(body)
+	return Entry{
+		Body:         body,
+		ContentType:  contentType,
+		ETag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+		LastModified: lastModified.UTC().Truncate(time.Second),
+	}
+}
+
+// NewVersionedEntry returns an entry whose strong ETag combines version, which must change
+// with the data behind the response, and a hash of body, which tells renderings of the same
+// version (e.g. per language) apart.
+func NewVersionedEntry(body []byte, contentType string, version int64, lastModified time.Time) Entry {
+	e := NewEntry(body, contentType, lastModified)
+	e.ETag = `"` + strconv.FormatInt(version, 10) + "-" + strings.Trim(e.ETag, `"`)[:16] + `"`
+	return e
+}
+
+// Send writes the entry, or 304 Not Modified if the request's validators still match.
+func (e Entry) Send(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderETag, e.ETag)
+	if e.ContentLanguage != "" {
+		c.Set(fiber.HeaderContentLanguage, e.ContentLanguage)
+	}
+	// HTTP dates have whole seconds, so another change within the current second would keep
+	// the date (RFC 9110, section 8.8.2.2). Last-Modified is only sent once that second is over.
+	if !e.LastModified.IsZero() && !time.Now().Before(e.LastModified.Add(time.Second)) {
+		c.Set(fiber.HeaderLastModified, e.LastModified.Format(http.TimeFormat))
+	}
+	if NotModified(c, e.ETag, e.LastModified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	c.Set(fiber.HeaderContentType, e.ContentType)
+	return c.Send(e.Body)
+}
+
+// NotModified evaluates If-None-Match and If-Modified-Since as described in RFC 9110
+// section 13.2.2: If-Modified-Since is only considered without If-None-Match.
+func NotModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return false
+	}
+	if inm := c.Get(fiber.HeaderIfNoneMatch); inm != "" {
+		return etagMatches(inm, etag)
+	}
+	ims := c.Get(fiber.HeaderIfModifiedSince)
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagMatches applies the weak comparison of If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheControl sets the Cache-Control header configured for the request path on 200 and 304
+// responses. routes maps paths to header values (server.http_cache.cache_control).
+func CacheControl(routes map[string]string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		value, ok := routes[c.Path()]
+		if !ok {
+			return c.Next()
+		}
+		err := c.Next()
+		if status := c.Response().StatusCode(); err == nil && (status == fiber.StatusOK || status == fiber.StatusNotModified) {
+			c.Set(fiber.HeaderCacheControl, value)
+		}
+		return err
+	}
+}
@@ -0,0 +1,83 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntry_Send(t *testing.T) {
+	modified := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	entry := NewEntry([]byte(`{"items":[]}`), fiber.MIMEApplicationJSON, modified.Add(300*time.Millisecond))
+
+	app := fiber.New()
+	app.Use(CacheControl(map[string]string{"/specials": "private, no-cache"}))
+	app.Get("/specials", entry.Send)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{"unconditional", nil, http.StatusOK},
+		{"matching etag", map[string]string{"If-None-Match": entry.ETag}, http.StatusNotModified},
+		{"weak etag in a list", map[string]string{"If-None-Match": `"other", W/` + entry.ETag}, http.StatusNotModified},
+		{"stale etag", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, http.StatusOK},
+		{"etag wins over date", map[string]string{
+			"If-None-Match":     `"other"`,
+			"If-Modified-Since": modified.Format(http.TimeFormat),
+		}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/specials", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, entry.ETag, resp.Header.Get("ETag"))
+			assert.Equal(t, modified.Format(http.TimeFormat), resp.Header.Get("Last-Modified"))
+			assert.Equal(t, "private, no-cache", resp.Header.Get("Cache-Control"))
+		})
+	}
+}
+
+func TestEntry_SendWithholdsLastModifiedOfTheCurrentSecond(t *testing.T) {
+	entry := NewVersionedEntry([]byte(`{"items":[]}`), fiber.MIMEApplicationJSON, 7, time.Now())
+	assert.Regexp(t, `^"7-[0-9a-f]{16}"$`, entry.ETag)
+
+	app := fiber.New()
+	app.Get("/specials", entry.Send)
+
+	// A client holding a date from before the change still gets the new representation.
+	req := httptest.NewRequest(http.MethodGet, "/specials", nil)
+	req.Header.Set("If-Modified-Since", time.Now().Add(-time.Minute).Format(http.TimeFormat))
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Last-Modified"), "a later change within this second would keep the date")
+	assert.Equal(t, entry.ETag, resp.Header.Get("ETag"))
+}
+
+func TestCacheControl_OnlyConfiguredPathsAndSuccess(t *testing.T) {
+	app := fiber.New()
+	app.Use(CacheControl(map[string]string{"/cached": "max-age=60", "/fails": "max-age=60"}))
+	app.Get("/cached", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	app.Get("/other", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	app.Get("/fails", func(c *fiber.Ctx) error { return fiber.ErrUnauthorized })
+
+	for path, want := range map[string]string{"/cached": "max-age=60", "/other": "", "/fails": ""} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, want, resp.Header.Get("Cache-Control"), path)
+	}
+}
@@ -0,0 +1,203 @@
+// Package idempotency makes retried POST and PATCH requests safe: a request sent with an
+// Idempotency-Key runs once per caller and key, and duplicates get the stored response.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	repo "traveler/internal/db/idempotency"
+	"traveler/internal/problem"
+	"traveler/pkg/auth"
+	"traveler/pkg/config"
+	"traveler/pkg/log"
+)
+
+// Header names.
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderReplayed marks responses replayed from the store.
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+// maxKeyLength bounds Idempotency-Key values; clients usually send UUIDs.
+const maxKeyLength = 255
+
+// pollInterval is how often a duplicate checks whether the first request has finished.
+const pollInterval = 25 * time.Millisecond
+
+// cleanupInterval is how often expired keys are deleted.
+const cleanupInterval = 10 * time.Minute
+
+// skipHeaders are response headers that are not replayed: they describe the connection or
+// the original response rather than the result.
+var skipHeaders = map[string]bool{
+	fiber.HeaderDate:             true,
+	fiber.HeaderContentLength:    true,
+	fiber.HeaderConnection:       true,
+	fiber.HeaderTransferEncoding: true,
+	fiber.HeaderSetCookie:        true,
+	fiber.HeaderServer:           true,
+}
+
+// Middleware handles Idempotency-Key on POST and PATCH requests. It must run after the
+// authentication middleware: keys are scoped to the request principal.
+//
+// The first request with a key reserves it, runs and stores its response for cfg.TTL, unless
+// it failed with a 5xx, which frees the key for a retry. A duplicate with the same method,
+// path and body gets the stored response with Idempotent-Replayed: true; while the first
+// request is still running it waits up to cfg.WaitTimeout and then gets 409. Reusing a key
+// for a different request gives 409 as well. Requests without the header are unaffected.
+func Middleware(db *sql.DB, cfg config.IdempotencyConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodPost && c.Method() != fiber.MethodPatch {
+			return c.Next()
+		}
+		key := c.Get(HeaderIdempotencyKey)
+		if key == "" {
+			return c.Next()
+		}
+		if !validKey(key) {
+			return problem.Send(c, problem.New(fiber.StatusBadRequest,
+				"The Idempotency-Key header must be 1 to 255 printable ASCII characters."))
+		}
+
+		ctx := c.UserContext()
+		principal := principalOf(c)
+		fingerprint := fingerprintOf(c)
+		deadline := time.Now().Add(cfg.WaitTimeout)
+		for {
+			now := time.Now()
+			rec, reserved, err := repo.Reserve(ctx, db, repo.Record{
+				Principal:   principal,
+				Key:         key,
+				Fingerprint: fingerprint,
+				LockedUntil: now.Add(cfg.LockTimeout),
+				ExpiresAt:   now.Add(cfg.TTL),
+			}, now)
+			switch {
+			case err != nil:
+				log.Error("failed to reserve idempotency key", "error", err)
+				return fiber.ErrInternalServerError
+			case reserved:
+				return run(c, db, principal, key)
+			case rec.Fingerprint != fingerprint:
+				return problem.Send(c, problem.New(fiber.StatusConflict,
+					"The Idempotency-Key was already used for a different request."))
+			case !rec.InFlight():
+				return replay(c, rec)
+			case now.After(deadline):
+				c.Set(fiber.HeaderRetryAfter, "1")
+				return problem.Send(c, problem.New(fiber.StatusConflict,
+					"A request with this Idempotency-Key is still being processed."))
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(pollInterval):
+			}
+		}
+	}
+}
+
+// run executes the request that holds the key and stores its response.
+func run(c *fiber.Ctx, db *sql.DB, principal, key string) error {
+	ctx := c.UserContext()
+	if err := c.Next(); err != nil {
+		// Render the error now so that the response can be stored.
+		if err := c.App().ErrorHandler(c, err); err != nil {
+			release(ctx, db, principal, key)
+			return err
+		}
+	}
+
+	resp := c.Response()
+	if resp.StatusCode() >= fiber.StatusInternalServerError || resp.IsBodyStream() {
+		release(ctx, db, principal, key)
+		return nil
+	}
+	headers := map[string][]string{}
+	resp.Header.VisitAll(func(k, v []byte) {
+		if name := string(k); !skipHeaders[name] {
+			headers[name] = append(headers[name], string(v))
+		}
+	})
+	if err := repo.Complete(ctx, db, principal, key, resp.StatusCode(), headers, resp.Body()); err != nil {
+		log.Error("failed to store idempotent response", "error", err)
+		release(ctx, db, principal, key)
+	}
+	return nil
+}
+
+func release(ctx context.Context, db *sql.DB, principal, key string) {
+	if err := repo.Release(ctx, db, principal, key); err != nil {
+		log.Error("failed to release idempotency key", "error", err)
+	}
+}
+
+func replay(c *fiber.Ctx, rec repo.Record) error {
+	for name, values := range rec.Headers {
+		c.Response().Header.Del(name)
+		for _, v := range values {
+			c.Response().Header.Add(name, v)
+		}
+	}
+	c.Set(HeaderReplayed, "true")
+	return c.Status(rec.Status).Send(rec.Body)
+}
+
+// principalOf scopes keys to the authenticated caller.
+func principalOf(c *fiber.Ctx) string {
+	if p, ok := auth.PrincipalFrom(c); ok {
+		return p.Source + ":" + p.Subject
+	}
+	return ""
+}
+
+// fingerprintOf identifies the request a key was first used for.
+func fingerprintOf(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method() + " " + c.Path() + "\n"))
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func validKey(key string) bool {
+	if len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Cleanup deletes expired keys periodically until ctx is cancelled. It is meant to run as a
+// background worker.
+func Cleanup(ctx context.Context, db *sql.DB) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := repo.DeleteExpired(ctx, db, now)
+			if err != nil {
+				log.Warn("failed to delete expired idempotency keys", "error", err)
+				continue
+			}
+			if n > 0 {
+				log.Debug("deleted expired idempotency keys", "count", n)
+			}
+		}
+	}
+}
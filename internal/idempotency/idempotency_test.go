@@ -0,0 +1,255 @@
+package idempotency
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appdb "traveler/internal/db"
+	repo "traveler/internal/db/idempotency"
+	"traveler/pkg/auth"
+	"traveler/pkg/config"
+)
+
+var testConfig = config.IdempotencyConfig{
+	TTL:         time.Hour,
+	WaitTimeout: 2 * time.Second,
+	LockTimeout: time.Minute,
+}
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := appdb.Init(t.Context(), filepath.Join(t.TempDir(), "traveler.db"), "../../db/schema.sql")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// newTestApp serves POST /bookings through the middleware. The X-Subject header stands in for
+// authentication.
+func newTestApp(t *testing.T, db *sql.DB, cfg config.IdempotencyConfig, handler fiber.Handler) *fiber.App {
+	t.Helper()
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		auth.SetPrincipal(c, auth.Principal{Subject: c.Get("X-Subject", "alice"), Source: auth.SourceJWT})
+		return c.Next()
+	})
+	app.Use(Middleware(db, cfg))
+	app.Post("/bookings", handler)
+	app.Get("/bookings", handler)
+	return app
+}
+
+// counting returns a handler that creates a booking and counts its calls.
+func counting(calls *atomic.Int32) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		n := calls.Add(1)
+		c.Set(fiber.HeaderLocation, "/bookings/"+strconv.Itoa(int(n)))
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"booking": n})
+	}
+}
+
+func post(t *testing.T, app *fiber.App, key, subject, body string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	if subject != "" {
+		req.Header.Set("X-Subject", subject)
+	}
+	resp, err := app.Test(req, 5000)
+	require.NoError(t, err)
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(b)
+}
+
+func TestMiddleware_ReplaysDuplicates(t *testing.T) {
+	var calls atomic.Int32
+	app := newTestApp(t, newTestDB(t), testConfig, counting(&calls))
+
+	first, firstBody := post(t, app, "k-1", "", `{"special":"sp-1001"}`)
+	require.Equal(t, http.StatusCreated, first.StatusCode)
+	assert.Empty(t, first.Header.Get(HeaderReplayed))
+
+	again, againBody := post(t, app, "k-1", "", `{"special":"sp-1001"}`)
+	assert.Equal(t, http.StatusCreated, again.StatusCode)
+	assert.Equal(t, firstBody, againBody)
+	assert.Equal(t, "/bookings/1", again.Header.Get(fiber.HeaderLocation))
+	assert.Equal(t, "true", again.Header.Get(HeaderReplayed))
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Keys are scoped to the caller, and requests without a key are not deduplicated.
+	other, _ := post(t, app, "k-1", "bob", `{"special":"sp-1001"}`)
+	assert.Empty(t, other.Header.Get(HeaderReplayed))
+	post(t, app, "", "", `{"special":"sp-1001"}`)
+	post(t, app, "", "", `{"special":"sp-1001"}`)
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestMiddleware_RejectsKeyReuseForDifferentRequest(t *testing.T) {
+	var calls atomic.Int32
+	app := newTestApp(t, newTestDB(t), testConfig, counting(&calls))
+
+	post(t, app, "k-1", "", `{"special":"sp-1001"}`)
+	resp, body := post(t, app, "k-1", "", `{"special":"sp-1002"}`)
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Contains(t, body, "different request")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestMiddleware_ConcurrentDuplicatesWaitForTheFirst(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	app := newTestApp(t, newTestDB(t), testConfig, func(c *fiber.Ctx) error {
+		calls.Add(1)
+		close(started)
+		<-finish
+		return c.Status(fiber.StatusCreated).SendString("created")
+	})
+
+	var wg sync.WaitGroup
+	results := make([]string, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp, body := post(t, app, "k-1", "", `{}`)
+		results[0] = resp.Status + " " + body
+	}()
+	<-started
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp, body := post(t, app, "k-1", "", `{}`)
+		results[1] = resp.Status + " " + body + " " + resp.Header.Get(HeaderReplayed)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(finish)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, "201 Created created", results[0])
+	assert.Equal(t, "201 Created created true", results[1])
+}
+
+func TestMiddleware_InFlightDuplicateTimesOut(t *testing.T) {
+	finish := make(chan struct{})
+	defer close(finish)
+	cfg := testConfig
+	cfg.WaitTimeout = 50 * time.Millisecond
+	db := newTestDB(t)
+	app := newTestApp(t, db, cfg, func(c *fiber.Ctx) error {
+		<-finish
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	// A reservation held by another request.
+	now := time.Now()
+	_, reserved, err := repo.Reserve(t.Context(), db, repo.Record{
+		Principal:   "jwt:alice",
+		Key:         "k-1",
+		Fingerprint: fingerprintFor(t, `{}`),
+		LockedUntil: now.Add(time.Minute),
+		ExpiresAt:   now.Add(time.Hour),
+	}, now)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	resp, body := post(t, app, "k-1", "", `{}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get(fiber.HeaderRetryAfter))
+	assert.Contains(t, body, "still being processed")
+}
+
+func TestMiddleware_ServerErrorsReleaseTheKey(t *testing.T) {
+	var calls atomic.Int32
+	app := newTestApp(t, newTestDB(t), testConfig, func(c *fiber.Ctx) error {
+		if calls.Add(1) == 1 {
+			return fiber.ErrServiceUnavailable
+		}
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	resp, _ := post(t, app, "k-1", "", `{}`)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	resp, _ = post(t, app, "k-1", "", `{}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(HeaderReplayed))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestMiddleware_StoresClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	app := newTestApp(t, newTestDB(t), testConfig, func(c *fiber.Ctx) error {
+		calls.Add(1)
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "special sold out")
+	})
+
+	for range 2 {
+		resp, body := post(t, app, "k-1", "", `{}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		assert.Equal(t, "special sold out", body)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestMiddleware_IgnoresSafeMethodsAndRejectsInvalidKeys(t *testing.T) {
+	var calls atomic.Int32
+	app := newTestApp(t, newTestDB(t), testConfig, counting(&calls))
+
+	for range 2 {
+		req := httptest.NewRequest(http.MethodGet, "/bookings", nil)
+		req.Header.Set(HeaderIdempotencyKey, "k-1")
+		_, err := app.Test(req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+
+	resp, _ := post(t, app, strings.Repeat("k", 256), "", `{}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestDeleteExpired(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	for key, expiry := range map[string]time.Time{"old": now.Add(-time.Minute), "new": now.Add(time.Hour)} {
+		_, _, err := repo.Reserve(t.Context(), db, repo.Record{
+			Principal: "jwt:alice", Key: key, Fingerprint: "f", LockedUntil: now, ExpiresAt: expiry,
+		}, now.Add(-time.Hour))
+		require.NoError(t, err)
+	}
+
+	n, err := repo.DeleteExpired(t.Context(), db, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+// fingerprintFor returns the fingerprint of POST /bookings with body.
+func fingerprintFor(t *testing.T, body string) string {
+	t.Helper()
+	var fp string
+	app := fiber.New()
+	app.Post("/bookings", func(c *fiber.Ctx) error {
+		fp = fingerprintOf(c)
+		return nil
+	})
+	_, err := app.Test(httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(body)))
+	require.NoError(t, err)
+	return fp
+}
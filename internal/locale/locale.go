@@ -0,0 +1,103 @@
+// Package locale negotiates the language of localised content: Accept-Language
+// (RFC 9110 section 12.5.4) with the lookup fallback of RFC 4647, where "de-CH" falls back
+// to "de".
+package locale
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// maxRanges bounds the language ranges read from one Accept-Language header.
+const maxRanges = 16
+
+// Canonical returns the canonical form of a BCP 47 language tag reduced to language,
+// script and region, e.g. "de-ch" gives "de-CH" and "iw" gives "he". ok is false for tags
+// that are not well-formed or name no language.
+func Canonical(tag string) (string, bool) {
+	tag = strings.TrimSpace(tag)
+	// language.Parse also takes "_" as a separator; BCP 47 does not.
+	if strings.ContainsFunc(tag, func(r rune) bool { return !isAlnum(r) && r != '-' }) {
+		return "", false
+	}
+	t, err := language.Parse(tag)
+	if err != nil {
+		return "", false
+	}
+	base, script, region := t.Raw()
+	if base.String() == "und" || base.String() == "mul" {
+		return "", false
+	}
+	t, err = language.Compose(base, script, region)
+	if err != nil {
+		return "", false
+	}
+	return t.String(), true
+}
+
+func isAlnum(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9'
+}
+
+// Fallbacks returns a canonical tag followed by its truncations, most specific first:
+// "zh-Hant-TW" gives zh-Hant-TW, zh-Hant, zh.
+func Fallbacks(tag string) []string {
+	chain := []string{tag}
+	for i := strings.LastIndexByte(tag, '-'); i > 0; i = strings.LastIndexByte(tag, '-') {
+		tag = tag[:i]
+		chain = append(chain, tag)
+	}
+	return chain
+}
+
+// Preferences returns the locales an Accept-Language header asks for, most preferred first
+// and each followed by its fallbacks: "de-CH, fr;q=0.8" gives de-CH, de, fr. Invalid
+// ranges, "*" and ranges with q=0 are skipped. The list ends before base, the language of
+// the untranslated content, since everything is available in it.
+func Preferences(acceptLanguage, base string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	parts := strings.Split(acceptLanguage, ",")
+	if len(parts) > maxRanges {
+		parts = parts[:maxRanges]
+	}
+	var ranges []weighted
+	for _, part := range parts {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < 0 || f > 1 {
+				continue
+			}
+			q = f
+		}
+		if q == 0 {
+			continue
+		}
+		if canonical, ok := Canonical(tag); ok {
+			ranges = append(ranges, weighted{canonical, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	var out []string
+	seen := map[string]bool{}
+	for _, r := range ranges {
+		for _, tag := range Fallbacks(r.tag) {
+			if tag == base {
+				return out
+			}
+			if !seen[tag] {
+				seen[tag] = true
+				out = append(out, tag)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package locale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonical(t *testing.T) {
+	for in, want := range map[string]string{
+		"de-ch":                   "de-CH",
+		"EN":                      "en",
+		"zh-hant-tw":              "zh-Hant-TW",
+		"iw":                      "he",
+		"de-CH-1996":              "de-CH",
+		"sr-Latn-RS-u-co-phonebk": "sr-Latn-RS",
+	} {
+		got, ok := Canonical(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"", "*", "und", "x", "en_US", "not a tag"} {
+		_, ok := Canonical(in)
+		assert.False(t, ok, in)
+	}
+}
+
+func TestPreferences(t *testing.T) {
+	for header, want := range map[string][]string{
+		"":                                 nil,
+		"fr-CA, fr;q=0.9, de;q=0.5":        {"fr-CA", "fr", "de"},
+		"de;q=0.5, zh-Hant-TW":             {"zh-Hant-TW", "zh-Hant", "zh", "de"},
+		"it;q=0.8, es-MX;q=0.8, es;q=0.1":  {"it", "es-MX", "es"},
+		"en-GB, fr":                        {"en-GB"}, // en-GB falls back to the untranslated English
+		"fr, en, de":                       {"fr"},
+		"*, nl;q=0, bad_tag, pt;q=abc, pl": {"pl"},
+	} {
+		assert.Equal(t, want, Preferences(header, "en"), header)
+	}
+}
@@ -0,0 +1,151 @@
+// Package metrics collects process and HTTP metrics and renders them in the Prometheus
+// text exposition format for the admin listener.
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"traveler/pkg/log"
+)
+
+// httpKey identifies one request counter series.
+type httpKey struct {
+	method string
+	code   string // status class such as "2xx"
+}
+
+type httpSeries struct {
+	count       atomic.Int64
+	durationSum atomic.Int64 // nanoseconds
+}
+
+// Registry holds the metrics of one process.
+type Registry struct {
+	started time.Time
+	mu      sync.RWMutex
+	http    map[httpKey]*httpSeries
+}
+
+// New returns an empty registry.
+func New() *Registry {
+	return &Registry{started: time.Now(), http: map[httpKey]*httpSeries{}}
+}
+
+// Middleware counts requests and their duration by method and status class.
+func (r *Registry) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if fe, ok := err.(*fiber.Error); ok {
+			status = fe.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+		r.observe(c.Method(), status, time.Since(start))
+		return err
+	}
+}
+
+func (r *Registry) observe(method string, status int, d time.Duration) {
+	key := httpKey{method: method, code: strconv.Itoa(status/100) + "xx"}
+	r.mu.RLock()
+	s, ok := r.http[key]
+	r.mu.RUnlock()
+	if !ok {
+		r.mu.Lock()
+		if s, ok = r.http[key]; !ok {
+			s = &httpSeries{}
+			r.http[key] = s
+		}
+		r.mu.Unlock()
+	}
+	s.count.Add(1)
+	s.durationSum.Add(int64(d))
+}
+
+// Handler serves the metrics in the Prometheus text format.
+// Route: GET /metrics (admin listener)
+func (r *Registry) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var buf bytes.Buffer
+		r.write(bufio.NewWriter(&buf))
+		c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+		return c.Send(buf.Bytes())
+	}
+}
+
+func (r *Registry) write(w *bufio.Writer) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	gauge(w, "process_uptime_seconds", "Seconds since the process started.", time.Since(r.started).Seconds())
+	gauge(w, "go_goroutines", "Number of goroutines.", float64(runtime.NumGoroutine()))
+	gauge(w, "go_memstats_heap_alloc_bytes", "Bytes of allocated heap objects.", float64(ms.HeapAlloc))
+	gauge(w, "go_memstats_sys_bytes", "Bytes obtained from the OS.", float64(ms.Sys))
+	counter(w, "go_gc_cycles_total", "Completed GC cycles.", float64(ms.NumGC))
+
+	type row struct {
+		key    httpKey
+		series *httpSeries
+	}
+	r.mu.RLock()
+	rows := make([]row, 0, len(r.http))
+	for k, s := range r.http {
+		rows = append(rows, row{k, s})
+	}
+	r.mu.RUnlock()
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].key.method != rows[j].key.method {
+			return rows[i].key.method < rows[j].key.method
+		}
+		return rows[i].key.code < rows[j].key.code
+	})
+
+	header(w, "traveler_http_requests_total", "HTTP requests served on the public listener.", "counter")
+	for _, row := range rows {
+		fmt.Fprintf(w, "traveler_http_requests_total{method=%q,code=%q} %d\n", row.key.method, row.key.code, row.series.count.Load())
+	}
+	header(w, "traveler_http_request_duration_seconds_sum", "Total time spent serving HTTP requests.", "counter")
+	for _, row := range rows {
+		fmt.Fprintf(w, "traveler_http_request_duration_seconds_sum{method=%q,code=%q} %g\n",
+			row.key.method, row.key.code, time.Duration(row.series.durationSum.Load()).Seconds())
+	}
+
+	sinks := log.SinkStatistics()
+	if len(sinks) > 0 {
+		header(w, "traveler_log_sink_entries_total", "Log entries handled by remote log sinks.", "counter")
+		for i, s := range sinks {
+			sink := s.Type + "-" + strconv.Itoa(i)
+			fmt.Fprintf(w, "traveler_log_sink_entries_total{sink=%q,result=\"sent\"} %d\n", sink, s.Sent)
+			fmt.Fprintf(w, "traveler_log_sink_entries_total{sink=%q,result=\"dropped\"} %d\n", sink, s.Dropped)
+			fmt.Fprintf(w, "traveler_log_sink_entries_total{sink=%q,result=\"failed\"} %d\n", sink, s.Failed)
+		}
+	}
+	_ = w.Flush()
+}
+
+func header(w *bufio.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func gauge(w *bufio.Writer, name, help string, v float64) {
+	header(w, name, help, "gauge")
+	fmt.Fprintf(w, "%s %g\n", name, v)
+}
+
+func counter(w *bufio.Writer, name, help string, v float64) {
+	header(w, name, help, "counter")
+	fmt.Fprintf(w, "%s %g\n", name, v)
+}
@@ -0,0 +1,90 @@
+// Package middleware holds the HTTP layer of the public listener: CORS, security headers,
+// panic recovery, rate limiting, API version routing and deprecation headers.
+package middleware
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+
+	"traveler/pkg/config"
+	"traveler/pkg/log"
+)
+
+// corsRoute is the CORS handler of one route group.
+type corsRoute struct {
+	prefix  string
+	handler fiber.Handler
+}
+
+// CORS applies the server.cors policy of the longest matching path prefix. Requests outside
+// every prefix get no CORS headers. The policies can be replaced at runtime.
+type CORS struct {
+	routes atomic.Pointer[[]corsRoute]
+}
+
+// NewCORS builds the policies from server.cors.
+func NewCORS(cfgs []config.CORSConfig) (*CORS, error) {
+	m := &CORS{}
+	if err := m.set(cfgs); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reconfigure is a config.Watcher subscriber for "server.cors".
+func (m *CORS) Reconfigure(_, next *config.Config) error {
+	if err := m.set(next.Server.CORS); err != nil {
+		return err
+	}
+	log.Info("CORS policies reloaded", "groups", len(next.Server.CORS))
+	return nil
+}
+
+func (m *CORS) set(cfgs []config.CORSConfig) error {
+	routes := make([]corsRoute, 0, len(cfgs))
+	for _, c := range cfgs {
+		h, err := newCORSHandler(c)
+		if err != nil {
+			return fmt.Errorf("cors policy for %s: %w", c.PathPrefix, err)
+		}
+		routes = append(routes, corsRoute{prefix: strings.TrimSuffix(c.PathPrefix, "/"), handler: h})
+	}
+	sort.SliceStable(routes, func(i, j int) bool { return len(routes[i].prefix) > len(routes[j].prefix) })
+	m.routes.Store(&routes)
+	return nil
+}
+
+// newCORSHandler wraps cors.New, which panics on settings it considers insecure.
+func newCORSHandler(c config.CORSConfig) (h fiber.Handler, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return cors.New(cors.Config{
+		AllowOrigins:     strings.Join(c.AllowOrigins, ","),
+		AllowMethods:     strings.Join(c.AllowMethods, ","),
+		AllowHeaders:     strings.Join(c.AllowHeaders, ","),
+		ExposeHeaders:    strings.Join(c.ExposeHeaders, ","),
+		AllowCredentials: c.AllowCredentials,
+		MaxAge:           int(c.MaxAge.Seconds()),
+	}), nil
+}
+
+// Handler returns the middleware.
+func (m *CORS) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		for _, r := range *m.routes.Load() {
+			if r.prefix == "" || path == r.prefix || strings.HasPrefix(path, r.prefix+"/") {
+				return r.handler(c)
+			}
+		}
+		return c.Next()
+	}
+}
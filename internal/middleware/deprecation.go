@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+
+	"traveler/pkg/config"
+	"traveler/pkg/log"
+)
+
+// deprecation holds the rendered headers of one server.api.deprecations entry.
+type deprecation struct {
+	prefix string
+	method string // empty matches all methods
+	// deprecation (RFC 9745), sunset (RFC 8594) and link are header values; sunset and
+	// link may be empty.
+	deprecation, sunset, link string
+}
+
+// Deprecations is the registry of deprecated routes. Responses of matching routes carry
+// Deprecation, Sunset and Link headers. The registry can be replaced at runtime.
+type Deprecations struct {
+	entries atomic.Pointer[[]deprecation]
+}
+
+// NewDeprecations builds the registry from server.api.deprecations.
+func NewDeprecations(cfgs []config.DeprecationConfig) *Deprecations {
+	d := &Deprecations{}
+	d.set(cfgs)
+	return d
+}
+
+// Reconfigure is a config.Watcher subscriber for "server.api.deprecations".
+func (d *Deprecations) Reconfigure(_, next *config.Config) error {
+	d.set(next.Server.API.Deprecations)
+	log.Info("API deprecations reloaded", "entries", len(next.Server.API.Deprecations))
+	return nil
+}
+
+func (d *Deprecations) set(cfgs []config.DeprecationConfig) {
+	entries := make([]deprecation, 0, len(cfgs))
+	for _, c := range cfgs {
+		e := deprecation{
+			prefix:      strings.TrimSuffix(c.Path, "/"),
+			method:      strings.ToUpper(c.Method),
+			deprecation: "@" + strconv.FormatInt(c.Since.Unix(), 10),
+		}
+		if !c.Sunset.IsZero() {
+			e.sunset = c.Sunset.UTC().Format(http.TimeFormat)
+		}
+		if c.Link != "" {
+			e.link = "<" + c.Link + `>; rel="deprecation"; type="text/html"`
+		}
+		entries = append(entries, e)
+	}
+	// Longest prefix first; at equal length method-specific entries win.
+	sort.SliceStable(entries, func(i, j int) bool {
+		if len(entries[i].prefix) != len(entries[j].prefix) {
+			return len(entries[i].prefix) > len(entries[j].prefix)
+		}
+		return entries[i].method != "" && entries[j].method == ""
+	})
+	d.entries.Store(&entries)
+}
+
+// Handler returns the middleware. Entries are matched against the route that served the
+// request, e.g. /api/v1/offerings/specials, after the API version was resolved.
+func (d *Deprecations) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		path, method := c.Route().Path, c.Method()
+		for _, e := range *d.entries.Load() {
+			if e.method != "" && e.method != method {
+				continue
+			}
+			if e.prefix == "" || path == e.prefix || strings.HasPrefix(path, e.prefix+"/") {
+				c.Set("Deprecation", e.deprecation)
+				if e.sunset != "" {
+					c.Set("Sunset", e.sunset)
+				}
+				if e.link != "" {
+					c.Append(fiber.HeaderLink, e.link)
+				}
+				break
+			}
+		}
+		return err
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/helmet"
+
+	"traveler/pkg/config"
+)
+
+// SecurityHeaders adds the server.headers response headers, X-Content-Type-Options: nosniff
+// and the other helmet defaults suitable for a JSON API.
+func SecurityHeaders(cfg config.SecurityHeadersConfig) fiber.Handler {
+	return helmet.New(helmet.Config{
+		// HSTS is only sent when c.Protocol() is https.
+		HSTSMaxAge:            int(cfg.HSTSMaxAge.Seconds()),
+		HSTSExcludeSubdomains: !cfg.HSTSIncludeSubdomains,
+		ContentSecurityPolicy: cfg.ContentSecurityPolicy,
+		XFrameOptions:         cfg.FrameOptions,
+		ReferrerPolicy:        cfg.ReferrerPolicy,
+	})
+}
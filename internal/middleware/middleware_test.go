@@ -0,0 +1,277 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"traveler/pkg/config"
+)
+
+func ok(c *fiber.Ctx) error { return c.SendString("ok") }
+
+func newCORSApp(t *testing.T, cfgs []config.CORSConfig) (*fiber.App, *CORS) {
+	t.Helper()
+	m, err := NewCORS(cfgs)
+	require.NoError(t, err)
+	app := fiber.New()
+	app.Use(m.Handler())
+	app.Get("/api/v1/offerings/specials", ok)
+	app.Get("/api/ping", ok)
+	return app, m
+}
+
+func preflight(t *testing.T, app *fiber.App, path, origin string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestCORS_PolicyPerRouteGroup(t *testing.T) {
+	app, _ := newCORSApp(t, []config.CORSConfig{
+		{PathPrefix: "/api", AllowOrigins: []string{"*"}, AllowMethods: []string{"GET"}},
+		{
+			PathPrefix:       "/api/v1/offerings",
+			AllowOrigins:     []string{"https://app.traveler.example.com"},
+			AllowMethods:     []string{"GET"},
+			AllowHeaders:     []string{"Authorization"},
+			AllowCredentials: true,
+			MaxAge:           10 * time.Minute,
+		},
+	})
+
+	t.Run("the most specific group applies", func(t *testing.T) {
+		resp := preflight(t, app, "/api/v1/offerings/specials", "https://app.traveler.example.com")
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "https://app.traveler.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "600", resp.Header.Get("Access-Control-Max-Age"))
+
+		resp = preflight(t, app, "/api/v1/offerings/specials", "https://evil.example.com")
+		assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("other routes use the broader group", func(t *testing.T) {
+		resp := preflight(t, app, "/api/ping", "https://evil.example.com")
+		assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("routes outside every group get no CORS headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/apiary", nil)
+		req.Header.Set("Origin", "https://app.traveler.example.com")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestCORS_Reconfigure(t *testing.T) {
+	app, m := newCORSApp(t, nil)
+	resp := preflight(t, app, "/api/ping", "https://app.traveler.example.com")
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+
+	next := &config.Config{Server: config.ServerConfig{CORS: []config.CORSConfig{
+		{PathPrefix: "/api", AllowOrigins: []string{"https://app.traveler.example.com"}},
+	}}}
+	require.NoError(t, m.Reconfigure(nil, next))
+
+	resp = preflight(t, app, "/api/ping", "https://app.traveler.example.com")
+	assert.Equal(t, "https://app.traveler.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+
+	t.Run("an insecure policy is rejected and the previous one kept", func(t *testing.T) {
+		bad := &config.Config{Server: config.ServerConfig{CORS: []config.CORSConfig{
+			{PathPrefix: "/api", AllowOrigins: []string{"*"}, AllowCredentials: true},
+		}}}
+		assert.Error(t, m.Reconfigure(next, bad))
+
+		resp := preflight(t, app, "/api/ping", "https://app.traveler.example.com")
+		assert.Equal(t, "https://app.traveler.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func newRateLimitApp(cfg config.RateLimitConfig) (*fiber.App, *RateLimiter, *time.Time) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRateLimiter(cfg)
+	r.now = func() time.Time { return now }
+	app := fiber.New(fiber.Config{ProxyHeader: "X-Real-IP"})
+	app.Use(r.Handler())
+	app.Get("/api/ping", ok)
+	return app, r, &now
+}
+
+func getFrom(t *testing.T, app *fiber.App, ip string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	req.Header.Set("X-Real-IP", ip)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestRateLimiter_PerClient(t *testing.T) {
+	app, _, now := newRateLimitApp(config.RateLimitConfig{Enabled: true, RequestsPerSecond: 0.5, Burst: 2})
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusOK, getFrom(t, app, "10.0.0.1").StatusCode)
+	}
+	resp := getFrom(t, app, "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, getFrom(t, app, "10.0.0.2").StatusCode, "other clients have their own bucket")
+
+	*now = now.Add(2 * time.Second)
+	assert.Equal(t, http.StatusOK, getFrom(t, app, "10.0.0.1").StatusCode, "a token is refilled after 1/rate")
+	assert.Equal(t, http.StatusTooManyRequests, getFrom(t, app, "10.0.0.1").StatusCode)
+}
+
+func TestRateLimiter_Reconfigure(t *testing.T) {
+	app, r, _ := newRateLimitApp(config.RateLimitConfig{})
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, getFrom(t, app, "10.0.0.1").StatusCode, "disabled by default")
+	}
+
+	next := &config.Config{Server: config.ServerConfig{
+		RateLimit: config.RateLimitConfig{Enabled: true, RequestsPerSecond: 1, Burst: 1},
+	}}
+	require.NoError(t, r.Reconfigure(nil, next))
+	assert.Equal(t, http.StatusOK, getFrom(t, app, "10.0.0.1").StatusCode)
+	assert.Equal(t, http.StatusTooManyRequests, getFrom(t, app, "10.0.0.1").StatusCode)
+
+	require.NoError(t, r.Reconfigure(next, &config.Config{}))
+	assert.Equal(t, http.StatusOK, getFrom(t, app, "10.0.0.1").StatusCode)
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	app := fiber.New()
+	app.Use(SecurityHeaders(config.SecurityHeadersConfig{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		ContentSecurityPolicy: "default-src 'none'",
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "no-referrer",
+	}))
+	app.Get("/", ok)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+	assert.Equal(t, "default-src 'none'", resp.Header.Get("Content-Security-Policy"))
+	assert.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+	assert.Equal(t, "no-referrer", resp.Header.Get("Referrer-Policy"))
+	assert.Empty(t, resp.Header.Get("Strict-Transport-Security"), "HSTS is only sent over HTTPS")
+}
+
+func TestRecover_ReturnsInternalServerError(t *testing.T) {
+	app := fiber.New()
+	app.Use(Recover())
+	app.Get("/boom", func(c *fiber.Ctx) error { panic("secret internal state") })
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/boom", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body := make([]byte, 256)
+	n, _ := resp.Body.Read(body)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.NotContains(t, string(body[:n]), "secret internal state")
+}
+
+func newVersionedApp(t *testing.T, deprecations []config.DeprecationConfig) *fiber.App {
+	t.Helper()
+	d := NewDeprecations(deprecations)
+	app := fiber.New()
+	app.Use(APIVersions([]string{"v1", "v2"}, "v1", "/api/openapi.json"))
+	app.Use(d.Handler())
+	for _, version := range []string{"v1", "v2"} {
+		app.Get("/api/"+version+"/ping", func(c *fiber.Ctx) error { return c.SendString(version) })
+		app.Get("/api/"+version+"/offerings/specials", func(c *fiber.Ctx) error { return c.SendString(version) })
+	}
+	app.Get("/api/openapi.json", ok)
+	return app
+}
+
+func getWithAccept(t *testing.T, app *fiber.App, path, accept string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestAPIVersions_Negotiation(t *testing.T) {
+	app := newVersionedApp(t, nil)
+
+	tests := []struct {
+		name, path, accept string
+		status             int
+		version            string
+	}{
+		{"alias to current", "/api/ping", "", http.StatusOK, "v1"},
+		{"alias with vendor type", "/api/ping", "application/vnd.traveler.v2+json", http.StatusOK, "v2"},
+		{"first known vendor type", "/api/ping", "application/json, application/vnd.traveler.v9+json, application/vnd.traveler.v2+json;q=0.5", http.StatusOK, "v2"},
+		{"unknown vendor type", "/api/ping", "application/vnd.traveler.v9+json", http.StatusNotAcceptable, ""},
+		{"path wins over Accept", "/api/v1/ping", "application/vnd.traveler.v2+json", http.StatusOK, "v1"},
+		{"versioned path", "/api/v2/offerings/specials", "", http.StatusOK, "v2"},
+		{"unknown path version", "/api/v9/ping", "", http.StatusNotFound, ""},
+		{"unversioned route", "/api/openapi.json", "", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := getWithAccept(t, app, tt.path, tt.accept)
+			assert.Equal(t, tt.status, resp.StatusCode, body)
+			if tt.version != "" {
+				assert.Equal(t, tt.version, body)
+				assert.Equal(t, tt.version, resp.Header.Get(HeaderAPIVersion))
+			}
+		})
+	}
+
+	resp, _ := getWithAccept(t, app, "/api/ping", "")
+	assert.Equal(t, "Accept", resp.Header.Get("Vary"), "aliased responses depend on Accept")
+}
+
+func TestDeprecations_Headers(t *testing.T) {
+	date := func(s string) time.Time {
+		t.Helper()
+		d, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return d
+	}
+	app := newVersionedApp(t, []config.DeprecationConfig{
+		{Path: "/api/v1", Since: date("2026-01-01T00:00:00Z")},
+		{
+			Path:   "/api/v1/offerings/specials",
+			Since:  date("2026-03-01T00:00:00Z"),
+			Sunset: date("2026-12-31T12:00:00Z"),
+			Link:   "https://docs.example.com/v2",
+		},
+	})
+
+	resp, _ := getWithAccept(t, app, "/api/offerings/specials", "")
+	assert.Equal(t, "@1772323200", resp.Header.Get("Deprecation"))
+	assert.Equal(t, "Thu, 31 Dec 2026 12:00:00 GMT", resp.Header.Get("Sunset"))
+	assert.Equal(t, `<https://docs.example.com/v2>; rel="deprecation"; type="text/html"`, resp.Header.Get("Link"))
+
+	resp, _ = getWithAccept(t, app, "/api/v1/ping", "")
+	assert.Equal(t, "@1767225600", resp.Header.Get("Deprecation"), "the version-wide entry applies")
+	assert.Empty(t, resp.Header.Get("Sunset"))
+
+	resp, _ = getWithAccept(t, app, "/api/v2/offerings/specials", "")
+	assert.Empty(t, resp.Header.Get("Deprecation"))
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"traveler/internal/problem"
+	"traveler/pkg/config"
+	"traveler/pkg/log"
+)
+
+// sweepEvery is how often buckets of clients that have been idle long enough to refill
+// completely are forgotten.
+const sweepEvery = time.Minute
+
+// bucket is the token bucket of one client.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter applies server.rate_limit: a token bucket per client IP. The limits can be
+// replaced at runtime.
+type RateLimiter struct {
+	mu      sync.Mutex
+	cfg     config.RateLimitConfig
+	clients map[string]*bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+// NewRateLimiter builds the limiter from server.rate_limit.
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	r := &RateLimiter{now: time.Now}
+	r.set(cfg)
+	return r
+}
+
+// Reconfigure is a config.Watcher subscriber for "server.rate_limit".
+func (r *RateLimiter) Reconfigure(_, next *config.Config) error {
+	r.set(next.Server.RateLimit)
+	rl := next.Server.RateLimit
+	log.Info("rate limit reloaded", "enabled", rl.Enabled, "requests_per_second", rl.RequestsPerSecond, "burst", rl.Burst)
+	return nil
+}
+
+// set replaces the limits; clients start over with a full bucket.
+func (r *RateLimiter) set(cfg config.RateLimitConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cfg = cfg
+	r.clients = map[string]*bucket{}
+	r.swept = r.now()
+}
+
+// allow takes a token from the bucket of key. If none is left it reports how long the
+// client has to wait for the next one.
+func (r *RateLimiter) allow(key string) (bool, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.cfg.Enabled {
+		return true, 0
+	}
+
+	now := r.now()
+	rate, burst := r.cfg.RequestsPerSecond, float64(r.cfg.Burst)
+	if now.Sub(r.swept) >= sweepEvery {
+		for k, b := range r.clients {
+			if b.tokens+now.Sub(b.last).Seconds()*rate >= burst {
+				delete(r.clients, k)
+			}
+		}
+		r.swept = now
+	}
+
+	b, ok := r.clients[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		r.clients[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// Handler returns the middleware. Limited requests get 429 with Retry-After in whole seconds.
+func (r *RateLimiter) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ok, wait := r.allow(c.IP())
+		if ok {
+			return c.Next()
+		}
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		return problem.Send(c, problem.New(fiber.StatusTooManyRequests, "Too many requests, retry later."))
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"runtime/debug"
+
+	"github.com/gofiber/fiber/v2"
+
+	"traveler/pkg/log"
+)
+
+// Recover turns a panic in a handler into a logged 500 response. The panic value and stack
+// are logged but never sent to the client.
+func Recover() fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("panic while handling request",
+					"panic", r,
+					"method", c.Method(),
+					"path", c.Path(),
+					"ip", c.IP(),
+					"stack", string(debug.Stack()),
+				)
+				err = fiber.ErrInternalServerError
+			}
+		}()
+		return c.Next()
+	}
+}
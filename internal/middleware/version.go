@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"traveler/internal/problem"
+)
+
+// HeaderAPIVersion names the API version that served a request.
+const HeaderAPIVersion = "API-Version"
+
+// vendorMediaType matches version-specific media types such as application/vnd.traveler.v2+json.
+var vendorMediaType = regexp.MustCompile(`^application/vnd\.traveler\.(v[0-9]+)\+json$`)
+
+// APIVersions routes requests for unversioned /api paths to an API version: the one named by
+// a vendor media type in Accept (application/vnd.traveler.v2+json), else current. The path is
+// rewritten to /api/<version>/..., so later middleware, the OpenAPI validator and the router
+// only see versioned paths. Paths in unversioned, such as the API document, are left alone.
+// Asking for a version that does not exist gives 406 Not Acceptable.
+func APIVersions(versions []string, current string, unversioned ...string) fiber.Handler {
+	known := make(map[string]bool, len(versions))
+	for _, v := range versions {
+		known[v] = true
+	}
+	skip := make(map[string]bool, len(unversioned))
+	for _, p := range unversioned {
+		skip[p] = true
+	}
+
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		rest, ok := strings.CutPrefix(path, "/api/")
+		if !ok || skip[path] {
+			return c.Next()
+		}
+		if v, _, _ := strings.Cut(rest, "/"); known[v] {
+			// The path decides; Accept is not consulted.
+			c.Set(HeaderAPIVersion, v)
+			return c.Next()
+		}
+
+		version, ok := acceptedVersion(c.Get(fiber.HeaderAccept), known, current)
+		c.Vary(fiber.HeaderAccept)
+		if !ok {
+			return problem.Send(c, problem.New(fiber.StatusNotAcceptable,
+				fmt.Sprintf("API version %s is not available.", version)))
+		}
+		c.Set(HeaderAPIVersion, version)
+		c.Path("/api/" + version + "/" + rest)
+		return c.Next()
+	}
+}
+
+// acceptedVersion returns the first known version named in the Accept header, or current if
+// none is named. If only unknown versions are named, it returns the first of them and false.
+func acceptedVersion(accept string, known map[string]bool, current string) (string, bool) {
+	var unknown string
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		m := vendorMediaType.FindStringSubmatch(strings.ToLower(strings.TrimSpace(mediaType)))
+		if m == nil {
+			continue
+		}
+		if known[m[1]] {
+			return m[1], true
+		}
+		if unknown == "" {
+			unknown = m[1]
+		}
+	}
+	if unknown != "" {
+		return unknown, false
+	}
+	return current, true
+}
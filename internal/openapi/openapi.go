@@ -0,0 +1,199 @@
+// Package openapi loads the OpenAPI document of the public API and validates requests and
+// responses against it.
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+
+	"traveler/api"
+	"traveler/internal/problem"
+	"traveler/pkg/log"
+)
+
+func init() {
+	// CSV and JSON Lines bodies (the specials import) are checked as a string only. The
+	// handler parses them and reports bad rows; kin-openapi's CSV decoder would reject a
+	// file with a ragged row as a whole.
+	openapi3filter.RegisterBodyDecoder("text/csv", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", openapi3filter.FileBodyDecoder)
+}
+
+// Spec is a loaded and validated OpenAPI document.
+type Spec struct {
+	doc    *openapi3.T
+	router routers.Router
+}
+
+// Load reads the OpenAPI document at path and checks that it is valid. An empty path loads
+// the document embedded in the binary (api/openapi.yaml at build time).
+func Load(path string) (*Spec, error) {
+	loader := openapi3.NewLoader()
+	var doc *openapi3.T
+	var err error
+	if path == "" {
+		path = "api/openapi.yaml (embedded)"
+		doc, err = loader.LoadFromData(api.OpenAPI)
+	} else {
+		doc, err = loader.LoadFromFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI document %s: %w", path, err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document %s: %w", path, err)
+	}
+
+	// Match routes on the path only; the servers list describes deployments, not this host.
+	routing := *doc
+	routing.Servers = nil
+	router, err := legacy.NewRouter(&routing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build OpenAPI router: %w", err)
+	}
+	return &Spec{doc: doc, router: router}, nil
+}
+
+// Document returns the loaded document. Callers must not modify it.
+func (s *Spec) Document() *openapi3.T {
+	return s.doc
+}
+
+// Operations returns the documented operations as "METHOD /path", sorted. Path parameters
+// use the OpenAPI "{name}" form.
+func (s *Spec) Operations() []string {
+	var ops []string
+	for path, item := range s.doc.Paths.Map() {
+		for method := range item.Operations() {
+			ops = append(ops, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+// Middleware rejects requests that do not match the document with a 400 problem+json
+// response. Requests to undocumented routes pass through unchanged. With validateResponses,
+// responses are checked too and mismatches become 500s; this buffers every response and is
+// meant for tests and development.
+func (s *Spec) Middleware(validateResponses bool) fiber.Handler {
+	opts := &openapi3filter.Options{
+		MultiError: true,
+		// JWTMiddleware authenticates; the document only describes the scheme.
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		IncludeResponseStatus: true,
+	}
+
+	return func(c *fiber.Ctx) error {
+		var req http.Request
+		if err := fasthttpadaptor.ConvertRequest(c.Context(), &req, true); err != nil {
+			return err
+		}
+		route, pathParams, err := s.router.FindRoute(&req)
+		if err != nil {
+			// Unknown paths and methods are left to the router (404/405).
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		input := &openapi3filter.RequestValidationInput{
+			Request:    &req,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    opts,
+		}
+		if err := openapi3filter.ValidateRequest(ctx, input); err != nil {
+			log.Debug("request does not match the OpenAPI document", "path", c.Path(), "error", err)
+			return problem.Send(c, problem.New(fiber.StatusBadRequest,
+				"The request does not match the API specification.", messages(err)...))
+		}
+
+		err = c.Next()
+		if !validateResponses || err != nil || c.Response().IsBodyStream() {
+			// Errors are rendered by the error handler after this middleware returns; streamed
+			// responses such as event streams never end, so they cannot be buffered.
+			return err
+		}
+		return s.validateResponse(ctx, c, input)
+	}
+}
+
+func (s *Spec) validateResponse(ctx context.Context, c *fiber.Ctx, input *openapi3filter.RequestValidationInput) error {
+	resp := c.Response()
+	header := http.Header{}
+	resp.Header.VisitAll(func(k, v []byte) {
+		header.Add(string(k), string(v))
+	})
+	err := openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 resp.StatusCode(),
+		Header:                 header,
+		Body:                   io.NopCloser(bytes.NewReader(resp.Body())),
+		Options:                input.Options,
+	})
+	if err == nil {
+		return nil
+	}
+
+	log.Error("response does not match the OpenAPI document",
+		"method", c.Method(), "path", c.Path(), "status", resp.StatusCode(), "error", err)
+	resp.ResetBody()
+	resp.Header.Del(fiber.HeaderETag)
+	resp.Header.Del(fiber.HeaderLastModified)
+	return problem.Send(c, problem.New(fiber.StatusInternalServerError,
+		"The response does not match the API specification.", messages(err)...))
+}
+
+// messages flattens the validation errors into one short message per violation, without
+// the schema dumps kin-openapi includes in Error().
+func messages(err error) []string {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var out []string
+		for _, inner := range e {
+			out = append(out, messages(inner)...)
+		}
+		return out
+	case *openapi3filter.RequestError:
+		where := "request"
+		switch {
+		case e.Parameter != nil:
+			where = fmt.Sprintf("parameter %q in %s", e.Parameter.Name, e.Parameter.In)
+		case e.RequestBody != nil:
+			where = "request body"
+		}
+		return prefixed(where, e.Reason, e.Err)
+	case *openapi3filter.ResponseError:
+		return prefixed("response", e.Reason, e.Err)
+	case *openapi3.SchemaError:
+		if ptr := e.JSONPointer(); len(ptr) > 0 {
+			return []string{"/" + strings.Join(ptr, "/") + ": " + e.Reason}
+		}
+		return []string{e.Reason}
+	default:
+		return []string{err.Error()}
+	}
+}
+
+func prefixed(where, reason string, err error) []string {
+	if err == nil {
+		return []string{where + ": " + reason}
+	}
+	var out []string
+	for _, m := range messages(err) {
+		out = append(out, where+": "+m)
+	}
+	return out
+}
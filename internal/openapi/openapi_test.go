@@ -0,0 +1,155 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"traveler/internal/problem"
+)
+
+const testSpec = `
+openapi: "3.0.0"
+info:
+  title: test
+  version: 1.0.0
+servers:
+  - url: http://example.com
+paths:
+  /items/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            maximum: 100
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id]
+                properties:
+                  id:
+                    type: integer
+  /items:
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string
+      responses:
+        '201':
+          description: Created
+`
+
+func loadTestSpec(t *testing.T) *Spec {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testSpec), 0o644))
+	spec, err := Load(path)
+	require.NoError(t, err)
+	return spec
+}
+
+func newTestApp(t *testing.T, validateResponses bool, item fiber.Handler) *fiber.App {
+	t.Helper()
+	app := fiber.New()
+	app.Use(loadTestSpec(t).Middleware(validateResponses))
+	app.Get("/items/:id", item)
+	app.Post("/items", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) })
+	app.Get("/undocumented", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	return app
+}
+
+func validItem(c *fiber.Ctx) error { return c.JSON(fiber.Map{"id": 7}) }
+
+func TestSpec_Operations(t *testing.T) {
+	assert.Equal(t, []string{"GET /items/{id}", "POST /items"}, loadTestSpec(t).Operations())
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	spec, err := Load("")
+	require.NoError(t, err)
+	assert.Contains(t, spec.Operations(), "GET /api/v1/offerings/specials")
+}
+
+func TestMiddleware_RejectsInvalidRequests(t *testing.T) {
+	app := newTestApp(t, false, validItem)
+
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+		errors string
+	}{
+		{"valid", httptest.NewRequest(http.MethodGet, "/items/7?limit=10", nil), http.StatusOK, ""},
+		{"path parameter", httptest.NewRequest(http.MethodGet, "/items/abc", nil), http.StatusBadRequest, `parameter "id" in path`},
+		{"query parameter", httptest.NewRequest(http.MethodGet, "/items/7?limit=500", nil), http.StatusBadRequest, `parameter "limit" in query`},
+		{"body", jsonRequest(`{"title":"x"}`), http.StatusBadRequest, `property "name" is missing`},
+		{"undocumented route", httptest.NewRequest(http.MethodGet, "/undocumented", nil), http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.errors == "" {
+				return
+			}
+			assert.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"))
+			var p problem.Details
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+			assert.Equal(t, http.StatusBadRequest, p.Status)
+			assert.Equal(t, "Bad Request", p.Title)
+			assert.Contains(t, strings.Join(p.Errors, "\n"), tt.errors)
+		})
+	}
+}
+
+func TestMiddleware_ValidatesResponsesWhenEnabled(t *testing.T) {
+	wrongShape := func(c *fiber.Ctx) error { return c.JSON(fiber.Map{"id": "seven"}) }
+
+	resp, err := newTestApp(t, false, wrongShape).Test(httptest.NewRequest(http.MethodGet, "/items/7", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "responses are not checked by default")
+
+	resp, err = newTestApp(t, true, wrongShape).Test(httptest.NewRequest(http.MethodGet, "/items/7", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Contains(t, string(body), "The response does not match the API specification.")
+
+	resp, err = newTestApp(t, true, validItem).Test(httptest.NewRequest(http.MethodGet, "/items/7", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func jsonRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
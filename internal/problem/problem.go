@@ -0,0 +1,42 @@
+// Package problem writes RFC 9457 "problem details" error responses (application/problem+json).
+package problem
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// Details is an RFC 9457 problem details object.
+type Details struct {
+	// Type is a URI identifying the problem type; "about:blank" means Title is the status text.
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Instance is the request path the problem occurred on.
+	Instance string `json:"instance,omitempty"`
+	// Errors lists individual violations, e.g. one per invalid parameter.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// New returns problem details of type about:blank for status.
+func New(status int, detail string, errs ...string) Details {
+	return Details{
+		Type:   "about:blank",
+		Title:  utils.StatusMessage(status),
+		Status: status,
+		Detail: detail,
+		Errors: errs,
+	}
+}
+
+// Send writes d with its status; Instance defaults to the request path.
+func Send(c *fiber.Ctx, d Details) error {
+	if d.Instance == "" {
+		d.Instance = c.Path()
+	}
+	return c.Status(d.Status).JSON(d, ContentType)
+}
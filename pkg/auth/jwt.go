@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"traveler/pkg/config"
@@ -20,6 +21,10 @@ var (
 	jwksMap  = make(map[string]*keyfunc.JWKS)
 	jwksErr  error
 	mu       sync.RWMutex
+
+	// audiences are the accepted token audiences; the first is the client id used for
+	// client roles. They can change at runtime (see Reconfigure).
+	audiences atomic.Pointer[[]string]
 )
 
 // getJWKS returns a cached JWKS for the given JWKS URL.
@@ -54,106 +59,181 @@ func getJWKS(jwksURL string) (*keyfunc.JWKS, error) {
 	return jwks, nil
 }
 
-// JWTMiddleware validates Bearer tokens issued by Keycloak and enforces issuer/audience.
-func JWTMiddleware(cfg *config.Config) fiber.Handler {
-	issuer := cfg.Auth.Issuer
-	audience := cfg.Auth.Audience
+// setAudiences installs the accepted audiences from the auth configuration.
+func setAudiences(cfg *config.AuthConfig) {
+	list := append([]string{cfg.Audience}, cfg.Audiences...)
+	audiences.Store(&list)
+}
+
+// currentAudiences returns the accepted audiences, primary first.
+func currentAudiences() []string {
+	if list := audiences.Load(); list != nil {
+		return *list
+	}
+	return nil
+}
+
+// Reconfigure applies reloaded audiences (auth.audience, auth.audiences) to the running
+// middleware; see config.Watcher. Issuer and JWKS changes require a restart.
+func Reconfigure(old, new *config.Config) error {
+	setAudiences(&new.Auth)
+	log.Info("accepted token audiences changed", "audiences", currentAudiences())
+	return nil
+}
+
+// ErrInvalidToken is returned by TokenValidator.Validate for tokens that are not accepted.
+var ErrInvalidToken = errors.New("invalid token")
+
+// TokenValidator checks access tokens issued by Keycloak: signature against the realm
+// JWKS, issuer and audience. It is shared by the HTTP middleware and the gRPC interceptors.
+type TokenValidator struct {
+	issuer  string
+	jwksURL string
+}
+
+// NewTokenValidator returns a validator for the auth configuration and installs its
+// accepted audiences (see Reconfigure).
+func NewTokenValidator(cfg *config.Config) *TokenValidator {
+	setAudiences(&cfg.Auth)
 	// Compute JWKS URL — allow override via config to support containerized envs where issuer host differs
-	jwksURL := strings.TrimRight(issuer, "/") + "/protocol/openid-connect/certs"
+	jwksURL := strings.TrimRight(cfg.Auth.Issuer, "/") + "/protocol/openid-connect/certs"
 	if cfg.Auth.JWKSURL != "" {
 		jwksURL = cfg.Auth.JWKSURL
 	}
+	return &TokenValidator{issuer: cfg.Auth.Issuer, jwksURL: jwksURL}
+}
+
+// Validate checks tokenString and returns its claims and the principal it identifies.
+// Rejected tokens are logged and reported as ErrInvalidToken.
+func (v *TokenValidator) Validate(tokenString string) (jwt.MapClaims, Principal, error) {
+	jwks, err := getJWKS(v.jwksURL)
+	if err != nil {
+		log.Error("failed to get JWKS", "error", err)
+		return nil, Principal{}, ErrInvalidToken
+	}
+
+	// First, validate signature, issuer, and algorithm. We'll handle audience
+	// validation manually to be compatible with Keycloak where `aud` may be
+	// "account" and the client id appears in `azp` (authorized party).
+	parsed, err := jwt.Parse(
+		tokenString,
+		jwks.Keyfunc,
+		// Validate signature algorithm; we'll validate issuer manually for better dev flexibility
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		// Allow small clock skew to avoid 401 due to exp/nbf/iat drift in local/dev environments
+		jwt.WithLeeway(60*time.Second),
+	)
+	if err != nil || !parsed.Valid {
+		if err == nil {
+			err = ErrInvalidToken
+		}
+		log.Warn("token validation failed", "error", err)
+		return nil, Principal{}, ErrInvalidToken
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, Principal{}, ErrInvalidToken
+	}
+
+	// Issuer validation (done manually to tolerate http/https & trailing slash differences in dev)
+	issClaim, _ := claims["iss"].(string)
+	if !issuerAllowed(v.issuer, issClaim) {
+		log.Warn("token issuer mismatch", "expected_issuer", v.issuer, "token_iss", issClaim)
+		return nil, Principal{}, ErrInvalidToken
+	}
+
+	// Audience/Client validation compatible with Keycloak; any accepted audience will do
+	audOK := false
+	for _, audience := range currentAudiences() {
+		if audienceAllowed(claims, audience) {
+			audOK = true
+			break
+		}
+	}
+	if !audOK {
+		log.Warn("token audience/azp mismatch", "expected_audience", currentAudiences(), "claims_aud", claims["aud"], "claims_azp", claims["azp"])
+		return nil, Principal{}, ErrInvalidToken
+	}
+
+	sub, _ := claims["sub"].(string)
+	p := Principal{Subject: sub, Source: SourceJWT}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		p.ExpiresAt = exp.Time
+	}
+	return claims, p, nil
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer <token>" value.
+func BearerToken(authorization string) (string, bool) {
+	parts := strings.SplitN(authorization, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// JWTMiddleware validates Bearer tokens issued by Keycloak and enforces issuer/audience.
+func JWTMiddleware(cfg *config.Config) fiber.Handler {
+	validator := NewTokenValidator(cfg)
+
+	// Verified client certificates may stand in for a token (service-to-service calls).
+	certAuth := cfg.Server.TLS.Enabled && cfg.Server.TLS.ClientCertAuth
 
 	return func(c *fiber.Ctx) error {
 		authz := c.Get("Authorization")
 		if authz == "" {
+			if p, ok := PrincipalFrom(c); ok && certAuth && p.Source == SourceClientCert {
+				return c.Next()
+			}
 			return fiber.ErrUnauthorized
 		}
-		parts := strings.SplitN(authz, " ", 2)
-		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		tokenString, ok := BearerToken(authz)
+		if !ok {
 			return fiber.ErrUnauthorized
 		}
-		tokenString := parts[1]
 
-		jwks, err := getJWKS(jwksURL)
+		claims, p, err := validator.Validate(tokenString)
 		if err != nil {
-			log.Error("failed to get JWKS", "error", err)
 			return fiber.ErrUnauthorized
 		}
+		// Store token claims in context for handlers to use
+		c.Locals("claims", claims)
+		SetPrincipal(c, p)
+		return c.Next()
+	}
+}
 
-		// First, validate signature, issuer, and algorithm. We'll handle audience
-		// validation manually to be compatible with Keycloak where `aud` may be
-		// "account" and the client id appears in `azp` (authorized party).
-		parsed, err := jwt.Parse(
-			tokenString,
-			jwks.Keyfunc,
-			// Validate signature algorithm; we'll validate issuer manually for better dev flexibility
-			jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
-			// Allow small clock skew to avoid 401 due to exp/nbf/iat drift in local/dev environments
-			jwt.WithLeeway(60*time.Second),
-		)
-		if err != nil || !parsed.Valid {
-			if err == nil {
-				err = errors.New("invalid token")
-			}
-			log.Warn("token validation failed", "error", err)
-			return fiber.ErrUnauthorized
-		}
-
-		// Issuer validation (done manually to tolerate http/https & trailing slash differences in dev)
-		if claims, ok := parsed.Claims.(jwt.MapClaims); ok {
-			issClaim, _ := claims["iss"].(string)
-			if !issuerAllowed(issuer, issClaim) {
-				log.Warn("token issuer mismatch", "expected_issuer", issuer, "token_iss", issClaim)
-				return fiber.ErrUnauthorized
-			}
-
-			// Audience/Client validation compatible with Keycloak
-			// 1) Try standard aud claim (string or array)
-			audOK := false
-			if rawAud, exists := claims["aud"]; exists {
-				switch v := rawAud.(type) {
-				case string:
-					if strings.EqualFold(v, audience) {
-						audOK = true
-					}
-				case []interface{}:
-					for _, item := range v {
-						if s, ok := item.(string); ok && strings.EqualFold(s, audience) {
-							audOK = true
-							break
-						}
-					}
-				}
-			}
-
-			// 2) Fallback to Keycloak's `azp` (authorized party == client_id)
-			if !audOK {
-				if azp, ok := claims["azp"].(string); ok && strings.EqualFold(azp, audience) {
-					audOK = true
-				}
+// audienceAllowed checks one accepted audience in a way compatible with Keycloak.
+func audienceAllowed(claims jwt.MapClaims, audience string) bool {
+	// 1) Try standard aud claim (string or array)
+	if rawAud, exists := claims["aud"]; exists {
+		switch v := rawAud.(type) {
+		case string:
+			if strings.EqualFold(v, audience) {
+				return true
 			}
-
-			// 3) Fallback to Keycloak's `resource_access` map which lists client roles
-			//    Accept token if it contains an entry for our client id regardless of roles
-			if !audOK {
-				if ra, ok := claims["resource_access"].(map[string]interface{}); ok {
-					if _, exists := ra[audience]; exists {
-						audOK = true
-					}
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok && strings.EqualFold(s, audience) {
+					return true
 				}
 			}
+		}
+	}
 
-			if !audOK {
-				log.Warn("token audience/azp mismatch", "expected_audience", audience, "claims_aud", claims["aud"], "claims_azp", claims["azp"])
-				return fiber.ErrUnauthorized
-			}
+	// 2) Fallback to Keycloak's `azp` (authorized party == client_id)
+	if azp, ok := claims["azp"].(string); ok && strings.EqualFold(azp, audience) {
+		return true
+	}
 
-			// Store token claims in context for handlers to use
-			c.Locals("claims", claims)
+	// 3) Fallback to Keycloak's `resource_access` map which lists client roles
+	//    Accept token if it contains an entry for our client id regardless of roles
+	if ra, ok := claims["resource_access"].(map[string]interface{}); ok {
+		if _, exists := ra[audience]; exists {
+			return true
 		}
-		return c.Next()
 	}
+	return false
 }
 
 // issuerAllowed compares expected issuer with token issuer allowing small
@@ -185,3 +265,55 @@ func issuerAllowed(expected, actual string) bool {
 	}
 	return false
 }
+
+// RequireRole allows the request only if the token claims stored by JWTMiddleware carry the
+// given role, either as a Keycloak realm role (realm_access.roles) or as a client role of the
+// configured audience (resource_access.<audience>.roles). An empty role allows any caller.
+// It must run after JWTMiddleware.
+func RequireRole(cfg *config.Config, role string) fiber.Handler {
+	fallback := cfg.Auth.Audience
+
+	return func(c *fiber.Ctx) error {
+		audience := fallback
+		if list := currentAudiences(); len(list) > 0 {
+			audience = list[0]
+		}
+		if role == "" {
+			return c.Next()
+		}
+		claims, ok := c.Locals("claims").(jwt.MapClaims)
+		if !ok {
+			return fiber.ErrUnauthorized
+		}
+		if hasRole(claims, audience, role) {
+			return c.Next()
+		}
+		log.Warn("missing required role", "role", role, "sub", claims["sub"], "path", c.Path())
+		return fiber.ErrForbidden
+	}
+}
+
+// hasRole looks for role in the realm roles and in the client roles of clientID.
+func hasRole(claims jwt.MapClaims, clientID, role string) bool {
+	contains := func(access interface{}) bool {
+		m, ok := access.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		roles, _ := m["roles"].([]interface{})
+		for _, r := range roles {
+			if s, ok := r.(string); ok && s == role {
+				return true
+			}
+		}
+		return false
+	}
+
+	if contains(claims["realm_access"]) {
+		return true
+	}
+	if ra, ok := claims["resource_access"].(map[string]interface{}); ok {
+		return contains(ra[clientID])
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Principal sources.
+const (
+	SourceJWT        = "jwt"
+	SourceClientCert = "client_cert"
+)
+
+// Principal identifies the caller of a request.
+type Principal struct {
+	// Subject is the token "sub" claim or the client certificate subject DN.
+	Subject string `json:"subject"`
+	// Source tells how the caller was authenticated: "jwt" or "client_cert".
+	Source string `json:"source"`
+	// CommonName and Organization are set for client certificates.
+	CommonName   string   `json:"common_name,omitempty"`
+	Organization []string `json:"organization,omitempty"`
+	// ExpiresAt is the token "exp" claim; zero for client certificates and tokens without one.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// principalKey is the fiber.Ctx locals key holding the Principal.
+const principalKey = "principal"
+
+// PrincipalFrom returns the principal stored by ClientCertMiddleware or JWTMiddleware.
+func PrincipalFrom(c *fiber.Ctx) (Principal, bool) {
+	p, ok := c.Locals(principalKey).(Principal)
+	return p, ok
+}
+
+// SetPrincipal stores p as the principal of the request.
+func SetPrincipal(c *fiber.Ctx, p Principal) {
+	c.Locals(principalKey, p)
+}
+
+// ClientCertMiddleware stores the subject of a verified TLS client certificate as the
+// request principal. Requests without a verified certificate pass through unchanged.
+func ClientCertMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if p, ok := ClientCertPrincipal(c.Context().TLSConnectionState()); ok {
+			SetPrincipal(c, p)
+		}
+		return c.Next()
+	}
+}
+
+// ClientCertPrincipal returns the principal of the client leaf certificate if the handshake
+// verified it against the configured client CAs.
+func ClientCertPrincipal(state *tls.ConnectionState) (Principal, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return Principal{}, false
+	}
+	cert := state.VerifiedChains[0][0]
+	return Principal{
+		Subject:      cert.Subject.String(),
+		Source:       SourceClientCert,
+		CommonName:   cert.Subject.CommonName,
+		Organization: cert.Subject.Organization,
+	}, true
+}
+
+type principalContextKey struct{}
+
+// ContextWithPrincipal returns a copy of ctx carrying p, for callers without a fiber.Ctx
+// such as gRPC handlers.
+func ContextWithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext returns the principal stored by ContextWithPrincipal.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(Principal)
+	return p, ok
+}
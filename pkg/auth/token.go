@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"traveler/pkg/log"
+)
+
+// StaticTokenMiddleware accepts requests carrying "Authorization: Bearer <token>" with the
+// given token. It is meant for machine access to the admin listener (admin.auth: token).
+func StaticTokenMiddleware(token string) fiber.Handler {
+	want := []byte(token)
+	return func(c *fiber.Ctx) error {
+		parts := strings.SplitN(c.Get("Authorization"), " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || len(want) == 0 {
+			return fiber.ErrUnauthorized
+		}
+		if subtle.ConstantTimeCompare([]byte(parts[1]), want) != 1 {
+			log.Warn("invalid admin token", "ip", c.IP(), "path", c.Path())
+			return fiber.ErrUnauthorized
+		}
+		return c.Next()
+	}
+}
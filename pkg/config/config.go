@@ -1,38 +1,407 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"time"
 
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 // Config holds application configuration.
 type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
+	Admin    AdminConfig    `mapstructure:"admin"`
+	GRPC     GRPCConfig     `mapstructure:"grpc"`
 	Log      LogConfig      `mapstructure:"log"`
 	Auth     AuthConfig     `mapstructure:"auth"`
 	Database DatabaseConfig `mapstructure:"database"`
+
+	// secretKeys are the keys whose values were resolved from secret references.
+	secretKeys map[string]bool
 }
 
 // ServerConfig holds server-specific configuration.
 type ServerConfig struct {
 	Port int `mapstructure:"port"`
+	// TLS optionally serves HTTPS, with client certificate verification (mTLS).
+	TLS TLSConfig `mapstructure:"tls"`
+	// Shutdown controls how the server stops on SIGTERM/SIGINT.
+	Shutdown ShutdownConfig `mapstructure:"shutdown"`
+
+	// BodyLimit is the maximum request body size in bytes; larger requests get 413.
+	BodyLimit int `mapstructure:"body_limit"`
+	// ReadTimeout bounds reading a whole request, WriteTimeout writing a response and
+	// IdleTimeout how long a keep-alive connection may wait for the next request.
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
+	// TrustedProxies are IPs or CIDR ranges of reverse proxies. Only for requests from these
+	// addresses is ProxyHeader used as the client IP and X-Forwarded-Proto as the scheme.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// ProxyHeader carries the client IP set by trusted proxies, e.g. X-Forwarded-For.
+	ProxyHeader string `mapstructure:"proxy_header"`
+	// Headers are the security headers added to every response of the public listener.
+	Headers SecurityHeadersConfig `mapstructure:"headers"`
+	// CORS are the cross-origin policies per route group. Without a matching entry no CORS
+	// headers are sent, so browsers only allow same-origin calls. Reloaded at runtime.
+	CORS []CORSConfig `mapstructure:"cors"`
+	// RateLimit limits the request rate per client IP. Reloaded at runtime.
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	// HTTPCache controls Cache-Control headers and the in-process response cache.
+	HTTPCache HTTPCacheConfig `mapstructure:"http_cache"`
+	// OpenAPI controls validation against the API document.
+	OpenAPI OpenAPIConfig `mapstructure:"openapi"`
+	// Docs serves the API document and an interactive documentation page.
+	Docs DocsConfig `mapstructure:"docs"`
+	// API configures the versions of the public API.
+	API APIConfig `mapstructure:"api"`
+	// Idempotency controls replay of POST and PATCH requests sent with an Idempotency-Key.
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	// Events controls the Server-Sent Events stream of specials changes.
+	Events EventsConfig `mapstructure:"events"`
+}
+
+// RateLimitConfig is a token bucket per client IP (as resolved through server.trusted_proxies).
+// Requests over the limit get 429 with a Retry-After header.
+type RateLimitConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// RequestsPerSecond is the sustained rate a client may send.
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+	// Burst is the number of requests a client may send at once after being idle.
+	Burst int `mapstructure:"burst"`
+}
+
+// EventsConfig controls the specials event log and its Server-Sent Events stream.
+type EventsConfig struct {
+	// PollInterval is how often the event log is read for new events and ended specials
+	// are expired.
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// HeartbeatInterval is how often an idle stream gets a comment line, which keeps proxies
+	// from closing it and detects clients that went away.
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
+	// Retry is the reconnection delay suggested to clients.
+	Retry time.Duration `mapstructure:"retry"`
+	// Buffer is how many events may queue up for one connection. A client that falls further
+	// behind is disconnected and resumes from the event log when it reconnects.
+	Buffer int `mapstructure:"buffer"`
+	// MaxConnections limits concurrent streams; further clients get 503. 0 means no limit.
+	MaxConnections int `mapstructure:"max_connections"`
+	// Retention is how long events are kept for Last-Event-ID resumption.
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+// IdempotencyConfig controls the Idempotency-Key handling of mutating routes.
+type IdempotencyConfig struct {
+	// TTL is how long a key and its response are kept for replay.
+	TTL time.Duration `mapstructure:"ttl"`
+	// WaitTimeout bounds how long a duplicate waits for the first request to finish before
+	// it gets 409 Conflict.
+	WaitTimeout time.Duration `mapstructure:"wait_timeout"`
+	// LockTimeout is how long a request may hold its key; after that the key counts as
+	// abandoned (e.g. the process died) and a retry may run it again.
+	LockTimeout time.Duration `mapstructure:"lock_timeout"`
+}
+
+// APIConfig configures the versions of the public API served under /api/v1, /api/v2, ...
+type APIConfig struct {
+	// Deprecations announce the retirement of routes or whole versions with Deprecation,
+	// Sunset and Link response headers. Reloaded at runtime.
+	Deprecations []DeprecationConfig `mapstructure:"deprecations"`
+}
+
+// DeprecationConfig marks routes of the public API as deprecated.
+type DeprecationConfig struct {
+	// Path is a route such as /api/v1/offerings/specials or a prefix such as /api/v1 for a
+	// whole version; the longest match wins.
+	Path string `mapstructure:"path"`
+	// Method restricts the entry to one HTTP method; empty matches all.
+	Method string `mapstructure:"method"`
+	// Since is when the route was deprecated and Sunset (optional) when it will be removed,
+	// as dates (2026-06-30, midnight UTC) or RFC 3339 timestamps.
+	Since  time.Time `mapstructure:"since"`
+	Sunset time.Time `mapstructure:"sunset"`
+	// Link optionally points to migration notes.
+	Link string `mapstructure:"link"`
+}
+
+// stringToDateHook decodes time.Time fields given as strings, e.g. from environment
+// variables or quoted YAML. Unquoted YAML dates arrive as time.Time already.
+func stringToDateHook(from, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(time.Time{}) {
+		return data, nil
+	}
+	return parseDate(data.(string))
+}
+
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, fmt.Errorf("%q is not a date (2006-01-02) or RFC 3339 timestamp", s)
+	}
+	return t, nil
+}
+
+// DocsConfig controls /api/openapi.yaml, /api/openapi.json and the Swagger UI at /docs.
+// Keep it disabled in production unless the API is meant to be public.
+type DocsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// ClientID is the public Keycloak client the Swagger UI logs in with (authorization code
+	// with PKCE). Its redirect URIs must allow <server>/docs/oauth2-redirect.html.
+	ClientID string `mapstructure:"client_id"`
+}
+
+// OpenAPIConfig controls validation of the public API against its OpenAPI document.
+type OpenAPIConfig struct {
+	// SpecFile optionally replaces the OpenAPI document embedded in the binary.
+	SpecFile string `mapstructure:"spec_file"`
+	// ValidateRequests answers requests that do not match the document with 400 problem+json.
+	ValidateRequests bool `mapstructure:"validate_requests"`
+	// ValidateResponses also checks responses and turns mismatches into 500s. It buffers
+	// every response; meant for tests and development.
+	ValidateResponses bool `mapstructure:"validate_responses"`
+}
+
+// HTTPCacheConfig controls HTTP caching of read endpoints. Cacheable endpoints always send
+// ETag and Last-Modified and answer matching conditional GETs with 304 Not Modified.
+type HTTPCacheConfig struct {
+	// CacheControl maps request paths to their Cache-Control header, e.g.
+	// "/api/v1/offerings/specials": "private, max-age=60". Paths are versioned; requests to
+	// unversioned /api aliases match the version that serves them. Other paths send none.
+	CacheControl map[string]string `mapstructure:"cache_control"`
+	// ResponseCache keeps rendered responses in memory until the underlying rows change.
+	ResponseCache bool `mapstructure:"response_cache"`
+}
+
+// SecurityHeadersConfig configures the security response headers.
+// X-Content-Type-Options: nosniff is always sent.
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge is the Strict-Transport-Security max-age, sent on HTTPS requests only
+	// (direct TLS or X-Forwarded-Proto from a trusted proxy). 0 disables HSTS.
+	HSTSMaxAge time.Duration `mapstructure:"hsts_max_age"`
+	// HSTSIncludeSubdomains adds includeSubDomains to the HSTS header.
+	HSTSIncludeSubdomains bool `mapstructure:"hsts_include_subdomains"`
+	// ContentSecurityPolicy is the Content-Security-Policy header; empty omits it.
+	ContentSecurityPolicy string `mapstructure:"content_security_policy"`
+	// FrameOptions is the X-Frame-Options header (DENY or SAMEORIGIN).
+	FrameOptions string `mapstructure:"frame_options"`
+	// ReferrerPolicy is the Referrer-Policy header.
+	ReferrerPolicy string `mapstructure:"referrer_policy"`
+}
+
+// CORSConfig is the CORS policy of one route group.
+type CORSConfig struct {
+	// PathPrefix selects the routes, e.g. "/api/v1/offerings", or "/api" for every version;
+	// the longest matching prefix wins. Unversioned /api paths are rewritten to their
+	// version before CORS applies, so prefixes must name the version.
+	PathPrefix string `mapstructure:"path_prefix"`
+	// AllowOrigins are exact origins such as "https://app.example.com", or "*".
+	AllowOrigins     []string `mapstructure:"allow_origins"`
+	AllowMethods     []string `mapstructure:"allow_methods"`
+	AllowHeaders     []string `mapstructure:"allow_headers"`
+	ExposeHeaders    []string `mapstructure:"expose_headers"`
+	AllowCredentials bool     `mapstructure:"allow_credentials"`
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+// ShutdownConfig controls the graceful shutdown sequence: readiness is reported as failing
+// first, then the server keeps serving for PreStopDelay so load balancers can stop routing
+// to it, then in-flight requests get up to DrainTimeout to complete.
+type ShutdownConfig struct {
+	// PreStopDelay should cover the time load balancers need to notice the failing readiness
+	// probe (e.g. the Kubernetes probe period times its failure threshold).
+	PreStopDelay time.Duration `mapstructure:"pre_stop_delay"`
+	// DrainTimeout bounds the wait for in-flight requests; remaining connections are closed.
+	DrainTimeout time.Duration `mapstructure:"drain_timeout"`
+}
+
+// AdminConfig configures the separate listener for operational endpoints (health, metrics,
+// pprof, log levels, configuration dump), which must not be reachable through the public ingress.
+type AdminConfig struct {
+	// Host is the interface the admin listener binds to; defaults to 127.0.0.1. Use 0.0.0.0
+	// (or "") for all interfaces, e.g. in a container, and keep the port firewalled then.
+	Host string `mapstructure:"host"`
+	// Port is the TCP port of the admin listener. Host and Port are ignored when Socket is set.
+	Port int `mapstructure:"port"`
+	// Socket is an optional Unix socket path to listen on instead of Port.
+	Socket string `mapstructure:"socket"`
+	// Auth protects everything except /healthz and /readyz: "jwt" (bearer token with
+	// auth.admin_role), "token" (static bearer Token) or "none".
+	Auth string `mapstructure:"auth"`
+	// Token is the static bearer token for Auth "token"; use a secret reference.
+	Token string `mapstructure:"token"`
+	// Pprof exposes /debug/pprof; off by default.
+	Pprof bool `mapstructure:"pprof"`
+}
+
+// GRPCConfig configures the gRPC listener for internal services. It authenticates like the
+// REST API and uses server.tls when that is enabled.
+type GRPCConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	Port    int  `mapstructure:"port"`
+	// Reflection lets tools such as grpcurl discover the services without the .proto files;
+	// off by default, and callers need a token like for any other method.
+	Reflection bool `mapstructure:"reflection"`
+}
+
+// TLSConfig controls TLS termination in the HTTP server.
+type TLSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// CertFile and KeyFile are PEM files; they are reloaded when they change on disk.
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// MinVersion is the lowest accepted protocol version: "1.2" (default) or "1.3".
+	MinVersion string `mapstructure:"min_version"`
+	// CipherSuites restricts the TLS 1.2 cipher suites by Go name, e.g.
+	// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Empty uses Go's secure defaults.
+	CipherSuites []string `mapstructure:"cipher_suites"`
+	// ClientAuth is "none" (default), "request" (verify a certificate if one is sent) or
+	// "require" (mutual TLS: every client must present a certificate signed by ClientCAFile).
+	ClientAuth string `mapstructure:"client_auth"`
+	// ClientCAFile is the PEM bundle of CAs trusted for client certificates.
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	// ClientCertAuth lets a verified client certificate authenticate API requests without a
+	// bearer token, for service-to-service calls. The certificate subject becomes the principal.
+	ClientCertAuth bool `mapstructure:"client_cert_auth"`
 }
 
 // LogConfig holds logging configuration.
 type LogConfig struct {
-	Level         string           `mapstructure:"level"`
-	File          string           `mapstructure:"file"` // Optional: if empty, logs only to stdout
+	Level string `mapstructure:"level"`
+	File  string `mapstructure:"file"` // Optional: if empty, logs only to stdout
+	// Rotation controls size/time based rotation and retention of File.
+	Rotation LogRotationConfig `mapstructure:"rotation"`
+	// LevelRevertAfter restores the configured level this long after a runtime change
+	// (admin endpoint or signal) that does not specify its own revert delay. 0 keeps changes.
+	LevelRevertAfter time.Duration    `mapstructure:"level_revert_after"`
+	Elasticsearch    ElasticLogConfig `mapstructure:"elasticsearch"`
+	// Redaction scrubs secrets and personal data from every log output.
+	Redaction RedactionConfig `mapstructure:"redaction"`
+	// Sinks are additional log destinations (syslog, loki, otlp, elasticsearch), each
+	// with its own level threshold and batching.
+	Sinks []SinkConfig `mapstructure:"sinks"`
+}
+
+// SinkConfig configures one entry of log.sinks.
+type SinkConfig struct {
+	// Type selects the sink implementation: syslog, loki, otlp or elasticsearch.
+	Type string `mapstructure:"type"`
+	// Level is the minimum level sent to this sink, applied on top of log.level. Empty
+	// sends everything log.level lets through.
+	Level string `mapstructure:"level"`
+	// Network ("udp" or "tcp") and Address ("host:514") locate a syslog server.
+	Network string `mapstructure:"network"`
+	Address string `mapstructure:"address"`
+	// Facility is the syslog facility code; defaults to 16 (local0).
+	Facility int `mapstructure:"facility"`
+	// URL is the Loki base URL (http://localhost:3100) or OTLP/HTTP endpoint (http://localhost:4318).
+	URL string `mapstructure:"url"`
+	// Headers are added to every HTTP request, e.g. X-Scope-OrgID for multi-tenant Loki.
+	Headers map[string]string `mapstructure:"headers"`
+	// Labels are static Loki stream labels; LabelFields promotes entry fields such as
+	// "level" or "logger" to labels as well.
+	Labels      map[string]string `mapstructure:"labels"`
+	LabelFields []string          `mapstructure:"label_fields"`
+	// ServiceName is the syslog APP-NAME and OTLP service.name; defaults to "traveler".
+	ServiceName string `mapstructure:"service_name"`
+	// Buffer, BatchSize and FlushInterval control queueing and batching of the syslog, Loki
+	// and OTLP sinks (defaults 1024 entries, 100 entries, 1s). These sinks drop the newest
+	// entry when the queue is full and retry a failed batch three times before counting it
+	// as failed; drop policies, configurable retries and the disk spool are only honoured by
+	// the elasticsearch sink, which is configured entirely under Elasticsearch.
+	Buffer        int           `mapstructure:"buffer"`
+	BatchSize     int           `mapstructure:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// Elasticsearch holds the settings of an elasticsearch sink.
 	Elasticsearch ElasticLogConfig `mapstructure:"elasticsearch"`
 }
 
+// RedactionConfig controls scrubbing of sensitive data from log entries.
+type RedactionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Keys are case-insensitive regular expressions matched against field names,
+	// e.g. "authorization", "token", "email", "card.?number". Matching values are replaced.
+	Keys []string `mapstructure:"keys"`
+	// ScrubValues replaces JWT-looking strings and card numbers (PANs) found in any
+	// string field or message, whatever the field name.
+	ScrubValues bool `mapstructure:"scrub_values"`
+	// Mode is "mask" (replace with [REDACTED]) or "hash" (keyed hash for correlation).
+	Mode string `mapstructure:"mode"`
+	// HashKey is the HMAC key for hash mode. If empty, a random per-process key is used,
+	// so hashes only correlate within one run.
+	HashKey string `mapstructure:"hash_key"`
+}
+
+// LogRotationConfig controls rotation of the log file. Zero values disable the respective limit.
+type LogRotationConfig struct {
+	// MaxSizeMB rotates the file once it would grow beyond this many megabytes.
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+	// Interval rotates the file on fixed boundaries, e.g. 24h rotates at midnight UTC.
+	Interval time.Duration `mapstructure:"interval"`
+	// MaxBackups is the number of rotated files to keep.
+	MaxBackups int `mapstructure:"max_backups"`
+	// MaxAgeDays removes rotated files older than this many days.
+	MaxAgeDays int `mapstructure:"max_age_days"`
+	// Compress gzips rotated files.
+	Compress bool `mapstructure:"compress"`
+}
+
 // ElasticLogConfig controls optional shipping of logs to Elasticsearch.
 type ElasticLogConfig struct {
 	Enabled bool   `mapstructure:"enabled"`
-	URL     string `mapstructure:"url"`   // e.g. http://localhost:9200
-	Index   string `mapstructure:"index"` // e.g. traveler-logs
-	Buffer  int    `mapstructure:"buffer"`
-	Workers int    `mapstructure:"workers"`
+	URL     string `mapstructure:"url"` // e.g. http://localhost:9200
+	// Index is the target index, e.g. traveler-logs. It may contain date verbs
+	// (%Y, %y, %m, %d, %H) such as traveler-logs-%Y.%m.%d for daily indices.
+	Index string `mapstructure:"index"`
+	// DataStream ships to Index as a data stream (bulk "create" operations).
+	DataStream bool `mapstructure:"data_stream"`
+	// Template optionally installs component and index templates at startup.
+	Template ElasticTemplateConfig `mapstructure:"template"`
+	// Username/Password enable basic auth; APIKey (base64 "id:key") takes precedence if set.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	APIKey   string `mapstructure:"api_key"`
+	// Buffer is the maximum number of log entries queued for shipping before DropPolicy applies.
+	Buffer int `mapstructure:"buffer"`
+	// Workers is the number of concurrent bulk requests sent to Elasticsearch.
+	Workers int `mapstructure:"workers"`
+	// BatchSize is the maximum number of entries per bulk request.
+	BatchSize int `mapstructure:"batch_size"`
+	// FlushInterval bounds how long a partial batch waits before being sent.
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// MaxRetries is the number of retries for a failed bulk request (0 disables retries).
+	MaxRetries int `mapstructure:"max_retries"`
+	// RetryBackoff is the initial delay between retries; it doubles up to MaxBackoff.
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`
+	// DropPolicy decides what happens when the queue is full: drop_newest, drop_oldest or block.
+	DropPolicy string `mapstructure:"drop_policy"`
+	// SpoolDir optionally enables an on-disk spool for batches that could not be delivered.
+	// Spooled batches are replayed once Elasticsearch is reachable again.
+	SpoolDir string `mapstructure:"spool_dir"`
+	// SpoolMaxBytes caps the spool size; the oldest batches are discarded beyond it.
+	SpoolMaxBytes int64 `mapstructure:"spool_max_bytes"`
+	// DeadLetterFile optionally records documents Elasticsearch rejected permanently
+	// (mapping conflicts, blocked indices) as JSON lines for later inspection.
+	DeadLetterFile string `mapstructure:"dead_letter_file"`
+}
+
+// ElasticTemplateConfig controls the index template installed for shipped logs.
+type ElasticTemplateConfig struct {
+	Install bool `mapstructure:"install"`
+	// Name of the index template; defaults to the index name without its date pattern.
+	Name     string `mapstructure:"name"`
+	Shards   int    `mapstructure:"shards"`
+	Replicas int    `mapstructure:"replicas"`
 }
 
 // AuthConfig holds authentication settings (Keycloak/OpenID Connect).
@@ -41,9 +410,14 @@ type AuthConfig struct {
 	Issuer string `mapstructure:"issuer"`
 	// Audience is the expected audience/client_id in tokens, e.g. traveler-app
 	Audience string `mapstructure:"audience"`
+	// Audiences are further accepted audiences, e.g. during a client migration.
+	Audiences []string `mapstructure:"audiences"`
 	// JWKSURL optionally overrides the JWKS endpoint URL used to validate tokens.
 	// If empty, it will be derived from Issuer as: <issuer>/protocol/openid-connect/certs
 	JWKSURL string `mapstructure:"jwks_url"`
+	// AdminRole is the realm or client role required for /api/admin endpoints.
+	// If empty, any authenticated caller is allowed.
+	AdminRole string `mapstructure:"admin_role"`
 }
 
 // DatabaseConfig holds local SQLite database settings.
@@ -52,50 +426,177 @@ type DatabaseConfig struct {
 	Path string `mapstructure:"path"`
 }
 
-// Load reads configuration from a YAML file.
+// Load reads configuration from a YAML file, with TRAVELER_* environment variables
+// taking precedence over the file. The result is validated; a missing file yields an
+// error wrapping ErrNotFound, an invalid one a parse error or *ValidationError.
 func Load(configPath string) (*Config, error) {
-	v := viper.New()
-	v.SetConfigFile(configPath)
+	return LoadWithFlags(configPath, nil)
+}
+
+// LoadWithFlags reads configuration like Load and applies command-line flags registered
+// with RegisterFlags on top. A --config flag replaces configPath.
+// Precedence: flag > environment > file > defaults.
+func LoadWithFlags(configPath string, flags *pflag.FlagSet) (*Config, error) {
+	v, err := newViper(flags)
+	if err != nil {
+		return nil, err
+	}
+	path := resolvePath(configPath, flags)
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+	v.SetConfigFile(path)
 	v.SetConfigType("yaml")
 
-	// Set defaults
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return unmarshal(v)
+}
+
+// newViper returns a viper instance with defaults, environment and flag overrides set up.
+func newViper(flags *pflag.FlagSet) (*viper.Viper, error) {
+	v := viper.New()
+	setDefaults(v)
+	bindEnv(v)
+	if err := bindFlags(v, flags); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func unmarshal(v *viper.Viper) (*Config, error) {
+	var cfg Config
+	hooks := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		stringToDateHook,
+	))
+	if err := v.Unmarshal(&cfg, hooks); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	// Values such as "env:ES_PASSWORD" or "file:///run/secrets/es_password" are secret references.
+	if err := resolveSecrets(&cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.port", 8080)
+	v.SetDefault("server.shutdown.pre_stop_delay", "0s")
+	v.SetDefault("server.shutdown.drain_timeout", "5s")
+	v.SetDefault("server.body_limit", 4*1024*1024)
+	v.SetDefault("server.read_timeout", "10s")
+	v.SetDefault("server.write_timeout", "30s")
+	v.SetDefault("server.idle_timeout", "120s")
+	v.SetDefault("server.trusted_proxies", []string{})
+	v.SetDefault("server.proxy_header", "X-Forwarded-For")
+	v.SetDefault("server.headers.hsts_max_age", "8760h")
+	v.SetDefault("server.headers.hsts_include_subdomains", false)
+	v.SetDefault("server.headers.content_security_policy", "default-src 'none'; frame-ancestors 'none'")
+	v.SetDefault("server.headers.frame_options", "DENY")
+	v.SetDefault("server.headers.referrer_policy", "no-referrer")
+	v.SetDefault("server.http_cache.cache_control", map[string]string{
+		// Specials need a token, so only the client may store them, and must revalidate.
+		"/api/v1/offerings/specials": "private, no-cache",
+		"/api/v2/offerings/specials": "private, no-cache",
+	})
+	v.SetDefault("server.http_cache.response_cache", true)
+	v.SetDefault("server.openapi.spec_file", "")
+	v.SetDefault("server.openapi.validate_requests", true)
+	v.SetDefault("server.openapi.validate_responses", false)
+	v.SetDefault("server.rate_limit.enabled", false)
+	v.SetDefault("server.rate_limit.requests_per_second", 10)
+	v.SetDefault("server.rate_limit.burst", 20)
+	v.SetDefault("server.idempotency.ttl", "24h")
+	v.SetDefault("server.idempotency.wait_timeout", "10s")
+	v.SetDefault("server.idempotency.lock_timeout", "1m")
+	v.SetDefault("server.events.poll_interval", "1s")
+	v.SetDefault("server.events.heartbeat_interval", "15s")
+	v.SetDefault("server.events.retry", "3s")
+	v.SetDefault("server.events.buffer", 64)
+	v.SetDefault("server.events.max_connections", 1000)
+	v.SetDefault("server.events.retention", "168h")
+	v.SetDefault("server.docs.enabled", false)
+	v.SetDefault("server.docs.client_id", "traveler-app")
+	v.SetDefault("admin.host", "127.0.0.1")
+	v.SetDefault("admin.port", 9090)
+	v.SetDefault("admin.socket", "")
+	v.SetDefault("admin.auth", "jwt")
+	v.SetDefault("admin.token", "")
+	v.SetDefault("admin.pprof", false)
+	v.SetDefault("grpc.enabled", false)
+	v.SetDefault("grpc.port", 50051)
+	v.SetDefault("grpc.reflection", false)
+	v.SetDefault("server.tls.enabled", false)
+	v.SetDefault("server.tls.min_version", "1.2")
+	v.SetDefault("server.tls.client_auth", "none")
+	v.SetDefault("server.tls.client_cert_auth", false)
 	v.SetDefault("log.level", "info")
 	v.SetDefault("log.file", "") // Empty means stdout only
+	v.SetDefault("log.level_revert_after", "0s")
+	v.SetDefault("log.redaction.enabled", true)
+	v.SetDefault("log.redaction.keys", []string{
+		"authorization", "cookie", "token$", "password", "secret", "api.?key",
+		"e.?mail", "card.?number", "^pan$", "cvv", "cvc",
+	})
+	v.SetDefault("log.redaction.scrub_values", true)
+	v.SetDefault("log.redaction.mode", "mask")
+	v.SetDefault("log.redaction.hash_key", "")
+	v.SetDefault("log.rotation.max_size_mb", 100)
+	v.SetDefault("log.rotation.interval", "0s")
+	v.SetDefault("log.rotation.max_backups", 10)
+	v.SetDefault("log.rotation.max_age_days", 30)
+	v.SetDefault("log.rotation.compress", true)
 	// Elastic logging defaults (disabled)
 	v.SetDefault("log.elasticsearch.enabled", false)
 	v.SetDefault("log.elasticsearch.url", "http://localhost:9200")
 	v.SetDefault("log.elasticsearch.index", "traveler-logs")
+	v.SetDefault("log.elasticsearch.data_stream", false)
+	v.SetDefault("log.elasticsearch.template.install", false)
+	v.SetDefault("log.elasticsearch.template.name", "")
+	v.SetDefault("log.elasticsearch.template.shards", 1)
+	v.SetDefault("log.elasticsearch.template.replicas", 1)
+	v.SetDefault("log.elasticsearch.username", "")
+	v.SetDefault("log.elasticsearch.password", "")
+	v.SetDefault("log.elasticsearch.api_key", "")
 	v.SetDefault("log.elasticsearch.buffer", 1024)
 	v.SetDefault("log.elasticsearch.workers", 1)
+	v.SetDefault("log.elasticsearch.batch_size", 200)
+	v.SetDefault("log.elasticsearch.flush_interval", "1s")
+	v.SetDefault("log.elasticsearch.max_retries", 5)
+	v.SetDefault("log.elasticsearch.retry_backoff", "500ms")
+	v.SetDefault("log.elasticsearch.max_backoff", "30s")
+	v.SetDefault("log.elasticsearch.drop_policy", "drop_newest")
+	v.SetDefault("log.elasticsearch.spool_dir", "")
+	v.SetDefault("log.elasticsearch.spool_max_bytes", 64<<20)
+	v.SetDefault("log.elasticsearch.dead_letter_file", "")
 	// Reasonable dev defaults for local Keycloak in docker
 	v.SetDefault("auth.issuer", "http://localhost:8081/realms/traveler-dev")
 	v.SetDefault("auth.audience", "traveler-app")
+	v.SetDefault("auth.admin_role", "traveler-admin")
 	// Database defaults
 	v.SetDefault("database.path", "db/traveler.db")
-
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config: %w", err)
-	}
-
-	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
-	}
-
-	return &cfg, nil
 }
 
-// LoadOrDefault attempts to load configuration from the given path,
-// falling back to defaults if the file doesn't exist.
-func LoadOrDefault(configPath string) *Config {
-	cfg, err := Load(configPath)
-	if err != nil {
-		// Return default config
-		return &Config{
-			Server: ServerConfig{Port: 8080},
-			Log:    LogConfig{Level: "info"},
-		}
+// LoadOrDefault loads configuration from the given path (or --config). If the file does not
+// exist, the defaults are used instead, still with environment variables and flags applied.
+// Any other problem — an unreadable or malformed file, or invalid values — is returned.
+func LoadOrDefault(configPath string, flags *pflag.FlagSet) (*Config, error) {
+	cfg, err := LoadWithFlags(configPath, flags)
+	if !errors.Is(err, ErrNotFound) {
+		return cfg, err
+	}
+	v, verr := newViper(flags)
+	if verr != nil {
+		return nil, verr
 	}
-	return cfg
+	return unmarshal(v)
 }
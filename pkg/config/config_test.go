@@ -0,0 +1,226 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadWithFlags_Precedence(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  port: 9000
+log:
+  level: warn
+auth:
+  issuer: http://file/realms/x
+`)
+	t.Setenv("TRAVELER_SERVER_PORT", "9100")
+	t.Setenv("TRAVELER_AUTH_ISSUER", "http://env/realms/x")
+	t.Setenv("TRAVELER_AUTH_JWKS_URL", "http://env/certs")
+	t.Setenv("TRAVELER_LOG_ELASTICSEARCH_FLUSH_INTERVAL", "3s")
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+	require.NoError(t, flags.Parse([]string{"--config", path, "--port", "9200"}))
+
+	cfg, err := LoadWithFlags("does-not-matter.yaml", flags)
+	require.NoError(t, err)
+
+	assert.Equal(t, 9200, cfg.Server.Port, "flag beats env and file")
+	assert.Equal(t, "http://env/realms/x", cfg.Auth.Issuer, "env beats file")
+	assert.Equal(t, "http://env/certs", cfg.Auth.JWKSURL, "env works for keys without defaults")
+	assert.Equal(t, 3*time.Second, cfg.Log.Elasticsearch.FlushInterval)
+	assert.Equal(t, "warn", cfg.Log.Level, "file beats defaults")
+	assert.Equal(t, "traveler-app", cfg.Auth.Audience, "defaults fill the rest")
+}
+
+func TestLoadWithFlags_UnsetPortFlagKeepsEnv(t *testing.T) {
+	path := writeConfig(t, "server:\n  port: 9000\n")
+	t.Setenv("TRAVELER_SERVER_PORT", "9100")
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+	require.NoError(t, flags.Parse(nil))
+
+	cfg, err := LoadWithFlags(path, flags)
+	require.NoError(t, err)
+	assert.Equal(t, 9100, cfg.Server.Port)
+}
+
+func TestLoad_DistinguishesMissingFromInvalid(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = Load(writeConfig(t, "server: [\n"))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.Contains(t, err.Error(), "failed to parse config")
+}
+
+func TestLoadOrDefault_UsesDefaultsOnlyWhenFileIsMissing(t *testing.T) {
+	t.Setenv("TRAVELER_SERVER_PORT", "9300")
+	cfg, err := LoadOrDefault(filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 9300, cfg.Server.Port)
+	assert.NotEmpty(t, cfg.Auth.Issuer)
+	assert.NotEmpty(t, cfg.Database.Path)
+
+	_, err = LoadOrDefault(writeConfig(t, "log:\n  level: loud\n"), nil)
+	require.Error(t, err)
+}
+
+func TestDefaults_KeepOperationalEndpointsPrivate(t *testing.T) {
+	cfg, err := LoadOrDefault(filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "127.0.0.1", cfg.Admin.Host)
+	assert.False(t, cfg.Admin.Pprof)
+	assert.False(t, cfg.GRPC.Reflection)
+}
+
+func TestValidate_AggregatesProblems(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  port: 70000
+log:
+  level: verbose
+  elasticsearch:
+    enabled: true
+    url: "elk:9200"
+  sinks:
+    - type: loki
+    - type: syslog
+      network: quic
+auth:
+  issuer: ""
+  audience: ""
+database:
+  path: ""
+`)
+	_, err := Load(path)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.ElementsMatch(t, []string{
+		"server.port: 70000 is out of range 1-65535",
+		`log.level: unknown level "verbose" (use debug, info, warn or error)`,
+		`log.elasticsearch.url: "elk:9200" is not an absolute http(s) URL`,
+		"log.sinks[0].url: is required",
+		`log.sinks[1].network: "quic" is not one of udp, tcp`,
+		"log.sinks[1].address: is required",
+		"auth.issuer: is required",
+		"auth.audience: is required",
+		"database.path: is required",
+	}, verr.Problems)
+}
+
+func TestValidate_AdminListener(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  port: 9090
+admin:
+  auth: token
+auth:
+  issuer: http://localhost:8081/realms/traveler-dev
+  audience: traveler-app
+`)
+	_, err := Load(path)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.ElementsMatch(t, []string{
+		"admin.port: must differ from server.port",
+		"admin.token: is required for admin.auth token",
+	}, verr.Problems)
+}
+
+func TestValidate_HardeningSettings(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  body_limit: 0
+  trusted_proxies: ["10.0.0.0/8", "proxy.local"]
+  headers:
+    frame_options: ALLOW
+  cors:
+    - path_prefix: api
+      allow_origins: ["*", "https://app.example.com/path"]
+      allow_credentials: true
+    - path_prefix: /api/offerings
+      allow_origins: ["https://app.example.com"]
+    - path_prefix: /api/v2/offerings
+      allow_origins: ["https://app.example.com"]
+  rate_limit:
+    enabled: true
+    burst: 0
+  idempotency:
+    ttl: 0s
+  events:
+    heartbeat_interval: 0s
+    buffer: 0
+auth:
+  issuer: http://localhost:8081/realms/traveler-dev
+  audience: traveler-app
+`)
+	_, err := Load(path)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.ElementsMatch(t, []string{
+		"server.body_limit: must be positive",
+		`server.trusted_proxies: "proxy.local" is not an IP address or CIDR range`,
+		`server.headers.frame_options: "ALLOW" is not one of DENY, SAMEORIGIN`,
+		"server.cors[0].path_prefix: must start with /",
+		`server.cors[0].allow_origins: "*" must be the only entry`,
+		`server.cors[0].allow_origins: "*" cannot be combined with allow_credentials`,
+		`server.cors[0].allow_origins: "https://app.example.com/path" is not an origin such as https://app.example.com`,
+		"server.cors[1].path_prefix: /api/offerings never matches, since unversioned /api paths are rewritten " +
+			"to their version first; use e.g. /api/v1/offerings, or /api for every version",
+		"server.rate_limit: requests_per_second must be positive and burst at least 1",
+		"server.idempotency: ttl, wait_timeout and lock_timeout must be positive",
+		"server.events: poll_interval, heartbeat_interval, retry and retention must be positive",
+		"server.events: buffer must be at least 1 and max_connections must not be negative",
+	}, verr.Problems)
+}
+
+func TestValidate_APIDeprecations(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  api:
+    deprecations:
+      - path: /api/v1
+        since: 2026-01-01
+        sunset: 2027-01-01T00:00:00Z
+        link: https://docs.example.com/migrate
+      - path: api/v1/ping
+      - path: /api/v1/offerings/specials
+        since: "2026-03-01"
+      - path: /api/v1/offerings
+        since: 2026-06-01
+        sunset: 2026-01-01
+auth:
+  issuer: http://localhost:8081/realms/traveler-dev
+  audience: traveler-app
+`)
+	_, err := Load(path)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.ElementsMatch(t, []string{
+		"server.api.deprecations[1].path: must start with /",
+		"server.api.deprecations[1].since: is required",
+		"server.api.deprecations[3].sunset: must not be before since",
+	}, verr.Problems)
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix prefixes environment variables that override configuration keys. Nested keys
+// are joined with underscores: auth.issuer is TRAVELER_AUTH_ISSUER and
+// log.elasticsearch.url is TRAVELER_LOG_ELASTICSEARCH_URL.
+const EnvPrefix = "TRAVELER"
+
+// DefaultPath is the configuration file used when neither --config nor TRAVELER_CONFIG is set.
+const DefaultPath = "configs/config.yaml"
+
+// RegisterFlags adds the configuration flags (--config, --port) to fs.
+func RegisterFlags(fs *pflag.FlagSet) {
+	fs.String("config", DefaultPath, "path to the YAML configuration file (env TRAVELER_CONFIG)")
+	fs.Int("port", 0, "HTTP port, overrides server.port")
+}
+
+// flagKeys maps flag names to the configuration keys they override.
+var flagKeys = map[string]string{
+	"port": "server.port",
+}
+
+func bindFlags(v *viper.Viper, flags *pflag.FlagSet) error {
+	if flags == nil {
+		return nil
+	}
+	for name, key := range flagKeys {
+		f := flags.Lookup(name)
+		if f == nil {
+			continue
+		}
+		// Unset flags must not shadow the environment or the file.
+		if !f.Changed {
+			continue
+		}
+		if err := v.BindPFlag(key, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolvePath picks the configuration file: --config, then TRAVELER_CONFIG, then configPath.
+func resolvePath(configPath string, flags *pflag.FlagSet) string {
+	if flags != nil {
+		if f := flags.Lookup("config"); f != nil && f.Changed {
+			return f.Value.String()
+		}
+	}
+	if p := os.Getenv(EnvPrefix + "_CONFIG"); p != "" {
+		return p
+	}
+	if configPath == "" {
+		return DefaultPath
+	}
+	return configPath
+}
+
+// bindEnv enables TRAVELER_* overrides. Every key of Config is bound explicitly, because
+// AutomaticEnv alone only covers keys viper already knows from defaults or the file.
+func bindEnv(v *viper.Viper) {
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	for _, key := range configKeys(reflect.TypeOf(Config{}), "") {
+		_ = v.BindEnv(key)
+	}
+}
+
+// configKeys lists the dotted keys of the scalar and []string fields of t.
+// Lists of structs and maps (e.g. log.sinks) can only be set in the file.
+func configKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := prefix + tag
+		switch f.Type.Kind() {
+		case reflect.Struct:
+			keys = append(keys, configKeys(f.Type, key+".")...)
+		case reflect.Map:
+			continue
+		case reflect.Slice:
+			if f.Type.Elem().Kind() == reflect.String {
+				keys = append(keys, key)
+			}
+		default:
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SecretProvider resolves secret references of one scheme in configuration values,
+// e.g. "env:KEYCLOAK_SECRET" or "file:///run/secrets/es_password".
+type SecretProvider interface {
+	// Scheme is the reference prefix before the colon, e.g. "env" or "file".
+	Scheme() string
+	// Resolve returns the secret for the full reference.
+	Resolve(ref string) (string, error)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]SecretProvider{}
+
+	// resolvedSecrets holds every secret value resolved so far, for log scrubbing.
+	resolvedSecrets atomic.Pointer[[]string]
+)
+
+func init() {
+	RegisterSecretProvider(envProvider{})
+	RegisterSecretProvider(fileProvider{})
+}
+
+// RegisterSecretProvider makes a provider available for references with its scheme.
+// Registering a scheme again replaces the previous provider.
+func RegisterSecretProvider(p SecretProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[strings.ToLower(p.Scheme())] = p
+}
+
+// ResolvedSecrets returns the secret values resolved from references so far. pkg/log uses
+// them to keep resolved secrets out of every log output.
+func ResolvedSecrets() []string {
+	if s := resolvedSecrets.Load(); s != nil {
+		return *s
+	}
+	return nil
+}
+
+func rememberSecret(v string) {
+	if v == "" {
+		return
+	}
+	for {
+		cur := resolvedSecrets.Load()
+		var list []string
+		if cur != nil {
+			for _, s := range *cur {
+				if s == v {
+					return
+				}
+			}
+			list = append(list, *cur...)
+		}
+		list = append(list, v)
+		if resolvedSecrets.CompareAndSwap(cur, &list) {
+			return
+		}
+	}
+}
+
+// providerFor returns the provider responsible for value, if it is a secret reference.
+func providerFor(value string) (SecretProvider, bool) {
+	scheme, _, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, false
+	}
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[strings.ToLower(scheme)]
+	return p, ok
+}
+
+// envProvider resolves "env:NAME" from the process environment.
+type envProvider struct{}
+
+func (envProvider) Scheme() string { return "env" }
+
+func (envProvider) Resolve(ref string) (string, error) {
+	name := strings.TrimPrefix(ref, "env:")
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return v, nil
+}
+
+// fileProvider resolves "file:///path" (e.g. Docker or Kubernetes secrets) to the file
+// contents without the trailing newline.
+type fileProvider struct{}
+
+func (fileProvider) Scheme() string { return "file" }
+
+func (fileProvider) Resolve(ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	path := u.Path
+	if path == "" {
+		path = u.Opaque // file:relative/path
+	}
+	if path == "" {
+		return "", fmt.Errorf("no file path in %q", ref)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// resolveSecrets replaces secret references in all string values of cfg and records the
+// keys that held one. Failures are collected so that every broken reference is reported.
+func resolveSecrets(cfg *Config) error {
+	var errs []error
+	cfg.secretKeys = map[string]bool{}
+	walkStrings(reflect.ValueOf(cfg).Elem(), "", func(key string, v reflect.Value) {
+		p, ok := providerFor(v.String())
+		if !ok {
+			return
+		}
+		secret, err := p.Resolve(v.String())
+		if err != nil {
+			// The reference itself is not secret, but keep the error short and key-oriented.
+			errs = append(errs, fmt.Errorf("%s: cannot resolve %s secret: %w", key, p.Scheme(), err))
+			return
+		}
+		v.SetString(secret)
+		cfg.secretKeys[key] = true
+		rememberSecret(secret)
+	})
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// walkStrings calls fn for every settable string reachable from v: struct fields (named by
+// their mapstructure keys), slice elements ("[i]") and map values (".key").
+func walkStrings(v reflect.Value, key string, fn func(key string, v reflect.Value)) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			fn(key, v)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			tag := t.Field(i).Tag.Get("mapstructure")
+			if tag == "" || tag == "-" {
+				continue
+			}
+			walkStrings(v.Field(i), joinKey(key, tag), fn)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkStrings(v.Index(i), fmt.Sprintf("%s[%d]", key, i), fn)
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for _, k := range v.MapKeys() {
+			// Map values are not addressable: copy, resolve, store back.
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			walkStrings(elem, joinKey(key, k.String()), fn)
+			v.SetMapIndex(k, elem)
+		}
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+const redacted = "[REDACTED]"
+
+// sensitiveNames mark keys whose values are hidden even when written in plain text.
+var sensitiveNames = []string{"password", "secret", "api_key", "hash_key", "token", "authorization"}
+
+func sensitiveKey(key string) bool {
+	k := strings.ToLower(key)
+	for _, s := range sensitiveNames {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns the effective configuration as a nested map with resolved secrets and
+// sensitive values (passwords, API keys, authorization headers) replaced by "[REDACTED]".
+func (c *Config) Redacted() map[string]interface{} {
+	out, _ := c.redactValue(reflect.ValueOf(c).Elem(), "").(map[string]interface{})
+	return out
+}
+
+func (c *Config) redactValue(v reflect.Value, key string) interface{} {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(v.Int()).String()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		if v.String() != "" && (c.secretKeys[key] || sensitiveKey(key)) {
+			return redacted
+		}
+		return v.String()
+	case reflect.Struct:
+		m := map[string]interface{}{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			tag := t.Field(i).Tag.Get("mapstructure")
+			if tag == "" || tag == "-" {
+				continue
+			}
+			m[tag] = c.redactValue(v.Field(i), joinKey(key, tag))
+		}
+		return m
+	case reflect.Slice:
+		list := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			list = append(list, c.redactValue(v.Index(i), fmt.Sprintf("%s[%d]", key, i)))
+		}
+		return list
+	case reflect.Map:
+		m := map[string]interface{}{}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			m[k.String()] = c.redactValue(v.MapIndex(k), joinKey(key, k.String()))
+		}
+		return m
+	default:
+		return v.Interface()
+	}
+}
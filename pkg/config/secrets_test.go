@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_ResolvesSecretReferences(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "es_password")
+	require.NoError(t, os.WriteFile(secretFile, []byte("s3cr3t-from-file\n"), 0o600))
+	t.Setenv("TEST_ES_API_KEY", "key-from-env")
+	t.Setenv("TEST_LOKI_TOKEN", "Bearer loki-token")
+
+	path := writeConfig(t, `
+log:
+  elasticsearch:
+    username: elastic
+    password: file://`+secretFile+`
+    api_key: env:TEST_ES_API_KEY
+  sinks:
+    - type: loki
+      url: http://localhost:3100
+      headers:
+        authorization: env:TEST_LOKI_TOKEN
+`)
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "s3cr3t-from-file", cfg.Log.Elasticsearch.Password)
+	assert.Equal(t, "key-from-env", cfg.Log.Elasticsearch.APIKey)
+	assert.Equal(t, "Bearer loki-token", cfg.Log.Sinks[0].Headers["authorization"])
+	assert.Contains(t, ResolvedSecrets(), "s3cr3t-from-file")
+
+	dump := cfg.Redacted()
+	es := dump["log"].(map[string]interface{})["elasticsearch"].(map[string]interface{})
+	assert.Equal(t, "[REDACTED]", es["password"])
+	assert.Equal(t, "[REDACTED]", es["api_key"])
+	assert.Equal(t, "elastic", es["username"])
+	assert.Equal(t, "1s", es["flush_interval"])
+	sink := dump["log"].(map[string]interface{})["sinks"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "[REDACTED]", sink["headers"].(map[string]interface{})["authorization"])
+}
+
+func TestLoad_ReportsUnresolvableSecrets(t *testing.T) {
+	path := writeConfig(t, `
+auth:
+  jwks_url: env:TEST_DOES_NOT_EXIST
+log:
+  elasticsearch:
+    password: file:///does/not/exist
+`)
+	_, err := Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "auth.jwks_url: cannot resolve env secret")
+	assert.Contains(t, err.Error(), "log.elasticsearch.password: cannot resolve file secret")
+}
+
+type staticProvider struct{}
+
+func (staticProvider) Scheme() string                     { return "vault" }
+func (staticProvider) Resolve(ref string) (string, error) { return "from-" + ref, nil }
+
+func TestRegisterSecretProvider(t *testing.T) {
+	RegisterSecretProvider(staticProvider{})
+	t.Cleanup(func() {
+		providersMu.Lock()
+		delete(providers, "vault")
+		providersMu.Unlock()
+	})
+
+	cfg, err := Load(writeConfig(t, "log:\n  redaction:\n    hash_key: vault:kv/log\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "from-vault:kv/log", cfg.Log.Redaction.HashKey)
+}
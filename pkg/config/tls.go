@@ -0,0 +1,19 @@
+package config
+
+import "crypto/tls"
+
+// TLSVersions maps server.tls.min_version values to crypto/tls versions.
+var TLSVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// CipherSuite looks up a secure cipher suite by its Go name.
+func CipherSuite(name string) (uint16, bool) {
+	for _, cs := range tls.CipherSuites() {
+		if cs.Name == name {
+			return cs.ID, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,317 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrNotFound is returned (wrapped) by Load when the configuration file does not exist.
+var ErrNotFound = errors.New("config file not found")
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// knownLevels are the log levels understood by pkg/log.
+var knownLevels = map[string]bool{"debug": true, "info": true, "warn": true, "warning": true, "error": true}
+
+// Validate checks the configuration for missing or malformed values and returns a
+// *ValidationError describing all of them, or nil.
+func (c *Config) Validate() error {
+	p := problems{secret: c.secretKeys}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		p.addf("server.port: %d is out of range 1-65535", c.Server.Port)
+	}
+	if c.Server.TLS.Enabled {
+		p.tls("server.tls", c.Server.TLS)
+	}
+	if c.Server.Shutdown.PreStopDelay < 0 {
+		p.addf("server.shutdown.pre_stop_delay: must not be negative")
+	}
+	if c.Server.Shutdown.DrainTimeout <= 0 {
+		p.addf("server.shutdown.drain_timeout: must be positive")
+	}
+	if c.Server.BodyLimit <= 0 {
+		p.addf("server.body_limit: must be positive")
+	}
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 {
+		p.addf("server: read_timeout, write_timeout and idle_timeout must not be negative")
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if !validIPOrCIDR(proxy) {
+			p.addf("server.trusted_proxies: %q is not an IP address or CIDR range", proxy)
+		}
+	}
+	if len(c.Server.TrustedProxies) > 0 && c.Server.ProxyHeader == "" {
+		p.addf("server.proxy_header: is required with server.trusted_proxies")
+	}
+	switch strings.ToUpper(c.Server.Headers.FrameOptions) {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		p.addf("server.headers.frame_options: %q is not one of DENY, SAMEORIGIN", c.Server.Headers.FrameOptions)
+	}
+	if c.Server.Docs.Enabled && c.Server.Docs.ClientID == "" {
+		p.addf("server.docs.client_id: is required when docs are enabled")
+	}
+	for i, cors := range c.Server.CORS {
+		p.cors(fmt.Sprintf("server.cors[%d]", i), cors)
+	}
+	if r := c.Server.RateLimit; r.Enabled && (r.RequestsPerSecond <= 0 || r.Burst < 1) {
+		p.addf("server.rate_limit: requests_per_second must be positive and burst at least 1")
+	}
+	if i := c.Server.Idempotency; i.TTL <= 0 || i.WaitTimeout <= 0 || i.LockTimeout <= 0 {
+		p.addf("server.idempotency: ttl, wait_timeout and lock_timeout must be positive")
+	}
+	if e := c.Server.Events; e.PollInterval <= 0 || e.HeartbeatInterval <= 0 || e.Retry <= 0 || e.Retention <= 0 {
+		p.addf("server.events: poll_interval, heartbeat_interval, retry and retention must be positive")
+	}
+	if e := c.Server.Events; e.Buffer < 1 || e.MaxConnections < 0 {
+		p.addf("server.events: buffer must be at least 1 and max_connections must not be negative")
+	}
+	for i, d := range c.Server.API.Deprecations {
+		p.deprecation(fmt.Sprintf("server.api.deprecations[%d]", i), d)
+	}
+
+	if c.Admin.Socket == "" {
+		if c.Admin.Port < 1 || c.Admin.Port > 65535 {
+			p.addf("admin.port: %d is out of range 1-65535", c.Admin.Port)
+		} else if c.Admin.Port == c.Server.Port {
+			p.addf("admin.port: must differ from server.port")
+		}
+	}
+	if c.GRPC.Enabled {
+		if c.GRPC.Port < 1 || c.GRPC.Port > 65535 {
+			p.addf("grpc.port: %d is out of range 1-65535", c.GRPC.Port)
+		} else if c.GRPC.Port == c.Server.Port || (c.Admin.Socket == "" && c.GRPC.Port == c.Admin.Port) {
+			p.addf("grpc.port: must differ from server.port and admin.port")
+		}
+	}
+	switch c.Admin.Auth {
+	case "jwt", "none":
+	case "token":
+		if c.Admin.Token == "" {
+			p.addf("admin.token: is required for admin.auth token")
+		}
+	default:
+		p.addf("admin.auth: %q is not one of jwt, token, none", c.Admin.Auth)
+	}
+
+	p.level("log.level", c.Log.Level, false)
+	if c.Log.LevelRevertAfter < 0 {
+		p.addf("log.level_revert_after: must not be negative")
+	}
+	r := c.Log.Rotation
+	if r.MaxSizeMB < 0 || r.MaxBackups < 0 || r.MaxAgeDays < 0 || r.Interval < 0 {
+		p.addf("log.rotation: limits must not be negative")
+	}
+	if c.Log.Elasticsearch.Enabled {
+		p.elastic("log.elasticsearch", c.Log.Elasticsearch)
+	}
+	if red := c.Log.Redaction; red.Enabled {
+		if m := strings.ToLower(red.Mode); m != "" && m != "mask" && m != "hash" {
+			p.addf("log.redaction.mode: %q is not one of mask, hash", red.Mode)
+		}
+	}
+	for i, s := range c.Log.Sinks {
+		p.sink(fmt.Sprintf("log.sinks[%d]", i), s)
+	}
+
+	p.url("auth.issuer", c.Auth.Issuer, true)
+	if c.Auth.Audience == "" {
+		p.addf("auth.audience: is required")
+	}
+	p.url("auth.jwks_url", c.Auth.JWKSURL, false)
+
+	if strings.TrimSpace(c.Database.Path) == "" {
+		p.addf("database.path: is required")
+	}
+
+	return p.err()
+}
+
+func validIPOrCIDR(s string) bool {
+	if _, _, err := net.ParseCIDR(s); err == nil {
+		return true
+	}
+	return net.ParseIP(s) != nil
+}
+
+// problems collects validation messages.
+type problems struct {
+	list   []string
+	secret map[string]bool // keys resolved from secret references; their values are never shown
+}
+
+func (p *problems) addf(format string, args ...interface{}) {
+	p.list = append(p.list, fmt.Sprintf(format, args...))
+}
+
+func (p *problems) err() error {
+	if len(p.list) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: p.list}
+}
+
+func (p *problems) level(key, level string, allowEmpty bool) {
+	if level == "" {
+		if !allowEmpty {
+			p.addf("%s: is required", key)
+		}
+		return
+	}
+	if !knownLevels[strings.ToLower(level)] {
+		p.addf("%s: unknown level %q (use debug, info, warn or error)", key, level)
+	}
+}
+
+// url checks that raw is an absolute http(s) URL.
+func (p *problems) url(key, raw string, required bool) {
+	if raw == "" {
+		if required {
+			p.addf("%s: is required", key)
+		}
+		return
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		if p.secret[key] {
+			p.addf("%s: secret value is not an absolute http(s) URL", key)
+			return
+		}
+		p.addf("%s: %q is not an absolute http(s) URL", key, raw)
+	}
+}
+
+func (p *problems) tls(key string, t TLSConfig) {
+	if t.CertFile == "" {
+		p.addf("%s.cert_file: is required", key)
+	}
+	if t.KeyFile == "" {
+		p.addf("%s.key_file: is required", key)
+	}
+	if _, ok := TLSVersions[t.MinVersion]; !ok && t.MinVersion != "" {
+		p.addf("%s.min_version: %q is not one of 1.2, 1.3", key, t.MinVersion)
+	}
+	for _, name := range t.CipherSuites {
+		if _, ok := CipherSuite(name); !ok {
+			p.addf("%s.cipher_suites: unknown or insecure cipher suite %q", key, name)
+		}
+	}
+	switch t.ClientAuth {
+	case "", "none":
+	case "request", "require":
+		if t.ClientCAFile == "" {
+			p.addf("%s.client_ca_file: is required for client_auth %q", key, t.ClientAuth)
+		}
+	default:
+		p.addf("%s.client_auth: %q is not one of none, request, require", key, t.ClientAuth)
+	}
+	if t.ClientCertAuth && (t.ClientAuth == "" || t.ClientAuth == "none") {
+		p.addf("%s.client_cert_auth: needs client_auth request or require", key)
+	}
+}
+
+func (p *problems) cors(key string, c CORSConfig) {
+	if !strings.HasPrefix(c.PathPrefix, "/") {
+		p.addf("%s.path_prefix: must start with /", key)
+	} else if !versionedAPIPath(c.PathPrefix) {
+		p.addf("%s.path_prefix: %s never matches, since unversioned /api paths are rewritten to "+
+			"their version first; use e.g. /api/v1%s, or /api for every version",
+			key, c.PathPrefix, strings.TrimPrefix(c.PathPrefix, "/api"))
+	}
+	if len(c.AllowOrigins) == 0 {
+		p.addf("%s.allow_origins: is required", key)
+	}
+	for _, origin := range c.AllowOrigins {
+		if origin == "*" {
+			if len(c.AllowOrigins) > 1 {
+				p.addf("%s.allow_origins: \"*\" must be the only entry", key)
+			}
+			if c.AllowCredentials {
+				p.addf("%s.allow_origins: \"*\" cannot be combined with allow_credentials", key)
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			p.addf("%s.allow_origins: %q is not an origin such as https://app.example.com", key, origin)
+		}
+	}
+	if c.MaxAge < 0 {
+		p.addf("%s.max_age: must not be negative", key)
+	}
+}
+
+// apiVersionSegment matches the version in /api/v1/... paths.
+var apiVersionSegment = regexp.MustCompile(`^v[0-9]+$`)
+
+// versionedAPIPath reports whether requests can still have path after the public API's
+// version routing: paths outside /api/, /api itself, versioned paths and the API document,
+// which is served unversioned.
+func versionedAPIPath(path string) bool {
+	rest, ok := strings.CutPrefix(path, "/api/")
+	if !ok || rest == "" || strings.HasPrefix(rest, "openapi.") {
+		return true
+	}
+	segment, _, _ := strings.Cut(rest, "/")
+	return apiVersionSegment.MatchString(segment)
+}
+
+func (p *problems) deprecation(key string, d DeprecationConfig) {
+	if !strings.HasPrefix(d.Path, "/") {
+		p.addf("%s.path: must start with /", key)
+	}
+	if d.Since.IsZero() {
+		p.addf("%s.since: is required", key)
+	} else if !d.Sunset.IsZero() && d.Sunset.Before(d.Since) {
+		p.addf("%s.sunset: must not be before since", key)
+	}
+	p.url(key+".link", d.Link, false)
+}
+
+func (p *problems) elastic(key string, es ElasticLogConfig) {
+	p.url(key+".url", es.URL, true)
+	if es.Index == "" {
+		p.addf("%s.index: is required", key)
+	}
+	switch es.DropPolicy {
+	case "", "drop_newest", "drop_oldest", "block":
+	default:
+		p.addf("%s.drop_policy: %q is not one of drop_newest, drop_oldest, block", key, es.DropPolicy)
+	}
+}
+
+// sink checks the built-in sink types; other types are registered at runtime and checked by log.Init.
+func (p *problems) sink(key string, s SinkConfig) {
+	if s.Type == "" {
+		p.addf("%s.type: is required", key)
+	}
+	p.level(key+".level", s.Level, true)
+	switch strings.ToLower(s.Type) {
+	case "syslog":
+		if n := strings.ToLower(s.Network); n != "" && n != "udp" && n != "tcp" {
+			p.addf("%s.network: %q is not one of udp, tcp", key, s.Network)
+		}
+		if s.Address == "" {
+			p.addf("%s.address: is required", key)
+		}
+		if s.Facility < 0 || s.Facility > 23 {
+			p.addf("%s.facility: %d is out of range 0-23", key, s.Facility)
+		}
+	case "loki", "otlp":
+		p.url(key+".url", s.URL, true)
+	case "elasticsearch":
+		p.elastic(key+".elasticsearch", s.Elasticsearch)
+	}
+}
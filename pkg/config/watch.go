@@ -0,0 +1,243 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/pflag"
+)
+
+// Subscriber applies a reloaded configuration to a running component.
+type Subscriber func(old, new *Config) error
+
+// ReloadReport describes the outcome of one reload.
+type ReloadReport struct {
+	// Changed are the keys whose new values were applied.
+	Changed []string
+	// Rejected are changed keys that only take effect after a restart; their running values were kept.
+	Rejected []string
+	// Err is set when the file could not be loaded or validated (nothing was applied)
+	// or when subscribers failed to apply the new values.
+	Err error
+}
+
+type subscription struct {
+	keys []string
+	fn   Subscriber
+}
+
+// Watcher reloads the configuration when its file changes or the process receives SIGHUP.
+// A reloaded configuration is validated first; changes to keys that a subscriber has
+// registered for are applied live, everything else (e.g. server.port, database.path)
+// keeps its running value until the next restart.
+type Watcher struct {
+	path  string
+	flags *pflag.FlagSet
+
+	current atomic.Pointer[Config]
+
+	mu       sync.Mutex // serialises reloads and guards subs/onReload
+	subs     []subscription
+	onReload []func(ReloadReport)
+
+	debounce time.Duration
+}
+
+// NewWatcher returns a watcher for the configuration loaded from configPath (or --config),
+// starting from initial.
+func NewWatcher(configPath string, flags *pflag.FlagSet, initial *Config) *Watcher {
+	w := &Watcher{path: resolvePath(configPath, flags), flags: flags, debounce: 250 * time.Millisecond}
+	w.current.Store(initial)
+	return w
+}
+
+// Current returns the configuration in effect.
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// Subscribe registers fn for changes below the given keys, e.g. "log" or "auth.audience".
+// Only keys with a subscriber can change without a restart.
+func (w *Watcher) Subscribe(fn Subscriber, keys ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subs = append(w.subs, subscription{keys: keys, fn: fn})
+}
+
+// OnReload registers fn to be told about every reload attempt, e.g. to log it.
+func (w *Watcher) OnReload(fn func(ReloadReport)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onReload = append(w.onReload, fn)
+}
+
+// Reload loads and validates the configuration and applies live changes.
+func (w *Watcher) Reload() ReloadReport {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	report := w.reloadLocked()
+	for _, fn := range w.onReload {
+		fn(report)
+	}
+	return report
+}
+
+func (w *Watcher) reloadLocked() ReloadReport {
+	next, err := LoadOrDefault(w.path, w.flags)
+	if err != nil {
+		return ReloadReport{Err: err}
+	}
+	old := w.current.Load()
+
+	var report ReloadReport
+	reconcile(reflect.ValueOf(old).Elem(), reflect.ValueOf(next).Elem(), "", w.liveLocked, &report)
+	if len(report.Changed) == 0 {
+		return report
+	}
+
+	w.current.Store(next)
+	var errs []error
+	for _, s := range w.subs {
+		if !touches(report.Changed, s.keys) {
+			continue
+		}
+		if err := s.fn(old, next); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	report.Err = errors.Join(errs...)
+	return report
+}
+
+// liveLocked reports whether a subscriber covers key.
+func (w *Watcher) liveLocked(key string) bool {
+	for _, s := range w.subs {
+		for _, k := range s.keys {
+			if coveredBy(key, k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func coveredBy(key, prefix string) bool {
+	return key == prefix || strings.HasPrefix(key, prefix+".")
+}
+
+func touches(changed, keys []string) bool {
+	for _, c := range changed {
+		for _, k := range keys {
+			if coveredBy(c, k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reconcile compares old and next field by field (by mapstructure key). Changed live keys are
+// recorded; changed keys that need a restart are reset to their old value and recorded as rejected.
+func reconcile(old, next reflect.Value, prefix string, live func(string) bool, report *ReloadReport) {
+	t := old.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := prefix + tag
+		of, nf := old.Field(i), next.Field(i)
+		if reflect.DeepEqual(of.Interface(), nf.Interface()) {
+			continue
+		}
+		if live(key) {
+			report.Changed = append(report.Changed, key)
+			continue
+		}
+		if of.Kind() == reflect.Struct {
+			reconcile(of, nf, key+".", live, report)
+			continue
+		}
+		nf.Set(of)
+		report.Rejected = append(report.Rejected, key)
+	}
+}
+
+// Run reloads on changes to the configuration file and on SIGHUP until ctx is cancelled.
+// The directory is watched rather than the file so that editors replacing the file and
+// Kubernetes ConfigMap symlink swaps are noticed.
+func (w *Watcher) Run(ctx context.Context) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config: %w", err)
+	}
+	defer fw.Close()
+
+	dir := filepath.Dir(w.path)
+	if err := fw.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch config directory %s: %w", dir, err)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	if sigs := reloadSignals(); len(sigs) > 0 {
+		signal.Notify(sigCh, sigs...)
+		defer signal.Stop(sigCh)
+	}
+
+	target := filepath.Clean(w.path)
+	var (
+		timer   *time.Timer
+		timerCh <-chan time.Time
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(ev.Name) != target && !strings.HasPrefix(filepath.Base(ev.Name), "..") {
+				continue
+			}
+			if ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			// Editors often write in several steps; reload once things settle.
+			if timer == nil {
+				timer = time.NewTimer(w.debounce)
+			} else {
+				timer.Reset(w.debounce)
+			}
+			timerCh = timer.C
+		case <-timerCh:
+			timerCh = nil
+			w.Reload()
+		case <-sigCh:
+			w.Reload()
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+			w.mu.Lock()
+			for _, fn := range w.onReload {
+				fn(ReloadReport{Err: fmt.Errorf("config watcher: %w", err)})
+			}
+			w.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_ReloadAppliesLiveKeysAndRejectsRestartKeys(t *testing.T) {
+	path := writeConfig(t, "server:\n  port: 9000\nlog:\n  level: info\n")
+	initial, err := Load(path)
+	require.NoError(t, err)
+
+	w := NewWatcher(path, nil, initial)
+	var applied []string
+	w.Subscribe(func(old, new *Config) error {
+		applied = append(applied, old.Log.Level+"->"+new.Log.Level)
+		return nil
+	}, "log")
+
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 9100\nlog:\n  level: debug\ndatabase:\n  path: other.db\n"), 0o644))
+	report := w.Reload()
+
+	require.NoError(t, report.Err)
+	assert.Equal(t, []string{"log"}, report.Changed)
+	assert.ElementsMatch(t, []string{"server.port", "database.path"}, report.Rejected)
+	assert.Equal(t, []string{"info->debug"}, applied)
+
+	cur := w.Current()
+	assert.Equal(t, "debug", cur.Log.Level)
+	assert.Equal(t, 9000, cur.Server.Port, "restart-only keys keep their running value")
+	assert.Equal(t, initial.Database.Path, cur.Database.Path)
+}
+
+func TestWatcher_InvalidConfigIsNotApplied(t *testing.T) {
+	path := writeConfig(t, "log:\n  level: info\n")
+	initial, err := Load(path)
+	require.NoError(t, err)
+
+	w := NewWatcher(path, nil, initial)
+	called := false
+	w.Subscribe(func(old, new *Config) error { called = true; return nil }, "log")
+
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: chatty\n"), 0o644))
+	report := w.Reload()
+
+	var verr *ValidationError
+	assert.ErrorAs(t, report.Err, &verr)
+	assert.False(t, called)
+	assert.Same(t, initial, w.Current())
+}
+
+func TestWatcher_RunReloadsOnFileChange(t *testing.T) {
+	path := writeConfig(t, "log:\n  level: info\n")
+	initial, err := Load(path)
+	require.NoError(t, err)
+
+	w := NewWatcher(path, nil, initial)
+	w.debounce = 10 * time.Millisecond
+	changed := make(chan string, 1)
+	w.Subscribe(func(old, new *Config) error {
+		changed <- new.Log.Level
+		return nil
+	}, "log")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	// Give the watcher a moment to register the directory.
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte("log:\n  level: warn\n"), 0o644))
+
+	select {
+	case lvl := <-changed:
+		assert.Equal(t, "warn", lvl)
+	case <-time.After(3 * time.Second):
+		t.Fatal("configuration was not reloaded")
+	}
+	cancel()
+	require.NoError(t, <-done)
+}
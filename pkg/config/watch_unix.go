@@ -0,0 +1,13 @@
+//go:build !windows
+
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignals are the signals that make a Watcher reload the configuration.
+func reloadSignals() []os.Signal {
+	return []os.Signal{syscall.SIGHUP}
+}
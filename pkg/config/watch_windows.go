@@ -0,0 +1,10 @@
+//go:build windows
+
+package config
+
+import "os"
+
+// reloadSignals is empty on Windows, which has no SIGHUP; file changes still trigger reloads.
+func reloadSignals() []os.Signal {
+	return nil
+}
@@ -0,0 +1,144 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// bulkResult classifies the items of an accepted bulk request.
+type bulkResult struct {
+	sent     int             // items acknowledged by Elasticsearch
+	retry    [][]byte        // throttled items worth sending again (429/503)
+	rejected []rejectedEntry // items that will never be accepted as-is
+}
+
+// rejectedEntry describes a document Elasticsearch refused permanently.
+type rejectedEntry struct {
+	Timestamp string          `json:"@timestamp"`
+	Index     string          `json:"index,omitempty"`
+	Status    int             `json:"status"`
+	ErrType   string          `json:"error_type,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+	Document  json.RawMessage `json:"document"`
+}
+
+type bulkResponse struct {
+	Errors bool                      `json:"errors"`
+	Items  []map[string]bulkItemResp `json:"items"`
+}
+
+type bulkItemResp struct {
+	Index  string `json:"_index"`
+	Status int    `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// parseBulkResponse reads a Bulk API response. Elasticsearch answers 200 even when individual
+// documents fail, reporting them per item (in request order) with "errors": true.
+func parseBulkResponse(r io.Reader, batch [][]byte) (bulkResult, error) {
+	var resp bulkResponse
+	if err := json.NewDecoder(r).Decode(&resp); err != nil {
+		return bulkResult{}, fmt.Errorf("decode bulk response: %w", err)
+	}
+	if !resp.Errors {
+		return bulkResult{sent: len(batch)}, nil
+	}
+	if len(resp.Items) != len(batch) {
+		return bulkResult{}, fmt.Errorf("bulk response has %d items for %d documents", len(resp.Items), len(batch))
+	}
+
+	var res bulkResult
+	for i, item := range resp.Items {
+		// Each item is keyed by its operation type ("index", "create", ...).
+		var it bulkItemResp
+		for _, v := range item {
+			it = v
+		}
+
+		switch {
+		case it.Status >= 200 && it.Status < 300:
+			res.sent++
+		case retryableBulkStatus(it.Status):
+			res.retry = append(res.retry, batch[i])
+		default:
+			rej := rejectedEntry{
+				Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+				Index:     it.Index,
+				Status:    it.Status,
+				Document:  bulkSource(batch[i]),
+			}
+			if it.Error != nil {
+				rej.ErrType = it.Error.Type
+				rej.Reason = it.Error.Reason
+			}
+			res.rejected = append(res.rejected, rej)
+		}
+	}
+	return res, nil
+}
+
+// retryableBulkStatus reports whether an item failure is transient (throttling or overload).
+func retryableBulkStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// bulkSource returns the document line of a bulk entry (the line after the action line).
+func bulkSource(entry []byte) json.RawMessage {
+	if i := bytes.IndexByte(entry, '\n'); i >= 0 {
+		entry = entry[i+1:]
+	}
+	doc := bytes.TrimSpace(entry)
+	if !json.Valid(doc) {
+		quoted, _ := json.Marshal(string(doc))
+		return quoted
+	}
+	return append(json.RawMessage{}, doc...)
+}
+
+// deadLetterFile appends rejected documents as JSON lines.
+type deadLetterFile struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func newDeadLetterFile(path string) (*deadLetterFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &deadLetterFile{f: f}, nil
+}
+
+func (d *deadLetterFile) write(entries []rejectedEntry) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.f.Write(buf.Bytes())
+	return err
+}
+
+func (d *deadLetterFile) close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.f.Close()
+}
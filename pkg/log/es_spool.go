@@ -0,0 +1,180 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// diskSpool stores undeliverable bulk batches as files in a directory so they survive
+// Elasticsearch outages (and process restarts) and can be replayed in order.
+//
+// File names are "<unix-nanos>-<seq>-<entries>.ndjson" so that lexical order is write order
+// and the entry count is known without reading the file.
+type diskSpool struct {
+	dir      string
+	maxBytes int64
+
+	mu  sync.Mutex
+	seq uint64
+}
+
+const spoolExt = ".ndjson"
+
+var errSpoolCorrupt = errors.New("corrupt spool file")
+
+func newDiskSpool(dir string, maxBytes int64) (*diskSpool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &diskSpool{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// write persists a batch. When the spool would exceed maxBytes the oldest files are removed
+// first; the number of entries discarded that way is returned.
+func (s *diskSpool) write(batch [][]byte) (int, error) {
+	var body bytes.Buffer
+	for _, entry := range batch {
+		body.Write(entry)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	evicted, err := s.evictLocked(int64(body.Len()))
+	if err != nil {
+		return evicted, err
+	}
+
+	s.seq++
+	name := fmt.Sprintf("%020d-%06d-%d%s", time.Now().UnixNano(), s.seq%1_000_000, len(batch), spoolExt)
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, body.Bytes(), 0o644); err != nil {
+		return evicted, err
+	}
+	// Rename so a crash never leaves a half-written batch for replay.
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		_ = os.Remove(tmp)
+		return evicted, err
+	}
+	return evicted, nil
+}
+
+// evictLocked removes the oldest files until incoming bytes fit within maxBytes.
+func (s *diskSpool) evictLocked(incoming int64) (int, error) {
+	if s.maxBytes <= 0 {
+		return 0, nil
+	}
+	files, err := s.filesLocked()
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+
+	evicted := 0
+	for len(files) > 0 && total+incoming > s.maxBytes {
+		oldest := files[0]
+		files = files[1:]
+		if err := os.Remove(filepath.Join(s.dir, oldest.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return evicted, err
+		}
+		total -= oldest.size
+		evicted += oldest.entries
+	}
+	return evicted, nil
+}
+
+// oldest returns the oldest spooled batch, or an empty name when the spool is empty.
+func (s *diskSpool) oldest() (string, [][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := s.filesLocked()
+	if err != nil || len(files) == 0 {
+		return "", nil, err
+	}
+
+	name := files[0].name
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Each entry is an action line followed by a source line.
+	lines := bytes.SplitAfter(bytes.TrimRight(data, "\n"), []byte("\n"))
+	if len(lines)%2 != 0 {
+		// Quarantine the file so it does not block replay forever.
+		_ = os.Rename(filepath.Join(s.dir, name), filepath.Join(s.dir, name+".corrupt"))
+		return "", nil, fmt.Errorf("%w: %s", errSpoolCorrupt, name)
+	}
+	batch := make([][]byte, 0, len(lines)/2)
+	for i := 0; i < len(lines); i += 2 {
+		entry := append(append([]byte{}, lines[i]...), lines[i+1]...)
+		if entry[len(entry)-1] != '\n' {
+			entry = append(entry, '\n')
+		}
+		batch = append(batch, entry)
+	}
+	return name, batch, nil
+}
+
+func (s *diskSpool) remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(filepath.Join(s.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+type spoolFile struct {
+	name    string
+	size    int64
+	entries int
+}
+
+// filesLocked lists spooled batches sorted oldest first.
+func (s *diskSpool) filesLocked() ([]spoolFile, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]spoolFile, 0, len(dirEntries))
+	for _, de := range dirEntries {
+		name := de.Name()
+		if de.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, spoolExt) {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, spoolFile{name: name, size: info.Size(), entries: spoolEntries(name)})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
+
+// spoolEntries extracts the entry count encoded in a spool file name.
+func spoolEntries(name string) int {
+	base := strings.TrimSuffix(name, spoolExt)
+	idx := strings.LastIndexByte(base, '-')
+	if idx < 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(base[idx+1:])
+	return n
+}
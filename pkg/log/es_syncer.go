@@ -4,27 +4,83 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"go.uber.org/zap/zapcore"
+	"traveler/pkg/config"
 )
 
+// Drop policies applied when the shipping queue is full.
+const (
+	dropNewest = "drop_newest" // discard the entry being written (default)
+	dropOldest = "drop_oldest" // discard the oldest queued entry to make room
+	dropBlock  = "block"       // block the caller until there is room
+)
+
+// ElasticStats is a snapshot of the Elasticsearch shipping counters.
+type ElasticStats struct {
+	Queued   int64 `json:"queued"`   // entries accepted into the queue
+	Sent     int64 `json:"sent"`     // entries acknowledged by Elasticsearch
+	Dropped  int64 `json:"dropped"`  // entries discarded by the drop policy or spool eviction
+	Failed   int64 `json:"failed"`   // entries discarded after exhausting retries
+	Retries  int64 `json:"retries"`  // bulk requests retried
+	Spooled  int64 `json:"spooled"`  // entries written to the disk spool
+	Replayed int64 `json:"replayed"` // spooled entries delivered after recovery
+	// Rejected counts documents Elasticsearch refused permanently (e.g. mapping conflicts).
+	Rejected     int64 `json:"rejected"`
+	DeadLettered int64 `json:"dead_lettered"` // rejected documents written to the dead-letter file
+}
+
+type elasticCounters struct {
+	queued, sent, dropped, failed, retries, spooled, replayed, rejected, deadLettered atomic.Int64
+}
+
 // elasticsearchSyncer implements zapcore.WriteSyncer and ships logs to Elasticsearch using the Bulk API.
+//
+// Entries are placed on a bounded queue, grouped into batches by a single collector goroutine
+// and posted by a fixed pool of workers with exponential backoff. Batches that still fail are
+// written to an optional disk spool and replayed once Elasticsearch accepts requests again.
 type elasticsearchSyncer struct {
-	bulkURL   string
-	indexName string
-	client    *http.Client
+	baseURL string
+	bulkURL string
+	index   indexNamer
+	opType  string // "index", or "create" for data streams
+	auth    string // Authorization header value, if any
+	client  *http.Client
+
+	template config.ElasticTemplateConfig
+	ready    chan struct{} // closed once template bootstrap has finished (or was not requested)
+
+	queue   chan []byte   // one entry = bulk action line + source line
+	batches chan [][]byte // handed from the collector to the workers
+	flushCh chan struct{}
+	done    chan struct{} // closed when Close starts
+	wg      sync.WaitGroup
+
+	mu        sync.RWMutex // guards closed and the queue close against concurrent writers
+	closed    bool
+	closeOnce sync.Once
 
-	mu     sync.Mutex
-	buf    bytes.Buffer // accumulates NDJSON bulk payload
-	count  int          // number of actions in current buffer
-	closed bool
+	flushEvery   time.Duration
+	maxActions   int
+	workers      int
+	maxRetries   int
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+	dropPolicy   string
+	syncTimeout  time.Duration
+	replayEvery  time.Duration
 
-	flushEvery time.Duration
-	maxActions int
+	spool      *diskSpool      // nil when spooling is disabled
+	deadLetter *deadLetterFile // nil when rejected documents are only counted
+	healthy    atomic.Bool
+
+	pending atomic.Int64 // entries queued or in flight
+	stats   elasticCounters
 
 	// error logging rate limit
 	errEvery    time.Duration
@@ -32,103 +88,465 @@ type elasticsearchSyncer struct {
 	errMu       sync.Mutex
 }
 
-func newElasticsearchSyncer(baseURL, index string) zapcore.WriteSyncer {
+func newElasticsearchSyncer(cfg *config.ElasticLogConfig) (*elasticsearchSyncer, error) {
 	es := &elasticsearchSyncer{
-		bulkURL:    strings.TrimRight(baseURL, "/") + "/_bulk",
-		indexName:  index,
-		client:     &http.Client{Timeout: 5 * time.Second},
-		flushEvery: 1 * time.Second,
-		maxActions: 200, // pairs of lines (index + doc) counts as 1 action
-		errEvery:   10 * time.Second,
+		baseURL:      strings.TrimRight(cfg.URL, "/"),
+		bulkURL:      strings.TrimRight(cfg.URL, "/") + "/_bulk",
+		index:        newIndexNamer(cfg.Index),
+		opType:       "index",
+		auth:         elasticAuthHeader(cfg),
+		client:       &http.Client{Timeout: 5 * time.Second},
+		template:     cfg.Template,
+		ready:        make(chan struct{}),
+		flushCh:      make(chan struct{}, 1),
+		done:         make(chan struct{}),
+		flushEvery:   orDuration(cfg.FlushInterval, time.Second),
+		maxActions:   orInt(cfg.BatchSize, 200), // pairs of lines (index + doc) counts as 1 action
+		workers:      orInt(cfg.Workers, 1),
+		maxRetries:   max(cfg.MaxRetries, 0),
+		retryBackoff: orDuration(cfg.RetryBackoff, 500*time.Millisecond),
+		maxBackoff:   orDuration(cfg.MaxBackoff, 30*time.Second),
+		dropPolicy:   strings.ToLower(cfg.DropPolicy),
+		syncTimeout:  5 * time.Second,
+		replayEvery:  5 * time.Second,
+		errEvery:     10 * time.Second,
+	}
+
+	if cfg.DataStream {
+		if es.index.dated() {
+			return nil, fmt.Errorf("elasticsearch data stream name %q must not contain a date pattern", cfg.Index)
+		}
+		// Data streams only accept create operations.
+		es.opType = "create"
+	}
+
+	switch es.dropPolicy {
+	case "":
+		es.dropPolicy = dropNewest
+	case dropNewest, dropOldest, dropBlock:
+	default:
+		return nil, fmt.Errorf("unknown elasticsearch drop policy %q", cfg.DropPolicy)
+	}
+
+	if cfg.SpoolDir != "" {
+		spool, err := newDiskSpool(cfg.SpoolDir, cfg.SpoolMaxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open elasticsearch spool: %w", err)
+		}
+		es.spool = spool
+	}
+
+	if cfg.DeadLetterFile != "" {
+		dl, err := newDeadLetterFile(cfg.DeadLetterFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open elasticsearch dead-letter file: %w", err)
+		}
+		es.deadLetter = dl
+	}
+
+	es.queue = make(chan []byte, orInt(cfg.Buffer, 1024))
+	es.batches = make(chan [][]byte, es.workers)
+	es.healthy.Store(true)
+
+	if es.template.Install {
+		es.wg.Add(1)
+		go es.bootstrap(cfg.DataStream)
+	} else {
+		close(es.ready)
+	}
+
+	es.wg.Add(1 + es.workers)
+	go es.collect()
+	for i := 0; i < es.workers; i++ {
+		go es.work()
 	}
-	// start periodic flusher
-	go es.flushLoop()
-	return es
+	if es.spool != nil {
+		es.wg.Add(1)
+		go es.replayLoop()
+	}
+	return es, nil
 }
 
 func (e *elasticsearchSyncer) Write(p []byte) (int, error) {
 	// Each zap entry is a single JSON object. Bulk requires action line + source line.
-	// We add: {"index":{"_index":"<index>"}}\n<json>\n
+	// We add: {"<op>":{"_index":"<index>"}}\n<json>\n
+	// Dated index names are resolved at write time, which is within the flush interval
+	// of the entry's own timestamp.
 	if len(p) == 0 {
 		return 0, nil
 	}
 
-	e.mu.Lock()
-	defer e.mu.Unlock()
+	// ensure newline-trimmed JSON then re-add newline for NDJSON
+	jsonLine := bytes.TrimSpace(p)
+	meta := fmt.Sprintf("{\"%s\":{\"_index\":\"%s\"}}\n", e.opType, e.index.at(time.Now()))
+	entry := make([]byte, 0, len(meta)+len(jsonLine)+1)
+	entry = append(entry, meta...)
+	entry = append(entry, jsonLine...)
+	entry = append(entry, '\n')
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	if e.closed {
 		return 0, io.ErrClosedPipe
 	}
 
-	// ensure newline-trimmed JSON then re-add newline for NDJSON
-	jsonLine := strings.TrimSpace(string(p))
-	meta := fmt.Sprintf("{\"index\":{\"_index\":\"%s\"}}\n", e.indexName)
-	e.buf.WriteString(meta)
-	e.buf.WriteString(jsonLine)
-	e.buf.WriteByte('\n')
-	e.count++
-
-	if e.count >= e.maxActions {
-		// flush without holding the lock for network call
-		buf := e.swapBufferLocked()
-		go e.post(buf)
+	if !e.enqueue(entry) {
+		e.stats.dropped.Add(1)
+		e.logErrRateLimited("elasticsearch log queue full, dropping entries", map[string]interface{}{
+			"policy":  e.dropPolicy,
+			"dropped": e.stats.dropped.Load(),
+		})
 	}
 	return len(p), nil
 }
 
+// enqueue places entry on the queue according to the drop policy and reports whether it was accepted.
+func (e *elasticsearchSyncer) enqueue(entry []byte) bool {
+	e.pending.Add(1)
+	if e.tryEnqueue(entry) {
+		e.stats.queued.Add(1)
+		return true
+	}
+	e.pending.Add(-1)
+	return false
+}
+
+func (e *elasticsearchSyncer) tryEnqueue(entry []byte) bool {
+	switch e.dropPolicy {
+	case dropBlock:
+		select {
+		case e.queue <- entry:
+			return true
+		case <-e.done:
+			return false
+		}
+	case dropOldest:
+		for {
+			select {
+			case e.queue <- entry:
+				return true
+			default:
+			}
+			// Make room by evicting the oldest entry; another writer may win the slot, so loop.
+			select {
+			case <-e.queue:
+				e.pending.Add(-1)
+				e.stats.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case e.queue <- entry:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// Sync asks the collector to flush its partial batch and waits until every queued entry has been
+// delivered, spooled or dropped, or until the sync timeout elapses.
 func (e *elasticsearchSyncer) Sync() error {
-	e.mu.Lock()
-	buf := e.swapBufferLocked()
-	e.mu.Unlock()
-	if buf == nil {
+	select {
+	case e.flushCh <- struct{}{}:
+	default:
+	}
+
+	deadline := time.Now().Add(e.syncTimeout)
+	for e.pending.Load() > 0 {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("elasticsearch sync timed out with %d entries pending", e.pending.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
+// Close stops accepting entries, delivers what is still queued (without further retries) and
+// stops all background goroutines.
+func (e *elasticsearchSyncer) Close() error {
+	e.closeOnce.Do(func() {
+		// Unblock writers waiting under the block policy before taking the write lock.
+		close(e.done)
+		e.mu.Lock()
+		e.closed = true
+		close(e.queue)
+		e.mu.Unlock()
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		if e.deadLetter != nil {
+			return e.deadLetter.close()
+		}
 		return nil
+	case <-time.After(e.syncTimeout):
+		return fmt.Errorf("elasticsearch shipper did not stop in time with %d entries pending", e.pending.Load())
+	}
+}
+
+// Stats returns a snapshot of the shipping counters.
+func (e *elasticsearchSyncer) Stats() ElasticStats {
+	return ElasticStats{
+		Queued:   e.stats.queued.Load(),
+		Sent:     e.stats.sent.Load(),
+		Dropped:  e.stats.dropped.Load(),
+		Failed:   e.stats.failed.Load(),
+		Retries:  e.stats.retries.Load(),
+		Spooled:  e.stats.spooled.Load(),
+		Replayed: e.stats.replayed.Load(),
+
+		Rejected:     e.stats.rejected.Load(),
+		DeadLettered: e.stats.deadLettered.Load(),
 	}
-	return e.post(buf)
 }
 
-func (e *elasticsearchSyncer) flushLoop() {
+func (e *elasticsearchSyncer) sinkStats() SinkStats {
+	st := e.Stats()
+	return SinkStats{Type: "elasticsearch", Sent: st.Sent, Dropped: st.Dropped, Failed: st.Failed + st.Rejected}
+}
+
+// collect groups queued entries into batches of at most maxActions, flushing partial batches
+// every flushEvery or when Sync asks for it.
+func (e *elasticsearchSyncer) collect() {
+	defer e.wg.Done()
+	defer close(e.batches)
+
 	ticker := time.NewTicker(e.flushEvery)
 	defer ticker.Stop()
-	for range ticker.C {
-		e.mu.Lock()
-		if e.closed {
-			e.mu.Unlock()
+
+	batch := make([][]byte, 0, e.maxActions)
+	flush := func() {
+		if len(batch) == 0 {
 			return
 		}
-		buf := e.swapBufferLocked()
-		e.mu.Unlock()
-		if buf != nil {
-			_ = e.post(buf) // best-effort; errors are swallowed to avoid crashing the app
+		e.batches <- batch
+		batch = make([][]byte, 0, e.maxActions)
+	}
+
+	for {
+		select {
+		case entry, ok := <-e.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= e.maxActions {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.flushCh:
+			// Drain whatever is already queued so Sync does not wait for the next tick.
+			for drained := false; !drained; {
+				select {
+				case entry, ok := <-e.queue:
+					if !ok {
+						flush()
+						return
+					}
+					batch = append(batch, entry)
+					if len(batch) >= e.maxActions {
+						flush()
+					}
+				default:
+					drained = true
+				}
+			}
+			flush()
 		}
 	}
 }
 
-func (e *elasticsearchSyncer) swapBufferLocked() *bytes.Buffer {
-	if e.count == 0 || e.buf.Len() == 0 {
-		return nil
+func (e *elasticsearchSyncer) work() {
+	defer e.wg.Done()
+	e.awaitReady()
+	for batch := range e.batches {
+		e.deliver(batch)
 	}
-	old := e.buf
-	e.buf = bytes.Buffer{}
-	e.count = 0
-	return &old
 }
 
-func (e *elasticsearchSyncer) post(body *bytes.Buffer) error {
-	if body == nil || body.Len() == 0 {
-		return nil
+// deliver posts a batch, falling back to the spool (if any) when Elasticsearch is unavailable.
+func (e *elasticsearchSyncer) deliver(batch [][]byte) {
+	defer e.pending.Add(-int64(len(batch)))
+
+	// While Elasticsearch is known to be down, keep ordering by appending to the spool
+	// instead of racing the replay loop.
+	if e.spool != nil && !e.healthy.Load() {
+		e.spoolBatch(batch)
+		return
+	}
+
+	left, err := e.postWithRetry(batch)
+	if err == nil {
+		return
+	}
+	if e.spool != nil {
+		e.spoolBatch(left)
+		return
+	}
+	e.stats.failed.Add(int64(len(left)))
+	e.logErrRateLimited("elasticsearch batch dropped after retries", map[string]interface{}{
+		"error":   err.Error(),
+		"entries": len(left),
+		"failed":  e.stats.failed.Load(),
+	})
+}
+
+// postWithRetry posts a batch, retrying with exponential backoff and jitter up to maxRetries times.
+// Only the entries that still need delivery are retried: the whole batch after a request-level
+// failure, or the throttled items of a partially accepted bulk request. Entries that could not be
+// delivered are returned together with the last error. Retries stop early once Close has been called.
+func (e *elasticsearchSyncer) postWithRetry(batch [][]byte) ([][]byte, error) {
+	backoff := e.retryBackoff
+	for attempt := 0; ; attempt++ {
+		res, err := e.post(batch)
+		if err == nil {
+			e.healthy.Store(true)
+			e.settle(res)
+			if len(res.retry) == 0 {
+				return nil, nil
+			}
+			batch = res.retry
+			err = fmt.Errorf("elasticsearch throttled %d bulk items", len(batch))
+		}
+		if attempt >= e.maxRetries {
+			e.healthy.Store(false)
+			return batch, err
+		}
+
+		e.stats.retries.Add(1)
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-time.After(wait):
+		case <-e.done:
+			e.healthy.Store(false)
+			return batch, err
+		}
+		backoff = min(backoff*2, e.maxBackoff)
+	}
+}
+
+// settle records the outcome of an accepted bulk request: acknowledged items count as sent
+// and permanently rejected items go to the dead-letter file.
+func (e *elasticsearchSyncer) settle(res bulkResult) {
+	e.stats.sent.Add(int64(res.sent))
+	if len(res.rejected) == 0 {
+		return
+	}
+	e.stats.rejected.Add(int64(len(res.rejected)))
+	first := res.rejected[0]
+	e.logErrRateLimited("elasticsearch rejected log documents", map[string]interface{}{
+		"count":    len(res.rejected),
+		"status":   first.Status,
+		"type":     first.ErrType,
+		"reason":   first.Reason,
+		"rejected": e.stats.rejected.Load(),
+	})
+	if e.deadLetter == nil {
+		return
+	}
+	if err := e.deadLetter.write(res.rejected); err != nil {
+		e.logErrRateLimited("elasticsearch dead-letter write failed", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	e.stats.deadLettered.Add(int64(len(res.rejected)))
+}
+
+func (e *elasticsearchSyncer) spoolBatch(batch [][]byte) {
+	evicted, err := e.spool.write(batch)
+	if evicted > 0 {
+		e.stats.dropped.Add(int64(evicted))
 	}
-	req, err := http.NewRequest(http.MethodPost, e.bulkURL, body)
+	if err != nil {
+		e.stats.failed.Add(int64(len(batch)))
+		e.logErrRateLimited("elasticsearch spool write failed", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	e.stats.spooled.Add(int64(len(batch)))
+}
+
+// replayLoop periodically re-sends spooled batches, oldest first.
+func (e *elasticsearchSyncer) replayLoop() {
+	defer e.wg.Done()
+	ticker := time.NewTicker(e.replayEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+			e.awaitReady()
+			e.replaySpool()
+		}
+	}
+}
+
+func (e *elasticsearchSyncer) replaySpool() {
+	for {
+		name, batch, err := e.spool.oldest()
+		if err != nil {
+			e.logErrRateLimited("elasticsearch spool read failed", map[string]interface{}{"error": err.Error()})
+			return
+		}
+		if name == "" {
+			// Nothing left to replay; let workers probe Elasticsearch directly again.
+			e.healthy.Store(true)
+			return
+		}
+		res, err := e.post(batch)
+		if err != nil {
+			e.healthy.Store(false)
+			return
+		}
+		e.settle(res)
+		if len(res.retry) > 0 {
+			// Keep throttled items for the next round; they move to the end of the spool.
+			e.spoolBatch(res.retry)
+		}
+		if err := e.spool.remove(name); err != nil {
+			e.logErrRateLimited("elasticsearch spool cleanup failed", map[string]interface{}{"error": err.Error()})
+			return
+		}
+		e.stats.replayed.Add(int64(len(batch) - len(res.retry)))
+		if len(res.retry) > 0 {
+			return
+		}
+	}
+}
+
+// post sends one bulk request. A non-nil error means the request as a whole failed and every
+// entry may be retried; otherwise the result classifies the individual items.
+func (e *elasticsearchSyncer) post(batch [][]byte) (bulkResult, error) {
+	if len(batch) == 0 {
+		return bulkResult{}, nil
+	}
+	body := bytes.NewBuffer(make([]byte, 0, len(batch)*256))
+	for _, entry := range batch {
+		body.Write(entry)
+	}
+
+	req, err := e.newRequest(http.MethodPost, e.bulkURL, body)
 	if err != nil {
 		e.logErrRateLimited("elasticsearch bulk request build failed", map[string]interface{}{"error": err.Error()})
-		return err
+		return bulkResult{}, err
 	}
 	req.Header.Set("Content-Type", "application/x-ndjson")
 	resp, err := e.client.Do(req)
 	if err != nil {
 		e.logErrRateLimited("elasticsearch bulk post failed", map[string]interface{}{"error": err.Error()})
-		return err
+		return bulkResult{}, err
 	}
 	defer resp.Body.Close()
-	// Consider non-2xx a failure but do not propagate to crash; return error
+	// Consider non-2xx a failure so the caller can retry or spool the batch
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		// read a small snippet for context
 		var snippet bytes.Buffer
@@ -138,10 +556,30 @@ func (e *elasticsearchSyncer) post(body *bytes.Buffer) error {
 			"response": snippet.String(),
 		})
 		io.Copy(io.Discard, resp.Body)
-		return fmt.Errorf("elasticsearch bulk post failed: status %d", resp.StatusCode)
+		return bulkResult{}, fmt.Errorf("elasticsearch bulk post failed: status %d", resp.StatusCode)
 	}
+
+	res, err := parseBulkResponse(resp.Body, batch)
 	io.Copy(io.Discard, resp.Body)
-	return nil
+	if err != nil {
+		// The request was accepted; without a readable response we cannot tell which items
+		// failed, and resending would duplicate the ones that succeeded.
+		e.logErrRateLimited("elasticsearch bulk response unreadable", map[string]interface{}{"error": err.Error()})
+		return bulkResult{sent: len(batch)}, nil
+	}
+	return res, nil
+}
+
+// newRequest builds a request to the cluster carrying the configured credentials.
+func (e *elasticsearchSyncer) newRequest(method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if e.auth != "" {
+		req.Header.Set("Authorization", e.auth)
+	}
+	return req, nil
 }
 
 // logErrRateLimited logs a warning about ES shipping failures at most once per e.errEvery.
@@ -155,10 +593,24 @@ func (e *elasticsearchSyncer) logErrRateLimited(msg string, fields map[string]in
 	if !shouldLog {
 		return
 	}
-	// Use the package logger without creating import cycles (we are in package log).
 	kv := make([]interface{}, 0, len(fields)*2)
 	for k, v := range fields {
 		kv = append(kv, k, v)
 	}
-	Sugar().Warnw(msg, kv...)
+	// Log only to the local outputs: shipping our own failures would feed back into the queue.
+	diag().Warnw(msg, kv...)
+}
+
+func orInt(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+func orDuration(v, def time.Duration) time.Duration {
+	if v <= 0 {
+		return def
+	}
+	return v
 }
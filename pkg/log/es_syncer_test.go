@@ -0,0 +1,249 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"traveler/pkg/config"
+)
+
+// bulkStub is a stand-in Elasticsearch that counts received documents and can be
+// switched into failure mode.
+type bulkStub struct {
+	docs   atomic.Int64
+	status atomic.Int64
+}
+
+func newBulkStub(t *testing.T) (*bulkStub, *httptest.Server) {
+	stub := &bulkStub{}
+	stub.status.Store(http.StatusOK)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code := int(stub.status.Load()); code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		lines := 0
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			lines++
+		}
+		stub.docs.Add(int64(lines / 2))
+		_, _ = w.Write([]byte(`{"errors":false,"items":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return stub, srv
+}
+
+func testElasticConfig(url string) *config.ElasticLogConfig {
+	return &config.ElasticLogConfig{
+		Enabled:       true,
+		URL:           url,
+		Index:         "test-logs",
+		Buffer:        16,
+		Workers:       1,
+		BatchSize:     10,
+		FlushInterval: 10 * time.Millisecond,
+		MaxRetries:    3,
+		RetryBackoff:  time.Millisecond,
+		MaxBackoff:    5 * time.Millisecond,
+	}
+}
+
+func TestElasticsearchSyncer_RetriesUntilDelivered(t *testing.T) {
+	stub, srv := newBulkStub(t)
+	stub.status.Store(http.StatusServiceUnavailable)
+
+	es, err := newElasticsearchSyncer(testElasticConfig(srv.URL))
+	require.NoError(t, err)
+	defer es.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := es.Write([]byte(`{"msg":"hello"}` + "\n"))
+		require.NoError(t, err)
+	}
+
+	// Recover after the first attempt has failed.
+	require.Eventually(t, func() bool { return es.Stats().Retries > 0 }, time.Second, time.Millisecond)
+	stub.status.Store(http.StatusOK)
+
+	require.NoError(t, es.Sync())
+	stats := es.Stats()
+	assert.EqualValues(t, 3, stats.Sent)
+	assert.EqualValues(t, 0, stats.Failed)
+	assert.EqualValues(t, 3, stub.docs.Load())
+}
+
+func TestElasticsearchSyncer_SpoolsAndReplays(t *testing.T) {
+	stub, srv := newBulkStub(t)
+	stub.status.Store(http.StatusBadGateway)
+
+	cfg := testElasticConfig(srv.URL)
+	cfg.MaxRetries = 0
+	cfg.SpoolDir = t.TempDir()
+	es, err := newElasticsearchSyncer(cfg)
+	require.NoError(t, err)
+	defer es.Close()
+
+	for i := 0; i < 5; i++ {
+		_, err := es.Write([]byte(`{"msg":"while down"}`))
+		require.NoError(t, err)
+	}
+	require.NoError(t, es.Sync())
+	assert.EqualValues(t, 5, es.Stats().Spooled)
+	assert.EqualValues(t, 0, stub.docs.Load())
+
+	stub.status.Store(http.StatusOK)
+	es.replaySpool()
+
+	assert.EqualValues(t, 5, es.Stats().Replayed)
+	assert.EqualValues(t, 5, stub.docs.Load())
+	name, _, err := es.spool.oldest()
+	require.NoError(t, err)
+	assert.Empty(t, name, "spool should be empty after replay")
+}
+
+func TestElasticsearchSyncer_DropPolicies(t *testing.T) {
+	t.Run("drop_newest keeps queued entries", func(t *testing.T) {
+		es := &elasticsearchSyncer{queue: make(chan []byte, 2), dropPolicy: dropNewest, done: make(chan struct{})}
+		assert.True(t, es.enqueue([]byte("1")))
+		assert.True(t, es.enqueue([]byte("2")))
+		assert.False(t, es.enqueue([]byte("3")))
+		assert.Equal(t, "1", string(<-es.queue))
+		assert.Equal(t, "2", string(<-es.queue))
+	})
+
+	t.Run("drop_oldest evicts the head of the queue", func(t *testing.T) {
+		es := &elasticsearchSyncer{queue: make(chan []byte, 2), dropPolicy: dropOldest, done: make(chan struct{})}
+		assert.True(t, es.enqueue([]byte("1")))
+		assert.True(t, es.enqueue([]byte("2")))
+		assert.True(t, es.enqueue([]byte("3")))
+		assert.EqualValues(t, 1, es.stats.dropped.Load())
+		assert.EqualValues(t, 2, es.pending.Load())
+		assert.Equal(t, "2", string(<-es.queue))
+		assert.Equal(t, "3", string(<-es.queue))
+	})
+
+	t.Run("block gives up once closed", func(t *testing.T) {
+		es := &elasticsearchSyncer{queue: make(chan []byte, 1), dropPolicy: dropBlock, done: make(chan struct{})}
+		assert.True(t, es.enqueue([]byte("1")))
+		close(es.done)
+		assert.False(t, es.enqueue([]byte("2")))
+	})
+}
+
+func TestElasticsearchSyncer_PartialBulkFailures(t *testing.T) {
+	// Item 0 succeeds, item 1 is throttled once, item 2 is a mapping conflict.
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			_, _ = w.Write([]byte(`{"errors":true,"items":[
+				{"index":{"_index":"test-logs","status":201}},
+				{"index":{"_index":"test-logs","status":429,"error":{"type":"es_rejected_execution_exception"}}},
+				{"index":{"_index":"test-logs","status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse field [status]"}}}
+			]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}}]}`))
+	}))
+	defer srv.Close()
+
+	cfg := testElasticConfig(srv.URL)
+	cfg.FlushInterval = time.Hour // keep all three documents in one bulk request
+	cfg.DeadLetterFile = filepath.Join(t.TempDir(), "dead-letter.ndjson")
+	es, err := newElasticsearchSyncer(cfg)
+	require.NoError(t, err)
+
+	for _, doc := range []string{`{"msg":"ok"}`, `{"msg":"throttled"}`, `{"msg":"bad","status":{"code":1}}`} {
+		_, err := es.Write([]byte(doc))
+		require.NoError(t, err)
+	}
+	require.NoError(t, es.Sync())
+	require.NoError(t, es.Close())
+
+	stats := es.Stats()
+	assert.EqualValues(t, 2, stats.Sent)
+	assert.EqualValues(t, 1, stats.Retries)
+	assert.EqualValues(t, 1, stats.Rejected)
+	assert.EqualValues(t, 1, stats.DeadLettered)
+	assert.EqualValues(t, 2, calls.Load())
+
+	data, err := os.ReadFile(cfg.DeadLetterFile)
+	require.NoError(t, err)
+	var rej rejectedEntry
+	require.NoError(t, json.Unmarshal(data, &rej))
+	assert.Equal(t, 400, rej.Status)
+	assert.Equal(t, "mapper_parsing_exception", rej.ErrType)
+	assert.JSONEq(t, `{"msg":"bad","status":{"code":1}}`, string(rej.Document))
+}
+
+func TestIndexNamer(t *testing.T) {
+	at := time.Date(2026, 3, 7, 9, 0, 0, 0, time.UTC)
+
+	daily := newIndexNamer("traveler-logs-%Y.%m.%d")
+	assert.Equal(t, "traveler-logs-2026.03.07", daily.at(at))
+	assert.Equal(t, "traveler-logs", daily.base())
+	assert.Equal(t, "traveler-logs-*", daily.matchPattern())
+
+	fixed := newIndexNamer("traveler-logs")
+	assert.False(t, fixed.dated())
+	assert.Equal(t, "traveler-logs", fixed.at(at))
+	assert.Equal(t, "traveler-logs", fixed.matchPattern())
+}
+
+func TestElasticsearchSyncer_BootstrapsTemplatesForDataStream(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+		authz    []string
+		bulk     string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		authz = append(authz, r.Header.Get("Authorization"))
+		if r.URL.Path == "/_bulk" {
+			bulk = string(body)
+		}
+		_, _ = w.Write([]byte(`{"acknowledged":true,"errors":false}`))
+	}))
+	defer srv.Close()
+
+	cfg := testElasticConfig(srv.URL)
+	cfg.Index = "traveler-logs"
+	cfg.DataStream = true
+	cfg.APIKey = "c2VjcmV0"
+	cfg.Template = config.ElasticTemplateConfig{Install: true, Shards: 1}
+	es, err := newElasticsearchSyncer(cfg)
+	require.NoError(t, err)
+
+	_, err = es.Write([]byte(`{"msg":"hello"}`))
+	require.NoError(t, err)
+	require.NoError(t, es.Sync())
+	require.NoError(t, es.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"PUT /_component_template/traveler-logs-mappings",
+		"PUT /_index_template/traveler-logs",
+		"POST /_bulk",
+	}, requests)
+	for _, a := range authz {
+		assert.Equal(t, "ApiKey c2VjcmV0", a)
+	}
+	assert.Contains(t, bulk, `{"create":{"_index":"traveler-logs"}}`)
+}
@@ -0,0 +1,227 @@
+package log
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"traveler/pkg/config"
+)
+
+// indexNamer resolves index names that may contain strftime-style date verbs.
+type indexNamer struct {
+	pattern string
+	static  bool
+}
+
+func newIndexNamer(pattern string) indexNamer {
+	return indexNamer{pattern: pattern, static: !strings.Contains(pattern, "%")}
+}
+
+// dated reports whether the pattern contains date verbs.
+func (n indexNamer) dated() bool {
+	return !n.static
+}
+
+// at returns the index name for t (in UTC). Supported verbs: %Y %y %m %d %H and %%.
+func (n indexNamer) at(t time.Time) string {
+	if n.static {
+		return n.pattern
+	}
+	t = t.UTC()
+	var b strings.Builder
+	for i := 0; i < len(n.pattern); i++ {
+		c := n.pattern[i]
+		if c != '%' || i == len(n.pattern)-1 {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch n.pattern[i] {
+		case 'Y':
+			fmt.Fprintf(&b, "%04d", t.Year())
+		case 'y':
+			fmt.Fprintf(&b, "%02d", t.Year()%100)
+		case 'm':
+			fmt.Fprintf(&b, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(n.pattern[i])
+		}
+	}
+	return b.String()
+}
+
+// base returns the pattern up to the first date verb, trimmed of separators,
+// e.g. "traveler-logs" for "traveler-logs-%Y.%m.%d".
+func (n indexNamer) base() string {
+	if n.static {
+		return n.pattern
+	}
+	prefix := n.pattern[:strings.IndexByte(n.pattern, '%')]
+	return strings.TrimRight(prefix, "-_.")
+}
+
+// matchPattern returns the index pattern an index template should apply to.
+func (n indexNamer) matchPattern() string {
+	if n.static {
+		return n.pattern
+	}
+	return n.pattern[:strings.IndexByte(n.pattern, '%')] + "*"
+}
+
+// elasticAuthHeader returns the Authorization header for the cluster, preferring an API key.
+func elasticAuthHeader(cfg *config.ElasticLogConfig) string {
+	if cfg.APIKey != "" {
+		return "ApiKey " + cfg.APIKey
+	}
+	if cfg.Username != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(cfg.Username+":"+cfg.Password))
+	}
+	return ""
+}
+
+// logMappings are the field mappings for documents produced by our zap encoder.
+// Structured key/value fields are mapped dynamically: strings become keywords so they can be
+// filtered and aggregated on without exploding into text fields.
+var logMappings = map[string]interface{}{
+	"dynamic_templates": []interface{}{
+		map[string]interface{}{
+			"strings_as_keywords": map[string]interface{}{
+				"match_mapping_type": "string",
+				"mapping":            map[string]interface{}{"type": "keyword", "ignore_above": 1024},
+			},
+		},
+	},
+	"properties": map[string]interface{}{
+		"@timestamp": map[string]interface{}{"type": "date"},
+		"level":      map[string]interface{}{"type": "keyword"},
+		"logger":     map[string]interface{}{"type": "keyword"},
+		"caller":     map[string]interface{}{"type": "keyword"},
+		"msg": map[string]interface{}{
+			"type":   "text",
+			"fields": map[string]interface{}{"keyword": map[string]interface{}{"type": "keyword", "ignore_above": 256}},
+		},
+		"stacktrace": map[string]interface{}{"type": "text", "index": false},
+		"error":      map[string]interface{}{"type": "text"},
+	},
+}
+
+// awaitReady blocks until template bootstrap has finished or the shipper is closing.
+func (e *elasticsearchSyncer) awaitReady() {
+	select {
+	case <-e.ready:
+	case <-e.done:
+	}
+}
+
+// bootstrap installs the component and index templates, retrying while the cluster is
+// unreachable. Shipping waits for it so the first index is created with our mappings.
+// A permanent rejection (bad credentials, invalid template) is logged and shipping continues
+// with dynamic mappings rather than stalling forever.
+func (e *elasticsearchSyncer) bootstrap(dataStream bool) {
+	defer e.wg.Done()
+	defer close(e.ready)
+
+	backoff := e.retryBackoff
+	for {
+		permanent, err := e.installTemplates(dataStream)
+		if err == nil {
+			diag().Infow("elasticsearch log templates installed", "template", e.templateName())
+			return
+		}
+		if permanent {
+			diag().Errorw("elasticsearch log template rejected; continuing with dynamic mappings", "error", err)
+			return
+		}
+		e.logErrRateLimited("elasticsearch log template install failed; retrying", map[string]interface{}{"error": err.Error()})
+
+		select {
+		case <-time.After(backoff):
+		case <-e.done:
+			return
+		}
+		backoff = min(backoff*2, e.maxBackoff)
+	}
+}
+
+func (e *elasticsearchSyncer) templateName() string {
+	if e.template.Name != "" {
+		return e.template.Name
+	}
+	return e.index.base()
+}
+
+// installTemplates puts a component template with settings and mappings, and an index template
+// composed of it. The boolean reports whether a failure is permanent.
+func (e *elasticsearchSyncer) installTemplates(dataStream bool) (bool, error) {
+	name := e.templateName()
+	component := name + "-mappings"
+
+	settings := map[string]interface{}{
+		"number_of_shards":   max(e.template.Shards, 1),
+		"number_of_replicas": max(e.template.Replicas, 0),
+	}
+	componentBody := map[string]interface{}{
+		"template": map[string]interface{}{
+			"settings": settings,
+			"mappings": logMappings,
+		},
+	}
+	if permanent, err := e.putJSON("/_component_template/"+component, componentBody); err != nil {
+		return permanent, fmt.Errorf("component template %s: %w", component, err)
+	}
+
+	indexBody := map[string]interface{}{
+		"index_patterns": []string{e.index.matchPattern()},
+		"composed_of":    []string{component},
+		"priority":       200,
+		"_meta":          map[string]interface{}{"managed_by": "traveler"},
+	}
+	if dataStream {
+		indexBody["data_stream"] = map[string]interface{}{}
+	}
+	if permanent, err := e.putJSON("/_index_template/"+name, indexBody); err != nil {
+		return permanent, fmt.Errorf("index template %s: %w", name, err)
+	}
+	return false, nil
+}
+
+func (e *elasticsearchSyncer) putJSON(path string, body interface{}) (bool, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return true, err
+	}
+	req, err := e.newRequest(http.MethodPut, e.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return true, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+
+	var snippet bytes.Buffer
+	io.CopyN(&snippet, resp.Body, 512)
+	io.Copy(io.Discard, resp.Body)
+	permanent := resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests
+	return permanent, fmt.Errorf("status %d: %s", resp.StatusCode, snippet.String())
+}
@@ -0,0 +1,297 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelState describes the log levels currently in effect.
+type LevelState struct {
+	Level string `json:"level"`
+	// Packages maps package names (e.g. "auth" or "traveler/pkg/auth") to their own level.
+	Packages map[string]string `json:"packages,omitempty"`
+	// RevertAt is when the levels return to the configured defaults, if scheduled.
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// levelController holds the global zap.AtomicLevel plus per-package overrides and an
+// optional timer that restores the configured levels.
+type levelController struct {
+	level     zap.AtomicLevel
+	overrides atomic.Pointer[map[string]zapcore.Level]
+
+	mu          sync.Mutex
+	baseline    zapcore.Level
+	revertAfter time.Duration // default revert delay for changes without an explicit one
+	revertTimer *time.Timer
+	revertAt    time.Time
+}
+
+var levels = newLevelController()
+
+func newLevelController() *levelController {
+	lc := &levelController{level: zap.NewAtomicLevelAt(zapcore.InfoLevel), baseline: zapcore.InfoLevel}
+	lc.overrides.Store(&map[string]zapcore.Level{})
+	return lc
+}
+
+// ParseLevel converts a level name (debug/info/warn/error) into a zapcore.Level.
+func ParseLevel(level string) (zapcore.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "info", "":
+		return zapcore.InfoLevel, nil
+	case "warn", "warning":
+		return zapcore.WarnLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	default:
+		return zapcore.InfoLevel, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+// Levels returns the log levels currently in effect.
+func Levels() LevelState {
+	return levels.state()
+}
+
+// SetLevels changes the global level (if level is not empty) and replaces the per-package
+// overrides (if packages is not nil). When revertAfter is positive — or zero with a configured
+// default — the configured levels are restored after that delay; a negative value disables it.
+func SetLevels(level string, packages map[string]string, revertAfter time.Duration) (LevelState, error) {
+	var (
+		lvl       zapcore.Level
+		overrides map[string]zapcore.Level
+	)
+	if level != "" {
+		var err error
+		if lvl, err = ParseLevel(level); err != nil {
+			return LevelState{}, err
+		}
+	}
+	if packages != nil {
+		overrides = make(map[string]zapcore.Level, len(packages))
+		for pkg, l := range packages {
+			pkg = strings.Trim(strings.TrimSpace(pkg), "/")
+			if pkg == "" {
+				return LevelState{}, fmt.Errorf("empty package name")
+			}
+			parsed, err := ParseLevel(l)
+			if err != nil {
+				return LevelState{}, fmt.Errorf("package %s: %w", pkg, err)
+			}
+			overrides[pkg] = parsed
+		}
+	}
+
+	levels.mu.Lock()
+	if level != "" {
+		levels.level.SetLevel(lvl)
+	}
+	if overrides != nil {
+		levels.overrides.Store(&overrides)
+	}
+	levels.scheduleRevertLocked(revertAfter)
+	levels.mu.Unlock()
+
+	state := levels.state()
+	Info("log levels changed", "level", state.Level, "packages", state.Packages, "revert_at", state.RevertAt)
+	return state, nil
+}
+
+// RaiseVerbosity lowers the global threshold by one step (e.g. info -> debug).
+func RaiseVerbosity() LevelState {
+	return levels.step(-1)
+}
+
+// LowerVerbosity raises the global threshold by one step (e.g. info -> warn).
+func LowerVerbosity() LevelState {
+	return levels.step(+1)
+}
+
+func (lc *levelController) step(delta int) LevelState {
+	lc.mu.Lock()
+	next := lc.level.Level() + zapcore.Level(delta)
+	if next < zapcore.DebugLevel {
+		next = zapcore.DebugLevel
+	}
+	if next > zapcore.ErrorLevel {
+		next = zapcore.ErrorLevel
+	}
+	lc.level.SetLevel(next)
+	lc.scheduleRevertLocked(0)
+	lc.mu.Unlock()
+
+	state := lc.state()
+	Info("log level changed", "level", state.Level, "revert_at", state.RevertAt)
+	return state
+}
+
+// reset installs the configured level, clearing overrides and any pending revert.
+func (lc *levelController) reset(baseline zapcore.Level, revertAfter time.Duration) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.baseline = baseline
+	lc.revertAfter = revertAfter
+	lc.level.SetLevel(baseline)
+	lc.overrides.Store(&map[string]zapcore.Level{})
+	lc.stopRevertLocked()
+}
+
+func (lc *levelController) scheduleRevertLocked(after time.Duration) {
+	lc.stopRevertLocked()
+	if after == 0 {
+		after = lc.revertAfter
+	}
+	if after <= 0 {
+		return
+	}
+	lc.revertAt = time.Now().Add(after).UTC()
+	lc.revertTimer = time.AfterFunc(after, lc.revert)
+}
+
+func (lc *levelController) stopRevertLocked() {
+	if lc.revertTimer != nil {
+		lc.revertTimer.Stop()
+		lc.revertTimer = nil
+	}
+	lc.revertAt = time.Time{}
+}
+
+func (lc *levelController) revert() {
+	lc.mu.Lock()
+	lc.level.SetLevel(lc.baseline)
+	lc.overrides.Store(&map[string]zapcore.Level{})
+	lc.revertTimer = nil
+	lc.revertAt = time.Time{}
+	lc.mu.Unlock()
+
+	Info("log levels reverted to configured defaults", "level", lc.baseline.String())
+}
+
+func (lc *levelController) state() LevelState {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	st := LevelState{Level: lc.level.Level().String()}
+	if ov := *lc.overrides.Load(); len(ov) > 0 {
+		st.Packages = make(map[string]string, len(ov))
+		for pkg, l := range ov {
+			st.Packages[pkg] = l.String()
+		}
+	}
+	if !lc.revertAt.IsZero() {
+		at := lc.revertAt
+		st.RevertAt = &at
+	}
+	return st
+}
+
+// minLevel is the most verbose level any package may currently log at.
+func (lc *levelController) minLevel() (zapcore.Level, bool) {
+	lvl := lc.level.Level()
+	ov := *lc.overrides.Load()
+	for _, l := range ov {
+		if l < lvl {
+			lvl = l
+		}
+	}
+	return lvl, len(ov) > 0
+}
+
+// enabledFor reports whether an entry logged from the given caller passes the levels.
+// The most specific matching override wins.
+func (lc *levelController) enabledFor(ent zapcore.Entry) bool {
+	ov := *lc.overrides.Load()
+	if len(ov) == 0 {
+		return lc.level.Enabled(ent.Level)
+	}
+	pkg := callerPackage(ent.Caller.Function)
+	if pkg == "" {
+		pkg = ent.LoggerName
+	}
+	if l, ok := matchOverride(ov, pkg); ok {
+		return l.Enabled(ent.Level)
+	}
+	return lc.level.Enabled(ent.Level)
+}
+
+// matchOverride finds the override for a package path, trying the full path and then
+// shorter suffixes ("traveler/pkg/auth", "pkg/auth", "auth").
+func matchOverride(ov map[string]zapcore.Level, pkg string) (zapcore.Level, bool) {
+	for pkg != "" {
+		if l, ok := ov[pkg]; ok {
+			return l, true
+		}
+		i := strings.IndexByte(pkg, '/')
+		if i < 0 {
+			break
+		}
+		pkg = pkg[i+1:]
+	}
+	return 0, false
+}
+
+// callerPackage extracts the import path from a fully qualified function name such as
+// "traveler/pkg/auth.JWTMiddleware.func1".
+func callerPackage(fn string) string {
+	slash := strings.LastIndexByte(fn, '/')
+	dot := strings.IndexByte(fn[slash+1:], '.')
+	if dot < 0 {
+		return ""
+	}
+	return fn[:slash+1+dot]
+}
+
+// levelCore applies the global level and per-package overrides in front of the real cores,
+// which are built fully enabled. Without overrides it defers to the wrapped core directly;
+// with overrides, entries are filtered in Write, once zap has resolved the caller.
+type levelCore struct {
+	zapcore.Core
+	ctl *levelController
+}
+
+func newLevelCore(core zapcore.Core, ctl *levelController) zapcore.Core {
+	return &levelCore{Core: core, ctl: ctl}
+}
+
+func (c *levelCore) Enabled(l zapcore.Level) bool {
+	min, _ := c.ctl.minLevel()
+	return min.Enabled(l) && c.Core.Enabled(l)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), ctl: c.ctl}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	min, hasOverrides := c.ctl.minLevel()
+	if !min.Enabled(ent.Level) {
+		return ce
+	}
+	if !hasOverrides {
+		return c.Core.Check(ent, ce)
+	}
+	if !c.Core.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *levelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.ctl.enabledFor(ent) {
+		return nil
+	}
+	// Re-check so wrapped cores with their own thresholds still filter the entry.
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
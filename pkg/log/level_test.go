@@ -0,0 +1,58 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLevelCore_PackageOverrides(t *testing.T) {
+	obs, logs := observer.New(zapcore.DebugLevel)
+	ctl := newLevelController()
+	ctl.reset(zapcore.InfoLevel, 0)
+	logger := zap.New(newLevelCore(obs, ctl), zap.AddCaller())
+
+	logger.Debug("hidden at info")
+	assert.Equal(t, 0, logs.Len())
+
+	// This test runs in traveler/pkg/log, so a "log" override applies to it.
+	ctl.overrides.Store(&map[string]zapcore.Level{"log": zapcore.DebugLevel})
+	logger.Debug("visible through override")
+	assert.Equal(t, 1, logs.Len())
+
+	ctl.overrides.Store(&map[string]zapcore.Level{"auth": zapcore.DebugLevel})
+	logger.Debug("other package override")
+	assert.Equal(t, 1, logs.Len())
+
+	// Overrides can also silence a package below the global level.
+	ctl.overrides.Store(&map[string]zapcore.Level{"traveler/pkg/log": zapcore.ErrorLevel})
+	logger.Info("silenced")
+	assert.Equal(t, 1, logs.Len())
+}
+
+func TestLevelController_RevertsAfterTimeout(t *testing.T) {
+	ctl := newLevelController()
+	ctl.reset(zapcore.WarnLevel, 0)
+
+	ctl.mu.Lock()
+	ctl.level.SetLevel(zapcore.DebugLevel)
+	ctl.overrides.Store(&map[string]zapcore.Level{"auth": zapcore.DebugLevel})
+	ctl.scheduleRevertLocked(20 * time.Millisecond)
+	ctl.mu.Unlock()
+	assert.NotNil(t, ctl.state().RevertAt)
+
+	assert.Eventually(t, func() bool {
+		st := ctl.state()
+		return st.Level == "warn" && len(st.Packages) == 0 && st.RevertAt == nil
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestCallerPackage(t *testing.T) {
+	assert.Equal(t, "traveler/pkg/auth", callerPackage("traveler/pkg/auth.JWTMiddleware.func1"))
+	assert.Equal(t, "main", callerPackage("main.main"))
+	assert.Equal(t, "", callerPackage(""))
+}
@@ -1,8 +1,12 @@
 package log
 
 import (
+	"errors"
 	"os"
-	"strings"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
 
 	"traveler/pkg/config"
 
@@ -10,26 +14,75 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-var sug *zap.SugaredLogger
+// outputs are the global loggers together with the outputs they write to. They are
+// replaced as a whole, so loggers in use are never changed under their callers.
+type outputs struct {
+	sug *zap.SugaredLogger
+	// diag writes only to the local outputs; used for problems with remote shipping.
+	diag *zap.SugaredLogger
+	// sinks are the remote log destinations attached to sug.
+	sinks []activeSink
+	// file is the (rotating) log file, if any.
+	file *rotatingFile
+}
+
+var (
+	current atomic.Pointer[outputs]
+	// buildMu serialises the functions that replace current.
+	buildMu sync.Mutex
+)
 
-// Init configures a global sugared logger based on a level string (debug/info/warn/error).
-// If filePath is not empty, logs will be written to both stdout and the specified file.
-// If esCfg is provided and Enabled, logs will also be shipped to Elasticsearch.
+// Init configures a global sugared logger from the log configuration.
+// The level (debug/info/warn/error) is only the starting point: it is held in a zap.AtomicLevel
+// and can be changed at runtime together with per-package overrides (see SetLevels).
+// If cfg.File is not empty, logs will be written to both stdout and the specified file.
+// If cfg.Elasticsearch is Enabled, logs will also be shipped to Elasticsearch, and every entry
+// of cfg.Sinks adds a further destination with its own level threshold (see RegisterSink).
 // It returns an error if the underlying logger cannot be built.
-func Init(level string, filePath string, esCfg *config.ElasticLogConfig) error {
-	lvl := zapcore.InfoLevel
-	switch strings.ToLower(level) {
-	case "debug":
-		lvl = zapcore.DebugLevel
-	case "info":
-		lvl = zapcore.InfoLevel
-	case "warn", "warning":
-		lvl = zapcore.WarnLevel
-	case "error":
-		lvl = zapcore.ErrorLevel
-	default:
-		lvl = zapcore.InfoLevel
+func Init(cfg *config.LogConfig) error {
+	buildMu.Lock()
+	defer buildMu.Unlock()
+	// Unknown levels fall back to info, as they always have.
+	lvl, _ := ParseLevel(cfg.Level)
+	levels.reset(lvl, cfg.LevelRevertAfter)
+	return build(cfg)
+}
+
+// Reconfigure applies a reloaded configuration (see config.Watcher). A changed level resets
+// the levels like Init does; other changes rebuild the outputs and sinks, keeping any
+// runtime level changes.
+func Reconfigure(old, new *config.Config) error {
+	buildMu.Lock()
+	defer buildMu.Unlock()
+	if old.Log.Level != new.Log.Level || old.Log.LevelRevertAfter != new.Log.LevelRevertAfter {
+		lvl, err := ParseLevel(new.Log.Level)
+		if err != nil {
+			return err
+		}
+		levels.reset(lvl, new.Log.LevelRevertAfter)
 	}
+	oldOutputs, newOutputs := old.Log, new.Log
+	oldOutputs.Level, oldOutputs.LevelRevertAfter = "", 0
+	newOutputs.Level, newOutputs.LevelRevertAfter = "", 0
+	if reflect.DeepEqual(oldOutputs, newOutputs) {
+		return nil
+	}
+	return build(&new.Log)
+}
+
+// build creates the outputs and the global loggers from cfg and swaps them in. The caller
+// holds buildMu.
+func build(cfg *config.LogConfig) error {
+	// Without redaction, a bare redactor still removes resolved configuration secrets.
+	red := &redactor{mode: redactMask}
+	if cfg.Redaction.Enabled {
+		var err error
+		if red, err = newRedactor(cfg.Redaction); err != nil {
+			return err
+		}
+	}
+	filePath := cfg.File
+	esCfg := &cfg.Elasticsearch
 
 	encCfg := zapcore.EncoderConfig{
 		TimeKey:        "@timestamp",
@@ -48,50 +101,173 @@ func Init(level string, filePath string, esCfg *config.ElasticLogConfig) error {
 
 	// Build base writer(s)
 	writers := []zapcore.WriteSyncer{zapcore.AddSync(os.Stdout)}
+	var file *rotatingFile
 	if filePath != "" {
-		if f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
-			writers = append(writers, zapcore.AddSync(f))
+		var err error
+		if file, err = newRotatingFile(filePath, cfg.Rotation); err != nil {
+			return err
 		}
+		writers = append(writers, file)
 	}
-	baseCore := zapcore.NewCore(zapcore.NewJSONEncoder(encCfg), zapcore.NewMultiWriteSyncer(writers...), lvl)
+	// Cores are fully enabled; levelCore applies the (runtime adjustable) levels in front of them.
+	baseCore := zapcore.NewCore(zapcore.NewJSONEncoder(encCfg), zapcore.NewMultiWriteSyncer(writers...), zapcore.DebugLevel)
 
 	core := baseCore
+	// The legacy log.elasticsearch block is an elasticsearch sink like any other.
+	sinkCfgs := cfg.Sinks
+	if esCfg.Enabled && esCfg.URL != "" && esCfg.Index != "" {
+		sinkCfgs = append([]config.SinkConfig{{Type: "elasticsearch", Elasticsearch: *esCfg}}, sinkCfgs...)
+	}
+	opened, sinkCores, err := openSinks(sinkCfgs, encCfg)
+	if err != nil {
+		if file != nil {
+			_ = file.Close()
+		}
+		return err
+	}
+	if len(sinkCores) > 0 {
+		core = zapcore.NewTee(append([]zapcore.Core{core}, sinkCores...)...)
+	}
+
+	zl := zap.New(newLevelCore(newRedactCore(core, red), levels), zap.AddCaller(), zap.AddCallerSkip(1))
+	previous := current.Swap(&outputs{
+		sug:   zl.Sugar(),
+		diag:  zap.New(newLevelCore(newRedactCore(baseCore, red), levels), zap.AddCaller()).Sugar(),
+		sinks: opened,
+		file:  file,
+	})
 
-	// Optionally add Elasticsearch core
-	if esCfg != nil && esCfg.Enabled && esCfg.URL != "" && esCfg.Index != "" {
-		esSyncer := newElasticsearchSyncer(esCfg.URL, esCfg.Index)
-		esCore := zapcore.NewCore(zapcore.NewJSONEncoder(encCfg), esSyncer, lvl)
-		core = zapcore.NewTee(core, esCore)
+	// Stop the previous outputs (if Init is called again) once the new logger is in place.
+	if previous != nil {
+		_ = previous.sug.Sync()
+		closeSinks(previous.sinks)
+		if previous.file != nil {
+			_ = previous.file.Close()
+		}
 	}
+	return nil
+}
 
-	zl := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
-	sug = zl.Sugar()
+// ReopenFiles reopens the log file at its configured path. Call it after an external tool
+// (e.g. logrotate) has moved the file away; SIGHUP triggers it (see HandleSignals).
+func ReopenFiles() error {
+	buildMu.Lock()
+	defer buildMu.Unlock()
+	out := current.Load()
+	if out == nil || out.file == nil {
+		return nil
+	}
+	return out.file.Reopen()
+}
+
+// bootstrapDiag reports problems of sinks opened before the first logger is in place.
+var bootstrapDiag = zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+	zapcore.Lock(os.Stdout), zapcore.DebugLevel)).Sugar()
+
+// diag returns the logger used to report problems with remote log shipping. It never
+// blocks on buildMu, since sinks may report while build holds it.
+func diag() *zap.SugaredLogger {
+	if out := current.Load(); out != nil {
+		return out.diag
+	}
+	return bootstrapDiag
+}
+
+// Close flushes buffered entries and stops remote log shipping. Logging continues
+// to the local outputs afterwards, so it is safe to call before the final log lines.
+func Close() error {
+	buildMu.Lock()
+	defer buildMu.Unlock()
+	out := current.Load()
+	if out == nil {
+		return nil
+	}
+	_ = out.sug.Sync()
+	if len(out.sinks) == 0 {
+		return nil
+	}
+	current.Store(&outputs{
+		sug:  out.diag.Desugar().WithOptions(zap.AddCallerSkip(1)).Sugar(),
+		diag: out.diag,
+		file: out.file,
+	})
+	return closeSinks(out.sinks)
+}
+
+// ShippingStats returns the counters of the first Elasticsearch sink. The boolean is false
+// when shipping to Elasticsearch is not enabled.
+func ShippingStats() (ElasticStats, bool) {
+	for _, s := range activeSinkList() {
+		if shipper, ok := s.sink.(*elasticsearchSyncer); ok {
+			return shipper.Stats(), true
+		}
+	}
+	return ElasticStats{}, false
+}
+
+// SinkStatistics returns the delivery counters of every configured sink, in configuration order.
+func SinkStatistics() []SinkStats {
+	var out []SinkStats
+	for _, s := range activeSinkList() {
+		if r, ok := s.sink.(statsReporter); ok {
+			out = append(out, r.sinkStats())
+		}
+	}
+	return out
+}
+
+// activeSinkList returns the remote log destinations currently attached to the logger.
+func activeSinkList() []activeSink {
+	if out := current.Load(); out != nil {
+		return out.sinks
+	}
 	return nil
 }
 
 // Sugar returns the global *zap.SugaredLogger. It will lazily initialize an
 // info-level logger if Init wasn't called.
 func Sugar() *zap.SugaredLogger {
-	if sug == nil {
-		_ = Init("info", "", nil)
+	if out := current.Load(); out != nil {
+		return out.sug
+	}
+	buildMu.Lock()
+	if current.Load() == nil {
+		_ = build(&config.LogConfig{Level: "info"})
 	}
-	return sug
+	buildMu.Unlock()
+	return current.Load().sug
 }
 
 // Logger returns the underlying *zap.Logger. It will lazily initialize if needed.
 func Logger() *zap.Logger {
-	if sug == nil {
-		_ = Init("info", "", nil)
-	}
-	return sug.Desugar()
+	return Sugar().Desugar()
 }
 
-// Sync flushes any buffered log entries.
+// Sync flushes any buffered log entries. Terminals and pipes (stdout in a container) cannot
+// be synced; those errors are ignored.
 func Sync() error {
-	if sug == nil {
+	out := current.Load()
+	if out == nil {
+		return nil
+	}
+	return ignoreUnsyncable(out.sug.Desugar().Sync())
+}
+
+// ignoreUnsyncable drops the errors fsync reports for outputs that are not files.
+func ignoreUnsyncable(err error) error {
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		var kept []error
+		for _, e := range multi.Unwrap() {
+			if e = ignoreUnsyncable(e); e != nil {
+				kept = append(kept, e)
+			}
+		}
+		return errors.Join(kept...)
+	}
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
 		return nil
 	}
-	return Logger().Sync()
+	return err
 }
 
 // Debug logs a debug message with optional key-value pairs.
@@ -0,0 +1,66 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"traveler/pkg/config"
+)
+
+func TestIgnoreUnsyncable(t *testing.T) {
+	stdout := &os.PathError{Op: "sync", Path: "/dev/stdout", Err: syscall.EINVAL}
+	tty := &os.PathError{Op: "sync", Path: "/dev/stderr", Err: syscall.ENOTTY}
+
+	assert.NoError(t, ignoreUnsyncable(nil))
+	assert.NoError(t, ignoreUnsyncable(stdout))
+	assert.NoError(t, ignoreUnsyncable(errors.Join(stdout, tty)))
+
+	err := ignoreUnsyncable(errors.Join(stdout, io.ErrClosedPipe))
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+	assert.NotErrorIs(t, err, syscall.EINVAL)
+}
+
+func TestReconfigure_WhileLogging(t *testing.T) {
+	t.Cleanup(func() { _ = Init(&config.LogConfig{Level: "info"}) })
+	// Outputs differ in redaction only, so every reload rebuilds the loggers.
+	cfgs := []*config.Config{{}, {}}
+	cfgs[0].Log.Level, cfgs[1].Log.Level = "info", "info"
+	cfgs[1].Log.Redaction.Enabled = true
+	require.NoError(t, Init(&cfgs[0].Log))
+
+	stop := make(chan struct{})
+	var wg, started sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					// Below the level, so nothing is written, but the logger is used.
+					Debug("request served")
+					_, _ = ShippingStats()
+				}
+			}
+		}()
+	}
+	started.Wait()
+	for i := range 100 {
+		require.NoError(t, Reconfigure(cfgs[i%2], cfgs[(i+1)%2]))
+		require.NoError(t, ReopenFiles())
+	}
+	close(stop)
+	wg.Wait()
+	require.NoError(t, Close())
+}
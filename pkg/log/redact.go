@@ -0,0 +1,278 @@
+package log
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+
+	"traveler/pkg/config"
+)
+
+// Redaction modes.
+const (
+	redactMask = "mask" // replace sensitive values with a fixed marker
+	redactHash = "hash" // replace them with a keyed hash so equal values still correlate
+)
+
+const redactedMarker = "[REDACTED]"
+
+// jwtPattern matches compact JWS/JWT strings (three base64url segments, header starting with "eyJ").
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`)
+
+// panPattern matches 13-19 digit sequences optionally separated by spaces or dashes.
+// Candidates are confirmed with a Luhn check to avoid scrubbing ordinary numbers.
+var panPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// redactor scrubs sensitive data from log fields and messages.
+type redactor struct {
+	keys        []*regexp.Regexp
+	mode        string
+	hashKey     []byte
+	scrubValues bool
+}
+
+func newRedactor(cfg config.RedactionConfig) (*redactor, error) {
+	r := &redactor{mode: strings.ToLower(cfg.Mode), scrubValues: cfg.ScrubValues}
+	switch r.mode {
+	case "":
+		r.mode = redactMask
+	case redactMask, redactHash:
+	default:
+		return nil, fmt.Errorf("unknown redaction mode %q", cfg.Mode)
+	}
+
+	for _, k := range cfg.Keys {
+		re, err := regexp.Compile("(?i)" + k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction key pattern %q: %w", k, err)
+		}
+		r.keys = append(r.keys, re)
+	}
+
+	r.hashKey = []byte(cfg.HashKey)
+	if r.mode == redactHash && len(r.hashKey) == 0 {
+		// Without a configured key, hashes still correlate within this process only.
+		r.hashKey = make([]byte, 32)
+		if _, err := rand.Read(r.hashKey); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *redactor) sensitiveKey(key string) bool {
+	for _, re := range r.keys {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// conceal replaces a whole sensitive value.
+func (r *redactor) conceal(value string) string {
+	if r.mode == redactHash {
+		mac := hmac.New(sha256.New, r.hashKey)
+		mac.Write([]byte(value))
+		return "hash:" + hex.EncodeToString(mac.Sum(nil)[:12])
+	}
+	return redactedMarker
+}
+
+// scrub replaces sensitive substrings (resolved configuration secrets, JWTs, card numbers)
+// inside a free-form string. Resolved secrets are replaced even when ScrubValues is off.
+func (r *redactor) scrub(s string) string {
+	if s == "" {
+		return s
+	}
+	s = r.scrubSecrets(s)
+	if !r.scrubValues {
+		return s
+	}
+	s = jwtPattern.ReplaceAllStringFunc(s, r.conceal)
+	s = panPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if luhnValid(m) {
+			return r.conceal(m)
+		}
+		return m
+	})
+	return s
+}
+
+// minSecretLen keeps trivially short secret values from mangling unrelated text.
+const minSecretLen = 4
+
+// scrubSecrets replaces values resolved from configuration secret references (see config.SecretProvider).
+func (r *redactor) scrubSecrets(s string) string {
+	for _, secret := range config.ResolvedSecrets() {
+		if len(secret) >= minSecretLen && strings.Contains(s, secret) {
+			s = strings.ReplaceAll(s, secret, redactedMarker)
+		}
+	}
+	return s
+}
+
+// fields returns a redacted copy of fields; the input slice is never modified.
+func (r *redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		out[i] = r.field(f)
+	}
+	return out
+}
+
+func (r *redactor) field(f zapcore.Field) zapcore.Field {
+	if r.sensitiveKey(f.Key) {
+		return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: r.conceal(fieldString(f))}
+	}
+	if !r.scrubValues && len(config.ResolvedSecrets()) == 0 {
+		return f
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = r.scrub(f.String)
+	case zapcore.ByteStringType:
+		if b, ok := f.Interface.([]byte); ok {
+			return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: r.scrub(string(b))}
+		}
+	case zapcore.ErrorType, zapcore.StringerType:
+		return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: r.scrub(fieldString(f))}
+	case zapcore.ReflectType:
+		f.Interface = r.value(f.Interface)
+	}
+	return f
+}
+
+// value walks generic JSON-like values (as produced by decoded claims or request bodies),
+// redacting sensitive keys and scrubbing strings. Other types are returned unchanged.
+func (r *redactor) value(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return r.scrub(t)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			if r.sensitiveKey(k) {
+				out[k] = r.conceal(fmt.Sprint(val))
+				continue
+			}
+			out[k] = r.value(val)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(t))
+		for k, val := range t {
+			if r.sensitiveKey(k) {
+				out[k] = r.conceal(val)
+				continue
+			}
+			out[k] = r.scrub(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			out[i] = r.value(val)
+		}
+		return out
+	case []string:
+		out := make([]string, len(t))
+		for i, val := range t {
+			out[i] = r.scrub(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// fieldString renders a field value as text for hashing or scrubbing.
+func fieldString(f zapcore.Field) string {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			return err.Error()
+		}
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok && s != nil {
+			return s.String()
+		}
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		if b, ok := f.Interface.([]byte); ok {
+			return string(b)
+		}
+	}
+	if f.Interface != nil {
+		return fmt.Sprint(f.Interface)
+	}
+	if f.String != "" {
+		return f.String
+	}
+	return fmt.Sprint(f.Integer)
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum used by card numbers.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// redactCore scrubs entries before they reach the wrapped cores, so every output
+// (stdout, file, Elasticsearch) receives the same redacted data. It is installed even with
+// redaction disabled, to keep resolved configuration secrets out of the logs.
+type redactCore struct {
+	zapcore.Core
+	r *redactor
+}
+
+func newRedactCore(core zapcore.Core, r *redactor) zapcore.Core {
+	if r == nil {
+		return core
+	}
+	return &redactCore{Core: core, r: r}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.fields(fields)), r: c.r}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Core.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.r.scrub(ent.Message)
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(c.r.fields(fields)...)
+	}
+	return nil
+}
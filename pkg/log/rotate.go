@@ -0,0 +1,274 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"traveler/pkg/config"
+)
+
+// rotatedTimeFormat is the timestamp inserted into rotated file names; it sorts lexically.
+const rotatedTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotatingFile is a zapcore.WriteSyncer writing to a log file that is rotated by size and/or
+// time. Rotated files are renamed to "<name>-<timestamp><ext>", optionally gzipped, and pruned
+// by count and age in the background. Reopen supports external rotation (logrotate + SIGHUP).
+type rotatingFile struct {
+	path     string
+	maxBytes int64
+	interval time.Duration
+	backups  int
+	maxAge   time.Duration
+	compress bool
+
+	mu         sync.Mutex
+	f          *os.File
+	size       int64
+	nextRotate time.Time
+
+	cleanupMu sync.Mutex // serialises compression and retention runs
+	cleanupWg sync.WaitGroup
+	now       func() time.Time
+}
+
+func newRotatingFile(path string, cfg config.LogRotationConfig) (*rotatingFile, error) {
+	r := &rotatingFile{
+		path:     path,
+		maxBytes: int64(cfg.MaxSizeMB) << 20,
+		interval: cfg.Interval,
+		backups:  cfg.MaxBackups,
+		maxAge:   time.Duration(cfg.MaxAgeDays) * 24 * time.Hour,
+		compress: cfg.Compress,
+		now:      time.Now,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := r.openLocked(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) openLocked() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.f = f
+	r.size = info.Size()
+	if r.interval > 0 {
+		r.nextRotate = r.now().Truncate(r.interval).Add(r.interval)
+	}
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	if r.shouldRotateLocked(int64(len(p))) {
+		if err := r.rotateLocked(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) shouldRotateLocked(incoming int64) bool {
+	if r.maxBytes > 0 && r.size > 0 && r.size+incoming > r.maxBytes {
+		return true
+	}
+	return r.interval > 0 && !r.now().Before(r.nextRotate)
+}
+
+// rotateLocked renames the current file aside and starts a new one.
+func (r *rotatingFile) rotateLocked() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	r.f = nil
+
+	ext := filepath.Ext(r.path)
+	rotated := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(r.path, ext), r.now().UTC().Format(rotatedTimeFormat), ext)
+	// Never overwrite an earlier rotation from the same millisecond.
+	for i := 1; fileExists(rotated) || fileExists(rotated+".gz"); i++ {
+		rotated = fmt.Sprintf("%s-%s.%d%s", strings.TrimSuffix(r.path, ext), r.now().UTC().Format(rotatedTimeFormat), i, ext)
+	}
+	if err := os.Rename(r.path, rotated); err != nil {
+		// Keep logging to the current file rather than losing entries.
+		if openErr := r.openLocked(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := r.openLocked(); err != nil {
+		return err
+	}
+
+	r.cleanupWg.Add(1)
+	go r.cleanup(rotated)
+	return nil
+}
+
+// Rotate forces a rotation regardless of size and time.
+func (r *rotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return os.ErrClosed
+	}
+	return r.rotateLocked()
+}
+
+// Reopen closes and reopens the file at the configured path. External tools such as
+// logrotate move the file away and then signal the process (SIGHUP) to call this.
+func (r *rotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f != nil {
+		_ = r.f.Close()
+		r.f = nil
+	}
+	return r.openLocked()
+}
+
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Sync()
+}
+
+// Close closes the file and waits for pending compression and retention work.
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	var err error
+	if r.f != nil {
+		err = r.f.Close()
+		r.f = nil
+	}
+	r.mu.Unlock()
+	r.cleanupWg.Wait()
+	return err
+}
+
+// cleanup compresses a freshly rotated file and applies retention.
+func (r *rotatingFile) cleanup(rotated string) {
+	defer r.cleanupWg.Done()
+	r.cleanupMu.Lock()
+	defer r.cleanupMu.Unlock()
+
+	if r.compress {
+		if err := gzipFile(rotated); err != nil {
+			diag().Warnw("failed to compress rotated log file", "file", rotated, "error", err)
+		}
+	}
+	if err := r.prune(); err != nil {
+		diag().Warnw("failed to prune rotated log files", "error", err)
+	}
+}
+
+// prune removes rotated files beyond the configured count or age.
+func (r *rotatingFile) prune() error {
+	if r.backups <= 0 && r.maxAge <= 0 {
+		return nil
+	}
+	dir := filepath.Dir(r.path)
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	type rotated struct {
+		name  string
+		stamp time.Time
+	}
+	var files []rotated
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		// The timestamp may be followed by a ".N" collision counter, the extension and ".gz".
+		stamp := strings.TrimPrefix(name, prefix)
+		if len(stamp) < len(rotatedTimeFormat) {
+			continue
+		}
+		t, err := time.Parse(rotatedTimeFormat, stamp[:len(rotatedTimeFormat)])
+		if err != nil {
+			continue
+		}
+		files = append(files, rotated{name: name, stamp: t})
+	}
+	// Newest first
+	sort.Slice(files, func(i, j int) bool { return files[i].stamp.After(files[j].stamp) })
+
+	cutoff := r.now().Add(-r.maxAge)
+	for i, f := range files {
+		expired := r.maxAge > 0 && f.stamp.Before(cutoff)
+		if (r.backups > 0 && i >= r.backups) || expired {
+			if err := os.Remove(filepath.Join(dir, f.name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// gzipFile replaces path with path.gz.
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		_ = zw.Close()
+		_ = dst.Close()
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,94 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"traveler/pkg/config"
+)
+
+func TestRotatingFile_RotatesBySizeAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	r, err := newRotatingFile(path, config.LogRotationConfig{MaxBackups: 2, Compress: true})
+	require.NoError(t, err)
+	r.maxBytes = 64
+
+	// Advance the clock so each rotation gets a distinct, ordered timestamp.
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { clock = clock.Add(time.Second); return clock }
+
+	line := []byte(strings.Repeat("x", 40) + "\n")
+	for i := 0; i < 5; i++ {
+		_, err := r.Write(line)
+		require.NoError(t, err)
+	}
+	require.NoError(t, r.Close())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var gz []string
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".log.gz") {
+			gz = append(gz, e.Name())
+		}
+	}
+	assert.Len(t, gz, 2, "only max_backups compressed files are kept: %v", entries)
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, line, current)
+}
+
+func TestRotatingFile_RotatesByInterval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	clock := time.Date(2026, 1, 1, 23, 59, 0, 0, time.UTC)
+
+	r, err := newRotatingFile(path, config.LogRotationConfig{})
+	require.NoError(t, err)
+	r.now = func() time.Time { return clock }
+	r.interval = 24 * time.Hour
+	require.NoError(t, r.Reopen())
+
+	_, err = r.Write([]byte("before midnight\n"))
+	require.NoError(t, err)
+	clock = clock.Add(2 * time.Minute)
+	_, err = r.Write([]byte("after midnight\n"))
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	rotated, err := os.ReadFile(filepath.Join(dir, "app-2026-01-02T00-01-00.000.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "before midnight\n", string(rotated))
+}
+
+func TestRotatingFile_ReopenAfterExternalMove(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	r, err := newRotatingFile(path, config.LogRotationConfig{})
+	require.NoError(t, err)
+	defer r.Close()
+
+	_, _ = r.Write([]byte("one\n"))
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, r.Reopen())
+	_, _ = r.Write([]byte("two\n"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "two\n", string(data))
+}
+
+func TestInit_FailsWhenLogFileCannotBeOpened(t *testing.T) {
+	dir := t.TempDir()
+	// A directory cannot be opened as the log file.
+	err := Init(&config.LogConfig{Level: "info", File: dir})
+	assert.Error(t, err)
+}
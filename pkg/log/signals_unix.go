@@ -0,0 +1,39 @@
+//go:build !windows
+
+package log
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleSignals reacts to logging signals until ctx is cancelled:
+// SIGUSR1 makes logging one step more verbose, SIGUSR2 one step less,
+// and SIGHUP reopens the log file for external logrotate compatibility.
+func HandleSignals(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-ch:
+				switch sig {
+				case syscall.SIGUSR1:
+					RaiseVerbosity()
+				case syscall.SIGUSR2:
+					LowerVerbosity()
+				case syscall.SIGHUP:
+					if err := ReopenFiles(); err != nil {
+						diag().Errorw("failed to reopen log file", "error", err)
+					}
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package log
+
+import "context"
+
+// HandleSignals is a no-op on Windows, which has no SIGUSR1/SIGUSR2/SIGHUP.
+// Use the admin log-level endpoint instead.
+func HandleSignals(ctx context.Context) {}
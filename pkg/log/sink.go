@@ -0,0 +1,405 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"traveler/pkg/config"
+)
+
+// Sink is a remote log destination configured in log.sinks. It receives every entry as one
+// JSON-encoded line per Write, must not block the caller for long, and is closed on shutdown.
+type Sink interface {
+	zapcore.WriteSyncer
+	io.Closer
+}
+
+// SinkFactory builds a Sink from its configuration.
+type SinkFactory func(cfg config.SinkConfig) (Sink, error)
+
+// SinkStats are the delivery counters of one configured sink.
+type SinkStats struct {
+	Type    string `json:"type"`
+	Sent    int64  `json:"sent"`
+	Dropped int64  `json:"dropped"`
+	Failed  int64  `json:"failed"`
+}
+
+// statsReporter is implemented by sinks that count deliveries.
+type statsReporter interface {
+	sinkStats() SinkStats
+}
+
+var (
+	sinkMu        sync.RWMutex
+	sinkFactories = map[string]SinkFactory{}
+)
+
+func init() {
+	RegisterSink("elasticsearch", func(cfg config.SinkConfig) (Sink, error) {
+		es := cfg.Elasticsearch
+		if es.URL == "" || es.Index == "" {
+			return nil, fmt.Errorf("elasticsearch sink requires url and index")
+		}
+		return newElasticsearchSyncer(&es)
+	})
+	RegisterSink("syslog", newSyslogSink)
+	RegisterSink("loki", newLokiSink)
+	RegisterSink("otlp", newOTLPSink)
+}
+
+// RegisterSink makes a sink type available to the log.sinks configuration.
+// Registering an existing type replaces it.
+func RegisterSink(typ string, factory SinkFactory) {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+	sinkFactories[strings.ToLower(typ)] = factory
+}
+
+// openSink builds the sink for cfg using the registered factory.
+func openSink(cfg config.SinkConfig) (Sink, error) {
+	sinkMu.RLock()
+	factory, ok := sinkFactories[strings.ToLower(cfg.Type)]
+	sinkMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown log sink type %q", cfg.Type)
+	}
+	s, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("log sink %s: %w", cfg.Type, err)
+	}
+	return s, nil
+}
+
+// sinkEntry is a decoded log line, as needed by sinks with their own wire formats.
+type sinkEntry struct {
+	Time    time.Time
+	Level   zapcore.Level
+	Message string
+	Logger  string
+	Caller  string
+	Fields  map[string]interface{} // everything else, in encoder output form
+	Raw     []byte                 // the JSON line without trailing newline
+}
+
+// zapTimeLayout matches zapcore.ISO8601TimeEncoder.
+const zapTimeLayout = "2006-01-02T15:04:05.000Z0700"
+
+func parseSinkEntry(line []byte) sinkEntry {
+	raw := bytes.TrimSpace(line)
+	e := sinkEntry{Time: time.Now(), Level: zapcore.InfoLevel, Raw: raw}
+
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		e.Message = string(raw)
+		return e
+	}
+	if ts, ok := m["@timestamp"].(string); ok {
+		if t, err := time.Parse(zapTimeLayout, ts); err == nil {
+			e.Time = t
+		}
+	}
+	if lvl, ok := m["level"].(string); ok {
+		_ = e.Level.UnmarshalText([]byte(lvl))
+	}
+	e.Message, _ = m["msg"].(string)
+	e.Logger, _ = m["logger"].(string)
+	e.Caller, _ = m["caller"].(string)
+	for _, k := range []string{"@timestamp", "level", "msg", "logger", "caller"} {
+		delete(m, k)
+	}
+	e.Fields = m
+	return e
+}
+
+// sortedKeys returns the keys of m in order, for deterministic output.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// batcher is the queueing and delivery engine shared by the syslog, Loki and OTLP sinks:
+// a bounded queue (newest entries are dropped when full), batches flushed by size or
+// interval, and a few retries with backoff before a batch is counted as failed. It is
+// deliberately simpler than elasticsearchSyncer: no drop policies, spool or dead letters.
+type batcher struct {
+	name      string
+	send      func(batch [][]byte) error
+	batchSize int
+	interval  time.Duration
+	retries   int
+	backoff   time.Duration
+
+	queue   chan []byte
+	flushCh chan chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+
+	mu        sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+
+	sent, dropped, failed atomic.Int64
+
+	errEvery    time.Duration
+	lastErrTime time.Time
+	errMu       sync.Mutex
+}
+
+func newBatcher(name string, cfg config.SinkConfig, send func([][]byte) error) *batcher {
+	b := &batcher{
+		name:      name,
+		send:      send,
+		batchSize: orInt(cfg.BatchSize, 100),
+		interval:  orDuration(cfg.FlushInterval, time.Second),
+		retries:   3,
+		backoff:   200 * time.Millisecond,
+		queue:     make(chan []byte, orInt(cfg.Buffer, 1024)),
+		flushCh:   make(chan chan struct{}),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		errEvery:  10 * time.Second,
+	}
+	go b.loop()
+	return b
+}
+
+func (b *batcher) Write(p []byte) (int, error) {
+	if len(bytes.TrimSpace(p)) == 0 {
+		return len(p), nil
+	}
+	// zap reuses its buffer after Write returns.
+	entry := append([]byte(nil), p...)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return 0, io.ErrClosedPipe
+	}
+	select {
+	case b.queue <- entry:
+	default:
+		b.dropped.Add(1)
+		b.logErrRateLimited("log sink queue full, dropping entries", "dropped", b.dropped.Load())
+	}
+	return len(p), nil
+}
+
+// Sync delivers everything queued so far.
+func (b *batcher) Sync() error {
+	done := make(chan struct{})
+	select {
+	case b.flushCh <- done:
+	case <-b.stopped:
+		return nil
+	}
+	select {
+	case <-done:
+	case <-b.stopped:
+	}
+	return nil
+}
+
+// Close delivers what is queued and stops the batcher.
+func (b *batcher) Close() error {
+	b.closeOnce.Do(func() {
+		b.mu.Lock()
+		b.closed = true
+		close(b.done)
+		b.mu.Unlock()
+	})
+	select {
+	case <-b.stopped:
+		return nil
+	case <-time.After(5 * time.Second):
+		return fmt.Errorf("log sink %s did not stop in time", b.name)
+	}
+}
+
+func (b *batcher) sinkStats() SinkStats {
+	return SinkStats{Type: b.name, Sent: b.sent.Load(), Dropped: b.dropped.Load(), Failed: b.failed.Load()}
+}
+
+func (b *batcher) loop() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, b.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			b.deliver(batch)
+			batch = make([][]byte, 0, b.batchSize)
+		}
+	}
+	// drain moves everything currently queued into batches.
+	drain := func() {
+		for {
+			select {
+			case entry := <-b.queue:
+				batch = append(batch, entry)
+				if len(batch) >= b.batchSize {
+					flush()
+				}
+			default:
+				flush()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case entry := <-b.queue:
+			batch = append(batch, entry)
+			if len(batch) >= b.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case done := <-b.flushCh:
+			drain()
+			close(done)
+		case <-b.done:
+			drain()
+			return
+		}
+	}
+}
+
+func (b *batcher) deliver(batch [][]byte) {
+	backoff := b.backoff
+	for attempt := 0; ; attempt++ {
+		err := b.send(batch)
+		if err == nil {
+			b.sent.Add(int64(len(batch)))
+			return
+		}
+		var perm *permanentError
+		if attempt >= b.retries || errors.As(err, &perm) {
+			b.failed.Add(int64(len(batch)))
+			b.logErrRateLimited("log sink delivery failed", "error", err.Error(), "entries", len(batch))
+			return
+		}
+		select {
+		case <-time.After(backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))):
+		case <-b.done:
+			// Shutting down: one last attempt without waiting.
+			if err := b.send(batch); err != nil {
+				b.failed.Add(int64(len(batch)))
+				return
+			}
+			b.sent.Add(int64(len(batch)))
+			return
+		}
+		backoff *= 2
+	}
+}
+
+func (b *batcher) logErrRateLimited(msg string, kv ...interface{}) {
+	b.errMu.Lock()
+	shouldLog := time.Since(b.lastErrTime) >= b.errEvery
+	if shouldLog {
+		b.lastErrTime = time.Now()
+	}
+	b.errMu.Unlock()
+	if shouldLog {
+		diag().Warnw(msg, append([]interface{}{"sink", b.name}, kv...)...)
+	}
+}
+
+// permanentError marks a delivery failure that retrying cannot fix, such as a rejected request.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// postJSON sends body to url with the configured headers. 4xx responses other than 429
+// are permanent errors; other failures are worth retrying.
+func postJSON(client *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%s: status %d: %s", url, resp.StatusCode, bytes.TrimSpace(msg))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return &permanentError{err: err}
+	}
+	return err
+}
+
+// activeSink is an opened sink together with its configured type.
+type activeSink struct {
+	typ  string
+	sink Sink
+}
+
+// openSinks opens every configured sink and wraps each in a core with its own threshold.
+// On error, the sinks opened so far are closed again.
+func openSinks(cfgs []config.SinkConfig, encCfg zapcore.EncoderConfig) ([]activeSink, []zapcore.Core, error) {
+	var (
+		opened []activeSink
+		cores  []zapcore.Core
+	)
+	for i, sc := range cfgs {
+		// Without a level of its own the sink takes whatever log.level lets through.
+		lvl := zapcore.DebugLevel
+		if sc.Level != "" {
+			var err error
+			if lvl, err = ParseLevel(sc.Level); err != nil {
+				closeSinks(opened)
+				return nil, nil, fmt.Errorf("log.sinks[%d]: %w", i, err)
+			}
+		}
+		s, err := openSink(sc)
+		if err != nil {
+			closeSinks(opened)
+			return nil, nil, fmt.Errorf("log.sinks[%d]: %w", i, err)
+		}
+		opened = append(opened, activeSink{typ: strings.ToLower(sc.Type), sink: s})
+		cores = append(cores, zapcore.NewCore(zapcore.NewJSONEncoder(encCfg), s, lvl))
+	}
+	return opened, cores, nil
+}
+
+// closeSinks flushes and closes sinks, returning the first error.
+func closeSinks(sinks []activeSink) error {
+	var first error
+	for _, s := range sinks {
+		if err := s.sink.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
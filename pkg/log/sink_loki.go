@@ -0,0 +1,116 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"traveler/pkg/config"
+)
+
+// lokiSink pushes entries to the Grafana Loki push API. Entries are grouped into streams by
+// their labels: the static Labels plus the values of LabelFields taken from each entry.
+type lokiSink struct {
+	*batcher
+	url         string
+	headers     map[string]string
+	labels      map[string]string
+	labelFields []string
+	client      *http.Client
+}
+
+// lokiPush is the JSON body of POST /loki/api/v1/push.
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"` // [unix nanoseconds, line]
+}
+
+func newLokiSink(cfg config.SinkConfig) (Sink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("loki sink requires a url")
+	}
+	url := strings.TrimRight(cfg.URL, "/")
+	if !strings.HasSuffix(url, "/loki/api/v1/push") {
+		url += "/loki/api/v1/push"
+	}
+	labels := make(map[string]string, len(cfg.Labels)+1)
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+	if len(labels) == 0 && len(cfg.LabelFields) == 0 {
+		// Loki rejects streams without labels.
+		labels["service_name"] = orString(cfg.ServiceName, "traveler")
+	}
+
+	s := &lokiSink{
+		url:         url,
+		headers:     cfg.Headers,
+		labels:      labels,
+		labelFields: cfg.LabelFields,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+	s.batcher = newBatcher("loki", cfg, s.send)
+	return s, nil
+}
+
+// streamLabels returns the labels of one entry.
+func (s *lokiSink) streamLabels(e sinkEntry) map[string]string {
+	labels := make(map[string]string, len(s.labels)+len(s.labelFields))
+	for k, v := range s.labels {
+		labels[k] = v
+	}
+	for _, f := range s.labelFields {
+		var v string
+		switch f {
+		case "level":
+			v = e.Level.String()
+		case "logger":
+			v = e.Logger
+		default:
+			if raw, ok := e.Fields[f]; ok {
+				v = fmt.Sprint(raw)
+			}
+		}
+		if v != "" {
+			labels[f] = v
+		}
+	}
+	return labels
+}
+
+func (s *lokiSink) send(batch [][]byte) error {
+	var (
+		push  lokiPush
+		index = map[string]int{}
+	)
+	for _, line := range batch {
+		e := parseSinkEntry(line)
+		labels := s.streamLabels(e)
+
+		var key strings.Builder
+		for _, k := range sortedKeys(labels) {
+			key.WriteString(k + "=" + labels[k] + "\x00")
+		}
+		i, ok := index[key.String()]
+		if !ok {
+			i = len(push.Streams)
+			index[key.String()] = i
+			push.Streams = append(push.Streams, lokiStream{Stream: labels})
+		}
+		push.Streams[i].Values = append(push.Streams[i].Values,
+			[2]string{strconv.FormatInt(e.Time.UnixNano(), 10), string(e.Raw)})
+	}
+
+	body, err := json.Marshal(push)
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	return postJSON(s.client, s.url, s.headers, body)
+}
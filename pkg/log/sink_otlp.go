@@ -0,0 +1,187 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"traveler/pkg/config"
+)
+
+// otlpSink exports entries as OTLP/HTTP logs in the JSON encoding, e.g. to an
+// OpenTelemetry Collector listening on :4318.
+type otlpSink struct {
+	*batcher
+	url     string
+	headers map[string]string
+	service string
+	client  *http.Client
+}
+
+// The following types mirror the subset of the OTLP logs JSON schema that is sent.
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"` // int64 is a string in OTLP JSON
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+	KvlistValue *otlpKvlist     `json:"kvlistValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+type otlpKvlist struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+func newOTLPSink(cfg config.SinkConfig) (Sink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("otlp sink requires a url")
+	}
+	url := strings.TrimRight(cfg.URL, "/")
+	if !strings.HasSuffix(url, "/v1/logs") {
+		url += "/v1/logs"
+	}
+	s := &otlpSink{
+		url:     url,
+		headers: cfg.Headers,
+		service: orString(cfg.ServiceName, "traveler"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	s.batcher = newBatcher("otlp", cfg, s.send)
+	return s, nil
+}
+
+func (s *otlpSink) send(batch [][]byte) error {
+	observed := strconv.FormatInt(time.Now().UnixNano(), 10)
+	records := make([]otlpLogRecord, 0, len(batch))
+	for _, line := range batch {
+		e := parseSinkEntry(line)
+		rec := otlpLogRecord{
+			TimeUnixNano:         strconv.FormatInt(e.Time.UnixNano(), 10),
+			ObservedTimeUnixNano: observed,
+			SeverityNumber:       otlpSeverity(e.Level),
+			SeverityText:         strings.ToUpper(e.Level.String()),
+			Body:                 otlpValue(e.Message),
+		}
+		if e.Logger != "" {
+			rec.Attributes = append(rec.Attributes, otlpKeyValue{Key: "logger", Value: otlpValue(e.Logger)})
+		}
+		if e.Caller != "" {
+			rec.Attributes = append(rec.Attributes, otlpKeyValue{Key: "caller", Value: otlpValue(e.Caller)})
+		}
+		for _, k := range sortedKeys(e.Fields) {
+			rec.Attributes = append(rec.Attributes, otlpKeyValue{Key: k, Value: otlpValue(e.Fields[k])})
+		}
+		records = append(records, rec)
+	}
+
+	req := otlpLogsRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			{Key: "service.name", Value: otlpValue(s.service)},
+		}},
+		ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: "traveler/pkg/log"}, LogRecords: records}},
+	}}}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	return postJSON(s.client, s.url, s.headers, body)
+}
+
+// otlpSeverity maps zap levels to OTLP severity numbers (DEBUG=5, INFO=9, WARN=13, ERROR=17, FATAL=21).
+func otlpSeverity(l zapcore.Level) int {
+	switch {
+	case l <= zapcore.DebugLevel:
+		return 5
+	case l == zapcore.InfoLevel:
+		return 9
+	case l == zapcore.WarnLevel:
+		return 13
+	case l == zapcore.ErrorLevel:
+		return 17
+	default:
+		return 21
+	}
+}
+
+// otlpValue converts a decoded JSON value into an OTLP AnyValue.
+func otlpValue(v interface{}) otlpAnyValue {
+	switch t := v.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &t}
+	case bool:
+		return otlpAnyValue{BoolValue: &t}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			s := strconv.FormatInt(i, 10)
+			return otlpAnyValue{IntValue: &s}
+		}
+		if f, err := t.Float64(); err == nil {
+			return otlpAnyValue{DoubleValue: &f}
+		}
+		s := t.String()
+		return otlpAnyValue{StringValue: &s}
+	case []interface{}:
+		arr := &otlpArrayValue{Values: make([]otlpAnyValue, 0, len(t))}
+		for _, el := range t {
+			arr.Values = append(arr.Values, otlpValue(el))
+		}
+		return otlpAnyValue{ArrayValue: arr}
+	case map[string]interface{}:
+		kv := &otlpKvlist{Values: make([]otlpKeyValue, 0, len(t))}
+		for _, k := range sortedKeys(t) {
+			kv.Values = append(kv.Values, otlpKeyValue{Key: k, Value: otlpValue(t[k])})
+		}
+		return otlpAnyValue{KvlistValue: kv}
+	case nil:
+		s := ""
+		return otlpAnyValue{StringValue: &s}
+	default:
+		s := fmt.Sprint(t)
+		return otlpAnyValue{StringValue: &s}
+	}
+}
@@ -0,0 +1,153 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"traveler/pkg/config"
+)
+
+// syslogTimeLayout is the RFC 5424 TIMESTAMP format (RFC 3339 with microseconds).
+const syslogTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+// syslogSink sends entries as RFC 5424 messages over UDP (one datagram each) or
+// TCP (octet-counting framing as in RFC 6587). The MSG part is the JSON entry.
+type syslogSink struct {
+	*batcher
+	network  string
+	address  string
+	facility int
+	hostname string
+	appName  string
+	procID   string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func newSyslogSink(cfg config.SinkConfig) (Sink, error) {
+	network := strings.ToLower(cfg.Network)
+	if network == "" {
+		network = "udp"
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("unsupported syslog network %q", cfg.Network)
+	}
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("syslog sink requires an address")
+	}
+	facility := cfg.Facility
+	if facility == 0 {
+		facility = 16 // local0
+	}
+	if facility < 0 || facility > 23 {
+		return nil, fmt.Errorf("invalid syslog facility %d", cfg.Facility)
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	s := &syslogSink{
+		network:  network,
+		address:  cfg.Address,
+		facility: facility,
+		hostname: hostname,
+		appName:  orString(cfg.ServiceName, "traveler"),
+		procID:   strconv.Itoa(os.Getpid()),
+	}
+	s.batcher = newBatcher("syslog", cfg, s.send)
+	return s, nil
+}
+
+func (s *syslogSink) Close() error {
+	err := s.batcher.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// format renders one entry as an RFC 5424 message.
+func (s *syslogSink) format(line []byte) []byte {
+	e := parseSinkEntry(line)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s - - ",
+		s.facility*8+syslogSeverity(e.Level),
+		e.Time.UTC().Format(syslogTimeLayout),
+		s.hostname, s.appName, s.procID)
+	b.Write(e.Raw)
+	return b.Bytes()
+}
+
+func (s *syslogSink) send(batch [][]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, 5*time.Second)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+
+	var err error
+	if s.network == "tcp" {
+		var buf bytes.Buffer
+		for _, line := range batch {
+			msg := s.format(line)
+			buf.WriteString(strconv.Itoa(len(msg)))
+			buf.WriteByte(' ')
+			buf.Write(msg)
+		}
+		_, err = s.conn.Write(buf.Bytes())
+	} else {
+		for _, line := range batch {
+			if _, err = s.conn.Write(s.format(line)); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		// Reconnect on the next attempt.
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// syslogSeverity maps zap levels to RFC 5424 severities.
+func syslogSeverity(l zapcore.Level) int {
+	switch {
+	case l <= zapcore.DebugLevel:
+		return 7 // debug
+	case l == zapcore.InfoLevel:
+		return 6 // informational
+	case l == zapcore.WarnLevel:
+		return 4 // warning
+	case l == zapcore.ErrorLevel:
+		return 3 // error
+	default:
+		return 2 // critical: dpanic, panic, fatal
+	}
+}
+
+func orString(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
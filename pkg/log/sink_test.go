@@ -0,0 +1,255 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"traveler/pkg/config"
+)
+
+// captureServer is a stand-in HTTP receiver that records request bodies and headers.
+type captureServer struct {
+	mu      sync.Mutex
+	bodies  [][]byte
+	headers []http.Header
+	paths   []string
+}
+
+func newCaptureServer(t *testing.T) (*captureServer, *httptest.Server) {
+	c := &captureServer{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		c.mu.Lock()
+		c.bodies = append(c.bodies, body)
+		c.headers = append(c.headers, r.Header.Clone())
+		c.paths = append(c.paths, r.URL.Path)
+		c.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+func (c *captureServer) requests() ([][]byte, []http.Header, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bodies, c.headers, c.paths
+}
+
+func sinkLine(level, msg string, fields string) []byte {
+	line := `{"level":"` + level + `","@timestamp":"2026-03-01T10:00:00.000Z","msg":"` + msg + `"`
+	if fields != "" {
+		line += "," + fields
+	}
+	return []byte(line + "}\n")
+}
+
+func TestLokiSink_GroupsStreamsByLabels(t *testing.T) {
+	capture, srv := newCaptureServer(t)
+
+	s, err := newLokiSink(config.SinkConfig{
+		URL:         srv.URL,
+		Labels:      map[string]string{"app": "traveler"},
+		LabelFields: []string{"level"},
+		Headers:     map[string]string{"X-Scope-OrgID": "tenant-1"},
+		BatchSize:   10,
+	})
+	require.NoError(t, err)
+
+	_, _ = s.Write(sinkLine("info", "first", `"user":"alice"`))
+	_, _ = s.Write(sinkLine("error", "boom", ""))
+	_, _ = s.Write(sinkLine("info", "second", ""))
+	require.NoError(t, s.Close())
+
+	bodies, headers, paths := capture.requests()
+	require.Len(t, bodies, 1)
+	assert.Equal(t, "/loki/api/v1/push", paths[0])
+	assert.Equal(t, "tenant-1", headers[0].Get("X-Scope-OrgID"))
+
+	var push lokiPush
+	require.NoError(t, json.Unmarshal(bodies[0], &push))
+	require.Len(t, push.Streams, 2)
+	assert.Equal(t, map[string]string{"app": "traveler", "level": "info"}, push.Streams[0].Stream)
+	require.Len(t, push.Streams[0].Values, 2)
+	assert.Equal(t, strconv.FormatInt(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC).UnixNano(), 10), push.Streams[0].Values[0][0])
+	assert.Contains(t, push.Streams[0].Values[0][1], `"user":"alice"`)
+	assert.Equal(t, map[string]string{"app": "traveler", "level": "error"}, push.Streams[1].Stream)
+	assert.Equal(t, int64(3), s.(*lokiSink).sinkStats().Sent)
+}
+
+func TestOTLPSink_ExportsLogRecords(t *testing.T) {
+	capture, srv := newCaptureServer(t)
+
+	s, err := newOTLPSink(config.SinkConfig{URL: srv.URL, ServiceName: "traveler-test"})
+	require.NoError(t, err)
+	_, _ = s.Write(sinkLine("warn", "slow request", `"duration_ms":250,"path":"/api/ping"`))
+	require.NoError(t, s.Sync())
+	require.NoError(t, s.Close())
+
+	bodies, _, paths := capture.requests()
+	require.Len(t, bodies, 1)
+	assert.Equal(t, "/v1/logs", paths[0])
+
+	var req otlpLogsRequest
+	require.NoError(t, json.Unmarshal(bodies[0], &req))
+	require.Len(t, req.ResourceLogs, 1)
+	assert.Equal(t, "service.name", req.ResourceLogs[0].Resource.Attributes[0].Key)
+	assert.Equal(t, "traveler-test", *req.ResourceLogs[0].Resource.Attributes[0].Value.StringValue)
+
+	rec := req.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, 13, rec.SeverityNumber)
+	assert.Equal(t, "WARN", rec.SeverityText)
+	assert.Equal(t, "slow request", *rec.Body.StringValue)
+	require.Len(t, rec.Attributes, 2)
+	assert.Equal(t, "duration_ms", rec.Attributes[0].Key)
+	assert.Equal(t, "250", *rec.Attributes[0].Value.IntValue)
+	assert.Equal(t, "/api/ping", *rec.Attributes[1].Value.StringValue)
+}
+
+func TestHTTPSinks_DoNotRetryRejectedRequests(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(srv.Close)
+
+	s, err := newLokiSink(config.SinkConfig{URL: srv.URL})
+	require.NoError(t, err)
+	_, _ = s.Write(sinkLine("info", "rejected", ""))
+	require.NoError(t, s.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, int64(1), s.(*lokiSink).sinkStats().Failed)
+}
+
+func TestSyslogSink_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pc.Close() })
+
+	s, err := newSyslogSink(config.SinkConfig{Network: "udp", Address: pc.LocalAddr().String(), ServiceName: "traveler"})
+	require.NoError(t, err)
+	_, _ = s.Write(sinkLine("error", "db down", ""))
+	require.NoError(t, s.Close())
+
+	buf := make([]byte, 4096)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	require.NoError(t, err)
+
+	msg := string(buf[:n])
+	// local0 (16) * 8 + error (3) = 131
+	assert.True(t, strings.HasPrefix(msg, "<131>1 2026-03-01T10:00:00.000000Z "), msg)
+	assert.Contains(t, msg, " traveler ")
+	assert.Contains(t, msg, `"msg":"db down"`)
+}
+
+func TestSyslogSink_TCPOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var msgs []string
+		for len(msgs) < 2 {
+			lenStr, err := r.ReadString(' ')
+			if err != nil {
+				break
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(lenStr))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				break
+			}
+			msgs = append(msgs, string(msg))
+		}
+		received <- msgs
+	}()
+
+	s, err := newSyslogSink(config.SinkConfig{Network: "tcp", Address: ln.Addr().String(), Facility: 1})
+	require.NoError(t, err)
+	_, _ = s.Write(sinkLine("debug", "one", ""))
+	_, _ = s.Write(sinkLine("info", "two", ""))
+	require.NoError(t, s.Close())
+
+	select {
+	case msgs := <-received:
+		require.Len(t, msgs, 2)
+		assert.True(t, strings.HasPrefix(msgs[0], "<15>1 "), msgs[0]) // user (1) * 8 + debug (7)
+		assert.True(t, strings.HasPrefix(msgs[1], "<14>1 "), msgs[1])
+	case <-time.After(2 * time.Second):
+		t.Fatal("no syslog messages received")
+	}
+}
+
+func TestInit_SinksApplyTheirOwnLevel(t *testing.T) {
+	capture, srv := newCaptureServer(t)
+	t.Cleanup(func() {
+		_ = Close()
+		_ = Init(&config.LogConfig{Level: "info"})
+	})
+
+	require.NoError(t, Init(&config.LogConfig{
+		Level: "debug",
+		Sinks: []config.SinkConfig{{Type: "loki", Level: "warn", URL: srv.URL}},
+	}))
+	Info("not shipped")
+	Warn("shipped")
+	require.NoError(t, Close())
+
+	bodies, _, _ := capture.requests()
+	require.Len(t, bodies, 1)
+	assert.NotContains(t, string(bodies[0]), "not shipped")
+	assert.Contains(t, string(bodies[0]), "shipped")
+}
+
+func TestInit_UnlevelledSinksFollowLogLevel(t *testing.T) {
+	capture, srv := newCaptureServer(t)
+	t.Cleanup(func() {
+		_ = Close()
+		_ = Init(&config.LogConfig{Level: "info"})
+	})
+
+	require.NoError(t, Init(&config.LogConfig{
+		Level: "debug",
+		Sinks: []config.SinkConfig{{Type: "loki", URL: srv.URL}},
+	}))
+	Debug("debug shipped")
+	require.NoError(t, Close())
+
+	bodies, _, _ := capture.requests()
+	require.Len(t, bodies, 1)
+	assert.Contains(t, string(bodies[0]), "debug shipped")
+}
+
+func TestInit_RejectsUnknownSinkType(t *testing.T) {
+	t.Cleanup(func() { _ = Init(&config.LogConfig{Level: "info"}) })
+	err := Init(&config.LogConfig{Sinks: []config.SinkConfig{{Type: "carrier-pigeon"}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown log sink type")
+}